@@ -0,0 +1,144 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// AdditionalRoots returns the roots of the Merkle Tree under the additional hash functions,
+// in the order of AdditionalHashFuncs in the configuration, or nil if there is no additional hash function.
+func (m *MerkleTree) AdditionalRoots() [][]byte {
+	return m.additionalRoots
+}
+
+// additionalLeavesFromBytes computes the leaves of the serialized data block at the leaf index
+// under the additional hash functions.
+func (m *MerkleTree) additionalLeavesFromBytes(leaf, blockBytes []byte, idx int) ([][]byte, error) {
+	leaves := make([][]byte, len(m.AdditionalHashFuncs))
+	if m.DisableLeafHashing {
+		for k := range leaves {
+			leaves[k] = leaf
+		}
+		return leaves, nil
+	}
+	if m.BindLeafIndex {
+		blockBytes = bindLeafIndex(blockBytes, idx)
+	}
+	var err error
+	for k, hashFunc := range m.AdditionalHashFuncs {
+		if leaves[k], err = m.hashWith(hashFunc, blockBytes); err != nil {
+			return nil, err
+		}
+	}
+	return leaves, nil
+}
+
+// additionalRootsGen computes the roots under the additional hash functions from the additional leaves,
+// folding the levels in the same way as the levels of the tree, and releases the additional leaves.
+func (m *MerkleTree) additionalRootsGen() (err error) {
+	defer func() {
+		m.additionalLeaves = nil
+	}()
+	m.additionalRoots = make([][]byte, len(m.AdditionalHashFuncs))
+	for k := range m.AdditionalHashFuncs {
+		level := make([][]byte, m.NumLeaves, m.NumLeaves+1)
+		for i := range level {
+			level[i] = m.additionalLeaves[i][k]
+		}
+		for prevLen := len(level); prevLen > 1; prevLen = len(level) {
+			if level, prevLen, err = m.fixOddWith(m.AdditionalHashFuncs[k], level, prevLen); err != nil {
+				return
+			}
+			if m.RunInParallel {
+				level, err = m.additionalLevelParallel(k, level, prevLen)
+			} else {
+				level, err = m.additionalLevel(k, level, prevLen)
+			}
+			if err != nil {
+				return
+			}
+		}
+		m.additionalRoots[k] = level[0]
+	}
+	return nil
+}
+
+// additionalLevel computes the parent level of the level under the additional hash function at index k.
+func (m *MerkleTree) additionalLevel(k int, level [][]byte, prevLen int) ([][]byte, error) {
+	var (
+		hashFunc = m.AdditionalHashFuncs[k]
+		parents  = make([][]byte, prevLen>>1, prevLen>>1+1)
+		err      error
+	)
+	for i := 0; i < prevLen; i += 2 {
+		if err = m.checkCanceled(); err != nil {
+			return nil, err
+		}
+		if level[i+1] == nil {
+			parents[i>>1] = level[i] // The node is promoted.
+			continue
+		}
+		if parents[i>>1], err = m.hashWith(hashFunc, m.concatFunc(level[i], level[i+1])); err != nil {
+			return nil, err
+		}
+	}
+	return parents, nil
+}
+
+func (m *MerkleTree) additionalLevelParallel(k int, level [][]byte, prevLen int) ([][]byte, error) {
+	parents := make([][]byte, prevLen>>1, prevLen>>1+1)
+	argList := m.pairTasks(prevLen>>1, argType{
+		mt:         m,
+		byteField1: level,
+		byteField2: parents,
+		intField4:  k, // index of the additional hash function
+	})
+	if err := m.mapWorkers(additionalLevelHandler, argList); err != nil {
+		return nil, err
+	}
+	return parents, nil
+}
+
+// additionalLevelHandler computes the parent level under an additional hash function in parallel.
+func additionalLevelHandler(arg argType) (err error) {
+	defer recoverError(&err)
+	var (
+		mt       = arg.mt
+		level    = arg.byteField1
+		parents  = arg.byteField2
+		batcher  = arg.batcher
+		hashFunc = mt.AdditionalHashFuncs[arg.intField4]
+	)
+	for start, end, ok := batcher.grab(); ok; start, end, ok = batcher.grab() {
+		for i := start; i < end; i += 2 {
+			if err = mt.checkCanceled(); err != nil {
+				return
+			}
+			if level[i+1] == nil {
+				parents[i>>1] = level[i] // The node is promoted.
+				continue
+			}
+			if parents[i>>1], err = mt.hashWith(hashFunc, mt.concatFunc(level[i], level[i+1])); err != nil {
+				return
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+type countingBlock struct {
+	DataBlock
+	count *atomic.Int64
+}
+
+func (b countingBlock) Serialize() ([]byte, error) {
+	b.count.Add(1)
+	return b.DataBlock.Serialize()
+}
+
+func sha512_256HashFunc(data []byte) ([]byte, error) {
+	h := sha512.Sum512_256(data)
+	return h[:], nil
+}
+
+func TestMerkleTree_AdditionalRoots(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		numBlocks int
+	}{
+		{"serial", &Config{}, 13},
+		{"parallel", &Config{RunInParallel: true, NumRoutines: 3}, 13},
+		{"two_blocks", &Config{}, 2},
+		{"tree_build", &Config{Mode: ModeTreeBuild, RunInParallel: true}, 100},
+		{"proof_gen_and_tree_build", &Config{Mode: ModeProofGenAndTreeBuild}, 33},
+		{"sorted_bound", &Config{SortSiblingPairs: true, BindLeafIndex: true}, 9},
+		{"leaf_hashing_disabled", &Config{DisableLeafHashing: true, RunInParallel: true}, 7},
+		{"hasher", &Config{Hasher: sha256.New}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var count atomic.Int64
+			blocks := dataBlocks(tt.numBlocks)
+			counted := make([]DataBlock, len(blocks))
+			for i, block := range blocks {
+				counted[i] = countingBlock{block, &count}
+			}
+			config := *tt.config
+			config.AdditionalHashFuncs = []TypeHashFunc{sha512HashFunc, sha512_256HashFunc}
+			m, err := New(&config, counted)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := count.Load(); got != int64(len(blocks)) {
+				t.Errorf("Serialize() calls = %d, want %d", got, len(blocks))
+			}
+			primary := *tt.config
+			want, err := New(&primary, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Errorf("Root = %x, want %x", m.Root, want.Root)
+			}
+			roots := m.AdditionalRoots()
+			if len(roots) != len(config.AdditionalHashFuncs) {
+				t.Fatalf("len(AdditionalRoots()) = %d, want %d", len(roots), len(config.AdditionalHashFuncs))
+			}
+			for k, hashFunc := range config.AdditionalHashFuncs {
+				additional := *tt.config
+				additional.HashFunc, additional.Hasher = hashFunc, nil
+				want, err := New(&additional, blocks)
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				if !bytes.Equal(roots[k], want.Root) {
+					t.Errorf("AdditionalRoots()[%d] = %x, want %x", k, roots[k], want.Root)
+				}
+			}
+			if m.additionalLeaves != nil {
+				t.Error("additional leaves are retained after the tree building")
+			}
+		})
+	}
+}
+
+func TestMerkleTree_AdditionalRoots_fromReader(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 50)
+	config := &Config{AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}}
+	m, _, err := NewFromReader(config, bytes.NewReader(data), 64)
+	if err != nil {
+		t.Fatalf("NewFromReader() error = %v", err)
+	}
+	want, _, err := NewFromReader(&Config{HashFunc: sha512HashFunc}, bytes.NewReader(data), 64)
+	if err != nil {
+		t.Fatalf("NewFromReader() error = %v", err)
+	}
+	if roots := m.AdditionalRoots(); len(roots) != 1 || !bytes.Equal(roots[0], want.Root) {
+		t.Errorf("AdditionalRoots() = %x, want [%x]", roots, want.Root)
+	}
+}
+
+func TestMerkleTree_AdditionalRoots_errors(t *testing.T) {
+	blocks := dataBlocks(5)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if roots := m.AdditionalRoots(); roots != nil {
+		t.Errorf("AdditionalRoots() = %x, want nil", roots)
+	}
+	failingHashFunc := func(data []byte) ([]byte, error) {
+		if len(data) > defaultHashLen {
+			return nil, errors.New("test error")
+		}
+		return sha512HashFunc(data)
+	}
+	for _, parallel := range []bool{false, true} {
+		config := &Config{RunInParallel: parallel, AdditionalHashFuncs: []TypeHashFunc{failingHashFunc}}
+		if _, err = New(config, blocks); err == nil {
+			t.Errorf("New() with a failing additional hash function, parallel %v: error = nil", parallel)
+		}
+	}
+	m, err = New(&Config{Mode: ModeTreeBuild, AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = m.UpdateBatch(map[int]DataBlock{0: blocks[1]}); err == nil {
+		t.Error("UpdateBatch() error = nil, want an error with additional hash functions")
+	}
+}
@@ -0,0 +1,100 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"time"
+)
+
+// TypeAnomalyKind is the kind of an anomaly reported to OnAnomaly.
+type TypeAnomalyKind int
+
+const (
+	// AnomalySlowSerialize is reported when the Serialize call of a data block exceeds AnomalyDuration.
+	AnomalySlowSerialize TypeAnomalyKind = iota + 1
+	// AnomalySlowHash is reported when the computation of a leaf from the serialized data block,
+	// or from the reader of an MmapBlock, exceeds AnomalyDuration.
+	AnomalySlowHash
+	// AnomalyLargeLeaf is reported when a data block serializes to more than AnomalyLeafSize bytes.
+	AnomalyLargeLeaf
+)
+
+func (k TypeAnomalyKind) String() string {
+	switch k {
+	case AnomalySlowSerialize:
+		return "slow serialize"
+	case AnomalySlowHash:
+		return "slow hash"
+	case AnomalyLargeLeaf:
+		return "large leaf"
+	default:
+		return fmt.Sprintf("TypeAnomalyKind(%d)", int(k))
+	}
+}
+
+// Anomaly is an anomaly of the computation of a leaf, reported to OnAnomaly.
+type Anomaly struct {
+	// Kind is the kind of the anomaly.
+	Kind TypeAnomalyKind
+	// Index is the leaf index of the data block.
+	Index int
+	// Duration is the measured duration of the call, for AnomalySlowSerialize and AnomalySlowHash.
+	Duration time.Duration
+	// Size is the size in bytes of the serialized data block, or -1 if it is not known,
+	// e.g. for AnomalySlowSerialize when the Serialize call fails.
+	Size int64
+}
+
+// anomalyStart returns the start time of a call timed for AnomalyDuration,
+// or the zero time if the calls are not timed, so that no clock is read when OnAnomaly is not set.
+func (m *MerkleTree) anomalyStart() time.Time {
+	if m.OnAnomaly == nil || m.AnomalyDuration <= 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// checkSlow reports an anomaly of the kind if the call started at the start time exceeded AnomalyDuration.
+func (m *MerkleTree) checkSlow(kind TypeAnomalyKind, idx int, start time.Time, size int64) {
+	if start.IsZero() {
+		return
+	}
+	if elapsed := time.Since(start); elapsed > m.AnomalyDuration {
+		m.reportAnomaly(Anomaly{Kind: kind, Index: idx, Duration: elapsed, Size: size})
+	}
+}
+
+// checkLarge reports an AnomalyLargeLeaf if the size exceeds AnomalyLeafSize.
+func (m *MerkleTree) checkLarge(idx int, size int64) {
+	if m.OnAnomaly != nil && m.AnomalyLeafSize > 0 && size > int64(m.AnomalyLeafSize) {
+		m.reportAnomaly(Anomaly{Kind: AnomalyLargeLeaf, Index: idx, Size: size})
+	}
+}
+
+// reportAnomaly calls OnAnomaly, serializing the calls of the parallel computations.
+func (m *MerkleTree) reportAnomaly(anomaly Anomaly) {
+	m.onAnomalyMu.Lock()
+	defer m.onAnomalyMu.Unlock()
+	m.OnAnomaly(anomaly)
+}
@@ -0,0 +1,123 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// slowBlock is a data block whose Serialize call sleeps.
+type slowBlock struct {
+	mock.DataBlock
+	delay time.Duration
+}
+
+func (b *slowBlock) Serialize() ([]byte, error) {
+	time.Sleep(b.delay)
+	return b.Data, nil
+}
+
+func TestConfig_OnAnomaly(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	blocks := dataBlocks(9)
+	slowData := blocks[6].(*mock.DataBlock).Data
+	slowHashFunc := func(data []byte) ([]byte, error) {
+		if bytes.Equal(data, slowData) {
+			time.Sleep(delay)
+		}
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}
+	blocks[2] = &slowBlock{DataBlock: mock.DataBlock{Data: []byte("slow")}, delay: delay}
+	blocks[4] = &mock.DataBlock{Data: make([]byte, 1000)}
+	for _, parallel := range []bool{false, true} {
+		var anomalies []Anomaly
+		config := &Config{
+			HashFunc:        slowHashFunc,
+			RunInParallel:   parallel,
+			NumRoutines:     3,
+			AnomalyDuration: delay / 2,
+			AnomalyLeafSize: 500,
+			OnAnomaly: func(anomaly Anomaly) {
+				anomalies = append(anomalies, anomaly)
+			},
+		}
+		if _, err := New(config, blocks); err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].Index < anomalies[j].Index })
+		want := []Anomaly{
+			{Kind: AnomalySlowSerialize, Index: 2, Size: 4},
+			{Kind: AnomalyLargeLeaf, Index: 4, Size: 1000},
+			{Kind: AnomalySlowHash, Index: 6, Size: 100},
+		}
+		if len(anomalies) != len(want) {
+			t.Fatalf("parallel %v: anomalies = %+v, want %+v", parallel, anomalies, want)
+		}
+		for i, anomaly := range anomalies {
+			if anomaly.Kind != want[i].Kind || anomaly.Index != want[i].Index || anomaly.Size != want[i].Size {
+				t.Errorf("parallel %v: anomaly %d = %+v, want %+v", parallel, i, anomaly, want[i])
+			}
+			if anomaly.Kind != AnomalyLargeLeaf && anomaly.Duration < delay {
+				t.Errorf("parallel %v: anomaly %d duration = %v, want at least %v", parallel, i, anomaly.Duration, delay)
+			}
+		}
+	}
+}
+
+func TestConfig_OnAnomaly_disabled(t *testing.T) {
+	calls := 0
+	blocks := dataBlocks(5)
+	blocks[1] = &mock.DataBlock{Data: make([]byte, 1000)}
+	// A threshold of 0 disables its check.
+	config := &Config{OnAnomaly: func(Anomaly) { calls++ }}
+	if _, err := New(config, blocks); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("OnAnomaly called %d times, want 0", calls)
+	}
+	m := &MerkleTree{Config: &Config{AnomalyDuration: time.Nanosecond, AnomalyLeafSize: 1}}
+	if !m.anomalyStart().IsZero() {
+		t.Errorf("anomalyStart() without OnAnomaly is not the zero time")
+	}
+}
+
+func TestTypeAnomalyKind_String(t *testing.T) {
+	for kind, want := range map[TypeAnomalyKind]string{
+		AnomalySlowSerialize: "slow serialize",
+		AnomalySlowHash:      "slow hash",
+		AnomalyLargeLeaf:     "large leaf",
+		TypeAnomalyKind(9):   "TypeAnomalyKind(9)",
+	} {
+		if got := kind.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
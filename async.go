@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "context"
+
+// Future is the handle of a Merkle Tree built asynchronously by BuildAsync.
+type Future struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	tree   *MerkleTree
+	err    error
+}
+
+// BuildAsync starts building a new Merkle Tree with specified configuration in a new goroutine,
+// and returns a Future to await the result.
+// The configuration is copied, so the same configuration can be used by concurrent builds.
+// The build stops with the context error if the context is canceled or the Future is canceled,
+// and panics in the user-provided functions, e.g. HashFunc or Serialize, are returned as errors.
+func BuildAsync(ctx context.Context, config *Config, blocks []DataBlock) *Future {
+	if config == nil {
+		config = new(Config)
+	}
+	configCopy := *config
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	go func() {
+		defer close(f.done)
+		defer cancel()
+		// The closure drops the data blocks, so that they are released once hashed as with New.
+		b := blocks
+		blocks = nil
+		f.tree, f.err = buildRecovered(ctx, &configCopy, b)
+	}()
+	return f
+}
+
+// buildRecovered builds the Merkle Tree, converting a panic in the calling goroutine into an error.
+func buildRecovered(ctx context.Context, config *Config, blocks []DataBlock) (m *MerkleTree, err error) {
+	defer func() {
+		if err != nil {
+			m = nil
+		}
+	}()
+	defer recoverError(&err)
+	return newWithContext(ctx, config, blocks, 0)
+}
+
+// Done returns a channel that is closed when the build finishes.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result waits for the build to finish and returns the Merkle Tree or the build error.
+func (f *Future) Result() (*MerkleTree, error) {
+	<-f.done
+	return f.tree, f.err
+}
+
+// Cancel cancels the build. The workers stop promptly and Result returns context.Canceled.
+// Canceling a finished build has no effect.
+func (f *Future) Cancel() {
+	f.cancel()
+}
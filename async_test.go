@@ -0,0 +1,149 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuildAsync(t *testing.T) {
+	blocks := dataBlocks(1001)
+	configs := []*Config{
+		nil,
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild},
+		{RunInParallel: true, NumRoutines: 4},
+		{Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 3},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+	}
+	want, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	futures := make([]*Future, 0, 2*len(configs))
+	// Each configuration is used by two concurrent builds.
+	for _, config := range configs {
+		futures = append(futures,
+			BuildAsync(context.Background(), config, blocks),
+			BuildAsync(context.Background(), config, blocks),
+		)
+	}
+	for i, f := range futures {
+		<-f.Done()
+		got, err := f.Result()
+		if err != nil {
+			t.Fatalf("future %d Result() error = %v", i, err)
+		}
+		if !bytes.Equal(got.Root, want.Root) {
+			t.Errorf("future %d Result() root = %x, want %x", i, got.Root, want.Root)
+		}
+	}
+}
+
+func slowHashFunc(data []byte) ([]byte, error) {
+	time.Sleep(100 * time.Microsecond)
+	return defaultHashFuncParallel(data)
+}
+
+func TestFuture_Cancel(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{
+			name:   "test_proof_gen",
+			config: &Config{HashFunc: slowHashFunc},
+		},
+		{
+			name:   "test_tree_build",
+			config: &Config{HashFunc: slowHashFunc, Mode: ModeTreeBuild},
+		},
+		{
+			name:   "test_proof_gen_parallel",
+			config: &Config{HashFunc: slowHashFunc, RunInParallel: true, NumRoutines: 4},
+		},
+		{
+			name:   "test_tree_build_parallel",
+			config: &Config{HashFunc: slowHashFunc, Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 4},
+		},
+	}
+	blocks := dataBlocks(100000)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := BuildAsync(context.Background(), tt.config, blocks)
+			time.Sleep(10 * time.Millisecond)
+			f.Cancel()
+			select {
+			case <-f.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatalf("build not stopped after Cancel()")
+			}
+			if m, err := f.Result(); !errors.Is(err, context.Canceled) || m != nil {
+				t.Errorf("Result() = %v, %v, want nil, %v", m, err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestBuildAsync_canceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BuildAsync(ctx, nil, dataBlocks(10)).Result(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Result() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestBuildAsync_panic(t *testing.T) {
+	panicHashFunc := func([]byte) ([]byte, error) {
+		panic("test_hash_func_panic")
+	}
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{
+			name:   "test_serial",
+			config: &Config{HashFunc: panicHashFunc},
+		},
+		{
+			name:   "test_parallel",
+			config: &Config{HashFunc: panicHashFunc, RunInParallel: true, NumRoutines: 4},
+		},
+		{
+			name:   "test_tree_build_parallel",
+			config: &Config{HashFunc: panicHashFunc, Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := BuildAsync(context.Background(), tt.config, dataBlocks(100)).Result()
+			if err == nil || m != nil {
+				t.Errorf("Result() = %v, %v, want an error", m, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/txaty/gool"
+)
+
+// NewBatch generates one Merkle Tree for each set of data blocks with the same configuration,
+// e.g. thousands of small trees, initializing the configuration once and creating one worker pool for all the sets
+// instead of one per tree built in parallel. Each tree is built by New and owns its leaves, nodes and proofs,
+// so the buffers are allocated for each set, as with New.
+// If RunInParallel is true, the sets are built in parallel by one worker pool, each set without parallelization,
+// so the hash function must be concurrent safe and the trees have RunInParallel false in their configurations.
+// Each tree has its own copy of the configuration.
+// On error, it returns the error of the first failing set, with the index of the set.
+func NewBatch(config *Config, blockSets [][]DataBlock) ([]*MerkleTree, error) {
+	if config == nil {
+		config = new(Config)
+	}
+	template := *config
+	parallel, numWorkers := template.RunInParallel, template.NumRoutines
+	template.RunInParallel = false
+	(&MerkleTree{Config: &template}).initConfig()
+	var (
+		trees = make([]*MerkleTree, len(blockSets))
+		errs  = make([]error, len(blockSets))
+	)
+	buildSets := func(start, step int) error {
+		for i := start; i < len(blockSets); i += step {
+			c := template
+			trees[i], errs[i] = New(&c, blockSets[i])
+		}
+		return nil
+	}
+	if parallel {
+		if numWorkers <= 0 {
+			numWorkers = runtime.NumCPU()
+		}
+		if maxProcs := runtime.GOMAXPROCS(0); numWorkers > maxProcs {
+			numWorkers = maxProcs
+		}
+		if numWorkers > len(blockSets) {
+			numWorkers = len(blockSets)
+		}
+		wp := gool.NewPool[int, error](numWorkers, 0)
+		defer wp.Close()
+		starts := make([]int, numWorkers)
+		for i := range starts {
+			starts[i] = i
+		}
+		wp.Map(func(start int) error {
+			return buildSets(start, numWorkers)
+		}, starts)
+	} else {
+		_ = buildSets(0, 1)
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("block set %d: %w", i, err)
+		}
+	}
+	return trees, nil
+}
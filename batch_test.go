@@ -0,0 +1,102 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func blockSets(numSets, numBlocks int) [][]DataBlock {
+	sets := make([][]DataBlock, numSets)
+	for i := range sets {
+		sets[i] = dataBlocks(numBlocks)
+	}
+	return sets
+}
+
+func TestNewBatch(t *testing.T) {
+	sets := append(blockSets(25, 5), blockSets(25, 16)...)
+	for _, config := range []*Config{
+		nil,
+		{RunInParallel: true, NumRoutines: 4},
+		{Mode: ModeProofGenAndTreeBuild, SortSiblingPairs: true, RunInParallel: true},
+	} {
+		trees, err := NewBatch(config, sets)
+		if err != nil {
+			t.Fatalf("NewBatch() error = %v", err)
+		}
+		if len(trees) != len(sets) {
+			t.Fatalf("NewBatch() returned %d trees, want %d", len(trees), len(sets))
+		}
+		for i, set := range sets {
+			var c Config
+			if config != nil {
+				c = *config
+			}
+			want, err := New(&c, set)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(trees[i].Root, want.Root) {
+				t.Errorf("NewBatch() root of set %d differs from New()", i)
+			}
+			if trees[i].Config == trees[0].Config && i > 0 {
+				t.Errorf("trees 0 and %d share their configuration", i)
+			}
+		}
+	}
+	sets[7] = sets[7][:1]
+	if _, err := NewBatch(&Config{RunInParallel: true}, sets); err == nil || !strings.Contains(err.Error(), "set 7") {
+		t.Errorf("NewBatch() error = %v, want error of set 7", err)
+	}
+	if trees, err := NewBatch(nil, nil); err != nil || len(trees) != 0 {
+		t.Errorf("NewBatch() of no sets = %v, %v, want no trees", trees, err)
+	}
+}
+
+// BenchmarkNewBatch builds the sets with one worker pool, to compare with BenchmarkNewBatch_loop,
+// which creates a worker pool for each set. The buffers of the trees are allocated for each set in both.
+func BenchmarkNewBatch(b *testing.B) {
+	sets := blockSets(10000, 16)
+	config := &Config{RunInParallel: true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewBatch(config, sets); err != nil {
+			b.Errorf("NewBatch() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkNewBatch_loop(b *testing.B) {
+	sets := blockSets(10000, 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, set := range sets {
+			if _, err := New(&Config{RunInParallel: true}, set); err != nil {
+				b.Errorf("New() error = %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ChainLink is a link of a proof chain, whose proof is applied to the output of the previous link,
+// e.g. the proof of a subtree root in the tree of the subtree roots.
+type ChainLink struct {
+	Proof *Proof // proof from the output of the previous link to the root of this link.
+	// HashInput hashes the output of the previous link into the leaf of this link first,
+	// as when the root of the previous tree is a data block of this tree, see NestedProof.
+	HashInput bool
+}
+
+// VerifyChain verifies the leaf hash against the final root through the chain of links,
+// e.g. from the leaf to its subtree root with the first proof, then to the global root with the second proof.
+// The output of each link must have the size of the leaf hash, and the chain must not be empty.
+// Misordered links fail the verification. If the hash function is nil, DefaultHashFunc is used.
+func VerifyChain(leafHash []byte, links []ChainLink, finalRoot []byte, hashFunc TypeHashFunc) (bool, error) {
+	if leafHash == nil {
+		return false, errNilInput("leaf hash")
+	}
+	if finalRoot == nil {
+		return false, errNilInput("final root")
+	}
+	if len(links) == 0 {
+		return false, errors.New("proof chain is empty")
+	}
+	config := verifyConfig(&Config{HashFunc: hashFunc})
+	// The depth of every link is checked before any hashing.
+	for i, link := range links {
+		if link.Proof == nil {
+			return false, errNilInput(fmt.Sprintf("proof of chain link %d", i))
+		}
+		if err := checkProofDepth(link.Proof, config); err != nil {
+			return false, fmt.Errorf("chain link %d: %w", i, err)
+		}
+	}
+	result := leafHash
+	for i, link := range links {
+		var err error
+		if link.HashInput {
+			if result, err = leafFromBytes(result, link.Proof.Index, config); err != nil {
+				return false, err
+			}
+		}
+		if result, err = rootFromLeaf(result, link.Proof, config); err != nil {
+			return false, fmt.Errorf("chain link %d: %w", i, err)
+		}
+		if len(result) != len(leafHash) {
+			return false, fmt.Errorf("%w: chain link %d outputs %d bytes but the leaf hash is %d bytes",
+				ErrInconsistentHashSize, i, len(result), len(leafHash))
+		}
+	}
+	return rootMatches(result, finalRoot, config), nil
+}
@@ -0,0 +1,159 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestVerifyChain(t *testing.T) {
+	subSizes := []int{4, 7, 2}
+	subTrees := make([]*MerkleTree, len(subSizes))
+	subRoots := make([][]byte, len(subSizes))
+	subRootBlocks := make([]DataBlock, len(subSizes))
+	for i, size := range subSizes {
+		var err error
+		if subTrees[i], err = New(nil, dataBlocks(size)); err != nil {
+			t.Fatalf("New() subtree error = %v", err)
+		}
+		subRoots[i] = subTrees[i].Root
+		subRootBlocks[i] = &mock.DataBlock{Data: subTrees[i].Root}
+	}
+	// The global tree of the subtree roots as its leaves, and of the subtree roots as its data blocks.
+	global, err := NewFromLeafHashes(nil, subRoots)
+	if err != nil {
+		t.Fatalf("NewFromLeafHashes() error = %v", err)
+	}
+	globalHashed, err := New(nil, subRootBlocks)
+	if err != nil {
+		t.Fatalf("New() global error = %v", err)
+	}
+	leafHash := subTrees[1].Leaves[5]
+	tests := []struct {
+		name     string
+		leafHash []byte
+		links    []ChainLink
+		root     []byte
+		hashFunc TypeHashFunc
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "test_ok",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}, {Proof: global.Proofs[1]}},
+			root:     global.Root,
+			want:     true,
+		},
+		{
+			name:     "test_ok_hash_input",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}, {Proof: globalHashed.Proofs[1], HashInput: true}},
+			root:     globalHashed.Root,
+			want:     true,
+		},
+		{
+			name:     "test_ok_single_link",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}},
+			root:     subTrees[1].Root,
+			hashFunc: DefaultHashFunc,
+			want:     true,
+		},
+		{
+			name:     "test_misordered_links",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: global.Proofs[1]}, {Proof: subTrees[1].Proofs[5]}},
+			root:     global.Root,
+			want:     false,
+		},
+		{
+			name:     "test_wrong_subtree",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}, {Proof: global.Proofs[2]}},
+			root:     global.Root,
+			want:     false,
+		},
+		{
+			name:     "test_missing_hash_input",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}, {Proof: globalHashed.Proofs[1]}},
+			root:     globalHashed.Root,
+			want:     false,
+		},
+		{
+			name:     "test_empty_chain",
+			leafHash: leafHash,
+			root:     global.Root,
+			wantErr:  true,
+		},
+		{
+			name:    "test_nil_leaf_hash",
+			links:   []ChainLink{{Proof: subTrees[1].Proofs[5]}},
+			root:    subTrees[1].Root,
+			wantErr: true,
+		},
+		{
+			name:     "test_nil_proof",
+			leafHash: leafHash,
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}, {}},
+			root:     global.Root,
+			wantErr:  true,
+		},
+		{
+			name:     "test_intermediate_output_size",
+			leafHash: leafHash[:16],
+			links:    []ChainLink{{Proof: &Proof{}, HashInput: true}, {Proof: &Proof{}}},
+			root:     global.Root,
+			wantErr:  true,
+		},
+		{
+			name:     "test_sibling_size",
+			leafHash: leafHash[:16],
+			links:    []ChainLink{{Proof: subTrees[1].Proofs[5]}},
+			root:     subTrees[1].Root,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyChain(tt.leafHash, tt.links, tt.root, tt.hashFunc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyChain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyChain() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChain_inconsistentHashSize(t *testing.T) {
+	links := []ChainLink{{Proof: &Proof{}, HashInput: true}}
+	if _, err := VerifyChain(make([]byte, 16), links, make([]byte, 16), nil); !errors.Is(err, ErrInconsistentHashSize) {
+		t.Errorf("VerifyChain() error = %v, want %v", err, ErrInconsistentHashSize)
+	}
+}
@@ -0,0 +1,89 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ToCircuitInputs returns the proof as the witness of a zero-knowledge circuit:
+// the siblings and the path bits ordered from the leaf up to the root, whatever the orientation of the proof.
+// pathBits[i] reports whether the node at level i is the right child, i.e. whether the sibling at level i is on the left,
+// the complement of bit i of Path, so that the path bits of an unpadded proof are the bits of the leaf index.
+// The siblings are copied, so that they can be converted to field elements in place.
+func (p *Proof) ToCircuitInputs() (siblings [][]byte, pathBits []bool, err error) {
+	if p == nil {
+		return nil, nil, errors.New("proof is nil")
+	}
+	if len(p.Siblings) > maxProofSiblings {
+		return nil, nil, fmt.Errorf("%w: %d siblings, the path holds at most %d",
+			ErrProofTooDeep, len(p.Siblings), maxProofSiblings)
+	}
+	proof := p
+	if p.RootToLeaf {
+		proof = p.Reverse()
+	}
+	siblings = make([][]byte, len(proof.Siblings))
+	pathBits = make([]bool, len(proof.Siblings))
+	for i, sibling := range proof.Siblings {
+		if len(sibling) == 0 {
+			return nil, nil, fmt.Errorf("sibling at level %d is empty", i)
+		}
+		siblings[i] = append([]byte(nil), sibling...)
+		pathBits[i] = proof.Path>>i&1 == 0
+	}
+	return siblings, pathBits, nil
+}
+
+// PadCircuitInputs pads the circuit inputs returned by ToCircuitInputs to the fixed depth of a circuit,
+// as circuits take witnesses of a fixed size. Each padded level has emptyNode as its sibling, on the right:
+// the circuit hashes the node with emptyNode above the actual root, up to the depth,
+// so the root of the circuit is the root of the tree as the leftmost subtree of a tree of the fixed depth
+// whose other nodes are emptyNode. emptyNode must be as long as the siblings, e.g. the zero field element.
+// The inputs are not modified.
+func PadCircuitInputs(siblings [][]byte, pathBits []bool, depth int, emptyNode []byte) ([][]byte, []bool, error) {
+	if len(pathBits) != len(siblings) {
+		return nil, nil, fmt.Errorf("got %d path bits for %d siblings", len(pathBits), len(siblings))
+	}
+	if len(siblings) > depth {
+		return nil, nil, fmt.Errorf("%w: %d siblings, the circuit depth is %d", ErrProofTooDeep, len(siblings), depth)
+	}
+	if len(emptyNode) == 0 {
+		return nil, nil, errors.New("empty node is empty")
+	}
+	for i, sibling := range siblings {
+		if len(sibling) != len(emptyNode) {
+			return nil, nil, fmt.Errorf("sibling at level %d has %d bytes, the empty node has %d",
+				i, len(sibling), len(emptyNode))
+		}
+	}
+	paddedSiblings := make([][]byte, depth)
+	paddedBits := make([]bool, depth)
+	copy(paddedSiblings, siblings)
+	copy(paddedBits, pathBits)
+	for i := len(siblings); i < depth; i++ {
+		paddedSiblings[i] = emptyNode
+	}
+	return paddedSiblings, paddedBits, nil
+}
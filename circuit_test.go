@@ -0,0 +1,222 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// truncatedHashFunc returns a stub hash function with outputs of the size,
+// standing in for a hash function over field elements such as Poseidon.
+func truncatedHashFunc(size int) TypeHashFunc {
+	return func(data []byte) ([]byte, error) {
+		digest := sha512.Sum512(data)
+		return digest[:size], nil
+	}
+}
+
+// circuitRoot computes the root from the leaf and the circuit inputs, as a circuit would.
+func circuitRoot(leaf []byte, siblings [][]byte, pathBits []bool, hashFunc TypeHashFunc) ([]byte, error) {
+	node := leaf
+	for i, sibling := range siblings {
+		var err error
+		if pathBits[i] {
+			node, err = hashFunc(append(append([]byte(nil), sibling...), node...))
+		} else {
+			node, err = hashFunc(append(append([]byte(nil), node...), sibling...))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func TestNew_nonStandardHashSize(t *testing.T) {
+	configs := []Config{
+		{},
+		{Mode: ModeProofGenAndTreeBuild, PaddingStrategy: PaddingPromote},
+		{Mode: ModeTreeBuild, PaddingStrategy: PaddingIndexed},
+		{RunInParallel: true, NoDuplicates: true},
+		{RunInParallel: true, Mode: ModeProofGenAndTreeBuild, SortSiblingPairs: true},
+	}
+	for _, size := range []int{20, 31, 48} {
+		for i := range configs {
+			for _, numBlocks := range []int{2, 7, 33} {
+				name := fmt.Sprintf("size %d/config %d/%d blocks", size, i, numBlocks)
+				config := configs[i]
+				config.HashFunc = truncatedHashFunc(size)
+				blocks := dataBlocks(numBlocks)
+				m, err := New(&config, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() error = %v", name, err)
+				}
+				if len(m.Root) != size {
+					t.Fatalf("%s: root has %d bytes, want %d", name, len(m.Root), size)
+				}
+				for idx, block := range blocks {
+					proof, err := m.GenerateProof(block)
+					if err != nil {
+						t.Fatalf("%s: GenerateProof(%d) error = %v", name, idx, err)
+					}
+					if ok, err := Verify(block, proof, m.Root, &config); err != nil || !ok {
+						t.Errorf("%s: Verify(%d) = %v, %v, want true", name, idx, ok, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestProof_ToCircuitInputs(t *testing.T) {
+	const size = 31
+	config := &Config{HashFunc: truncatedHashFunc(size)}
+	for _, numBlocks := range []int{2, 8, 13} {
+		blocks := dataBlocks(numBlocks)
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for idx, proof := range m.Proofs {
+			leaf, err := leafFromBlock(blocks[idx], idx, config)
+			if err != nil {
+				t.Fatalf("leafFromBlock() error = %v", err)
+			}
+			for _, p := range []*Proof{proof, proof.Reverse()} {
+				siblings, pathBits, err := p.ToCircuitInputs()
+				if err != nil {
+					t.Fatalf("%d blocks: ToCircuitInputs(%d) error = %v", numBlocks, idx, err)
+				}
+				root, err := circuitRoot(leaf, siblings, pathBits, config.HashFunc)
+				if err != nil || !bytes.Equal(root, m.Root) {
+					t.Errorf("%d blocks: circuit root of proof %d = %x, %v, want %x", numBlocks, idx, root, err, m.Root)
+				}
+				for level, bit := range pathBits {
+					if want := idx>>level&1 == 1; bit != want {
+						t.Errorf("%d blocks: proof %d path bit %d = %v, want %v", numBlocks, idx, level, bit, want)
+					}
+				}
+			}
+			siblings, _, _ := proof.ToCircuitInputs()
+			siblings[0][0] ^= 1
+			if bytes.Equal(siblings[0], proof.Siblings[0]) {
+				t.Fatalf("%d blocks: ToCircuitInputs(%d) shares the siblings with the proof", numBlocks, idx)
+			}
+		}
+	}
+}
+
+func TestProof_ToCircuitInputs_errors(t *testing.T) {
+	var nilProof *Proof
+	if _, _, err := nilProof.ToCircuitInputs(); err == nil {
+		t.Error("ToCircuitInputs() of a nil proof: error = nil")
+	}
+	deep := &Proof{Siblings: make([][]byte, maxProofSiblings+1)}
+	if _, _, err := deep.ToCircuitInputs(); !errors.Is(err, ErrProofTooDeep) {
+		t.Errorf("ToCircuitInputs() of a deep proof: error = %v, want ErrProofTooDeep", err)
+	}
+	empty := &Proof{Siblings: [][]byte{{1}, nil}}
+	if _, _, err := empty.ToCircuitInputs(); err == nil {
+		t.Error("ToCircuitInputs() with an empty sibling: error = nil")
+	}
+}
+
+func TestPadCircuitInputs(t *testing.T) {
+	const (
+		size  = 31
+		depth = 8
+	)
+	hashFunc := truncatedHashFunc(size)
+	emptyNode := make([]byte, size)
+	blocks := dataBlocks(5)
+	m, err := New(&Config{HashFunc: hashFunc}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The tree of the fixed depth has the tree as its leftmost subtree, and the empty node elsewhere.
+	want := m.Root
+	for i := int(m.Depth); i < depth; i++ {
+		if want, err = hashFunc(append(append([]byte(nil), want...), emptyNode...)); err != nil {
+			t.Fatalf("hashFunc() error = %v", err)
+		}
+	}
+	for idx, proof := range m.Proofs {
+		siblings, pathBits, err := proof.ToCircuitInputs()
+		if err != nil {
+			t.Fatalf("ToCircuitInputs(%d) error = %v", idx, err)
+		}
+		paddedSiblings, paddedBits, err := PadCircuitInputs(siblings, pathBits, depth, emptyNode)
+		if err != nil {
+			t.Fatalf("PadCircuitInputs(%d) error = %v", idx, err)
+		}
+		if len(paddedSiblings) != depth || len(paddedBits) != depth {
+			t.Fatalf("PadCircuitInputs(%d) = %d siblings, %d path bits, want %d", idx, len(paddedSiblings), len(paddedBits), depth)
+		}
+		if len(siblings) != int(m.Depth) {
+			t.Errorf("PadCircuitInputs(%d) modified the siblings", idx)
+		}
+		leaf, err := leafFromBlock(blocks[idx], idx, m.Config)
+		if err != nil {
+			t.Fatalf("leafFromBlock() error = %v", err)
+		}
+		root, err := circuitRoot(leaf, paddedSiblings, paddedBits, hashFunc)
+		if err != nil || !bytes.Equal(root, want) {
+			t.Errorf("circuit root of padded proof %d = %x, %v, want %x", idx, root, err, want)
+		}
+	}
+}
+
+func TestPadCircuitInputs_errors(t *testing.T) {
+	sibling := sha256.Sum256([]byte("sibling"))
+	siblings := [][]byte{sibling[:], sibling[:]}
+	pathBits := []bool{true, false}
+	emptyNode := make([]byte, sha256.Size)
+	tests := []struct {
+		name      string
+		siblings  [][]byte
+		pathBits  []bool
+		depth     int
+		emptyNode []byte
+	}{
+		{"bits_mismatch", siblings, pathBits[:1], 4, emptyNode},
+		{"too_deep", siblings, pathBits, 1, emptyNode},
+		{"empty_node_nil", siblings, pathBits, 4, nil},
+		{"empty_node_size", siblings, pathBits, 4, emptyNode[:31]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := PadCircuitInputs(tt.siblings, tt.pathBits, tt.depth, tt.emptyNode); err == nil {
+				t.Error("PadCircuitInputs() error = nil")
+			}
+		})
+	}
+	padded, bits, err := PadCircuitInputs(siblings, pathBits, 2, emptyNode)
+	if err != nil || len(padded) != 2 || len(bits) != 2 {
+		t.Errorf("PadCircuitInputs() to the proof depth = %d, %d, %v, want 2, 2, nil", len(padded), len(bits), err)
+	}
+}
@@ -0,0 +1,96 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// maxProofSiblings is the maximum number of siblings of a proof, bounded by the bits of the proof path.
+const maxProofSiblings = 32
+
+// CompactEncode encodes the proof in the minimal encoding for external verifiers, e.g. on hardware wallets:
+// the leaf index as a uvarint followed by the siblings, from the leaf level up, without length prefixes.
+// The path is not encoded, as it is derived from the bits of the leaf index,
+// and the fingerprint is not encoded either, the verifier must know the configuration of the tree.
+// The verification algorithm is described in the package documentation.
+// It returns an error if the siblings have different sizes, or if the path does not match the leaf index.
+func (p *Proof) CompactEncode() ([]byte, error) {
+	if len(p.Siblings) == 0 {
+		return nil, errors.New("proof has no siblings")
+	}
+	hashSize := len(p.Siblings[0])
+	if hashSize == 0 {
+		return nil, errors.New("proof has an empty sibling")
+	}
+	for _, sibling := range p.Siblings {
+		if len(sibling) != hashSize {
+			return nil, fmt.Errorf("%w: siblings of %d and %d bytes could not be compactly encoded",
+				ErrInconsistentHashSize, hashSize, len(sibling))
+		}
+	}
+	if err := checkProofPath(p); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, uvarintLen(uint64(p.Index))+len(p.Siblings)*hashSize)
+	data = binary.AppendUvarint(data, uint64(p.Index))
+	for _, sibling := range p.Siblings {
+		data = append(data, sibling...)
+	}
+	return data, nil
+}
+
+// DecodeCompactProof decodes the proof from the encoding of CompactEncode, with siblings of hashSize bytes.
+// The siblings are copied, so the proof does not reference the data.
+func DecodeCompactProof(data []byte, hashSize int) (*Proof, error) {
+	if hashSize <= 0 {
+		return nil, fmt.Errorf("invalid hash size %d", hashSize)
+	}
+	index, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("invalid uvarint in proof data")
+	}
+	data = data[n:]
+	if len(data) == 0 || len(data)%hashSize != 0 {
+		return nil, fmt.Errorf("proof data of %d bytes is not a sequence of %d-byte siblings", len(data), hashSize)
+	}
+	numSiblings := len(data) / hashSize
+	if numSiblings > maxProofSiblings {
+		return nil, fmt.Errorf("proof has %d siblings, more than %d", numSiblings, maxProofSiblings)
+	}
+	if index>>numSiblings != 0 {
+		return nil, fmt.Errorf("leaf index %d is out of range of the proof", index)
+	}
+	buf := make([]byte, len(data))
+	copy(buf, data)
+	proof := &Proof{Siblings: make([][]byte, numSiblings), Index: int(index)}
+	for level := range proof.Siblings {
+		proof.Siblings[level] = buf[level*hashSize : (level+1)*hashSize : (level+1)*hashSize]
+		if (index>>level)&1 == 0 {
+			proof.Path |= 1 << level
+		}
+	}
+	return proof, nil
+}
@@ -0,0 +1,178 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// compactVerificationPseudocode is the pseudocode of the package documentation,
+// each line followed by its implementation in verifyCompact.
+var compactVerificationPseudocode = []string{
+	"index, n := uvarint(proof)",
+	"siblings := proof[n:], split into hashSize-byte siblings",
+	"node := Hash(data block)",
+	"for level, sibling in siblings:",
+	"    if bit level of index is 0:",
+	"        node = Hash(node || sibling)",
+	"    else:",
+	"        node = Hash(sibling || node)",
+	"valid := node == root, compared in constant time",
+}
+
+// verifyCompact verifies the compactly encoded proof following compactVerificationPseudocode line by line,
+// with the default configuration.
+func verifyCompact(proof []byte, hashSize int, block DataBlock, root []byte) bool {
+	// index, n := uvarint(proof)
+	index, n := binary.Uvarint(proof)
+	// siblings := proof[n:], split into hashSize-byte siblings
+	var siblings [][]byte
+	for rest := proof[n:]; len(rest) >= hashSize; rest = rest[hashSize:] {
+		siblings = append(siblings, rest[:hashSize])
+	}
+	// node := Hash(data block)
+	data, _ := block.Serialize()
+	node, _ := DefaultHashFunc(data)
+	// for level, sibling in siblings:
+	for level, sibling := range siblings {
+		// if bit level of index is 0:
+		if (index>>level)&1 == 0 {
+			// node = Hash(node || sibling)
+			node, _ = DefaultHashFunc(append(append([]byte{}, node...), sibling...))
+		} else {
+			// node = Hash(sibling || node)
+			node, _ = DefaultHashFunc(append(append([]byte{}, sibling...), node...))
+		}
+	}
+	// valid := node == root, compared in constant time
+	return subtle.ConstantTimeCompare(node, root) == 1
+}
+
+func TestPackageDoc_verificationPseudocode(t *testing.T) {
+	doc, err := os.ReadFile("doc.go")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := "//\t" + strings.Join(compactVerificationPseudocode, "\n//\t") + "\n"
+	if !strings.Contains(string(doc), want) {
+		t.Errorf("package documentation does not contain the verification pseudocode:\n%s", want)
+	}
+}
+
+func TestProof_CompactEncode(t *testing.T) {
+	blocks := dataBlocks(13)
+	for _, config := range []*Config{{}, {Mode: ModeTreeBuild}, {RunInParallel: true}} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, block := range blocks {
+			proof, err := m.Proof(block)
+			if m.Proofs != nil {
+				proof, err = m.Proofs[i], nil
+			}
+			if err != nil {
+				t.Fatalf("Proof() error = %v", err)
+			}
+			data, err := proof.CompactEncode()
+			if err != nil {
+				t.Fatalf("CompactEncode() error = %v", err)
+			}
+			if want := uvarintLen(uint64(i)) + len(proof.Siblings)*defaultHashLen; len(data) != want {
+				t.Errorf("len(CompactEncode()) = %d, want %d", len(data), want)
+			}
+			decoded, err := DecodeCompactProof(data, defaultHashLen)
+			if err != nil {
+				t.Fatalf("DecodeCompactProof() error = %v", err)
+			}
+			if !decoded.Equal(proof) {
+				t.Errorf("DecodeCompactProof() = %+v, want %+v", decoded, proof)
+			}
+			if ok, err := Verify(block, decoded, m.Root, nil); err != nil || !ok {
+				t.Errorf("Verify() of the decoded proof %d = %v, %v, want true", i, ok, err)
+			}
+			if !verifyCompact(data, defaultHashLen, block, m.Root) {
+				t.Errorf("pseudocode verification of the proof %d = false, want true", i)
+			}
+			if verifyCompact(data, defaultHashLen, blocks[(i+1)%len(blocks)], m.Root) {
+				t.Errorf("pseudocode verification of the proof %d with another block = true, want false", i)
+			}
+		}
+	}
+}
+
+func TestProof_CompactEncode_errors(t *testing.T) {
+	sibling := bytes.Repeat([]byte{1}, defaultHashLen)
+	tests := []struct {
+		name    string
+		proof   *Proof
+		wantErr error
+	}{
+		{"no_siblings", &Proof{}, nil},
+		{"empty_sibling", &Proof{Siblings: [][]byte{{}}, Path: 1}, nil},
+		{"variable_hash_sizes", &Proof{Siblings: [][]byte{sibling, sibling[:20]}, Path: 0b11}, ErrInconsistentHashSize},
+		{"path_mismatch", &Proof{Siblings: [][]byte{sibling, sibling}, Path: 0b01, Index: 1}, nil},
+		{"index_out_of_range", &Proof{Siblings: [][]byte{sibling}, Path: 0, Index: 3}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.proof.CompactEncode()
+			if err == nil {
+				t.Fatal("CompactEncode() error = nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CompactEncode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeCompactProof_errors(t *testing.T) {
+	sibling := bytes.Repeat([]byte{1}, defaultHashLen)
+	tests := []struct {
+		name     string
+		data     []byte
+		hashSize int
+	}{
+		{"invalid_hash_size", append([]byte{0}, sibling...), 0},
+		{"empty", nil, defaultHashLen},
+		{"invalid_uvarint", []byte{0x80}, defaultHashLen},
+		{"no_siblings", []byte{0}, defaultHashLen},
+		{"truncated_sibling", append([]byte{0}, sibling[:31]...), defaultHashLen},
+		{"index_out_of_range", append([]byte{2}, sibling...), defaultHashLen},
+		{"too_many_siblings", append([]byte{0}, bytes.Repeat([]byte{1}, 33)...), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCompactProof(tt.data, tt.hashSize); err == nil {
+				t.Error("DecodeCompactProof() error = nil")
+			}
+		})
+	}
+}
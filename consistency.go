@@ -0,0 +1,176 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// consistencyVariant is one of the equivalent configurations built by CheckConsistency.
+type consistencyVariant struct {
+	mode        TypeConfigMode
+	parallel    bool
+	numRoutines int
+}
+
+func (v consistencyVariant) String() string {
+	if !v.parallel {
+		return fmt.Sprintf("%v, serial", v.mode)
+	}
+	return fmt.Sprintf("%v, parallel with %d routines", v.mode, v.numRoutines)
+}
+
+// consistencyVariants returns every combination of the configuration modes and parallelization settings.
+// Small and odd routine numbers make the parallel chunks end at different positions of each tree level.
+func consistencyVariants() []consistencyVariant {
+	var variants []consistencyVariant
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		variants = append(variants, consistencyVariant{mode: mode})
+		for _, numRoutines := range []int{1, 2, 3, 5, runtime.NumCPU()} {
+			variants = append(variants, consistencyVariant{mode: mode, parallel: true, numRoutines: numRoutines})
+		}
+	}
+	return variants
+}
+
+// CheckConsistency builds the Merkle Tree of the data blocks with every combination of the configuration modes
+// and parallelization settings, keeping the other fields of the configuration, and checks that all the builds
+// have identical leaves, roots and proofs, and that every proof verifies against its root,
+// the proofs of the padding leaves of PaddingIndexed with VerifyPadding.
+// With ProofIndices, the proofs not selected in ModeProofGen are not checked.
+// With DuplicateLeafCollapse, each data block is verified against the proof of its leaf, see LeafIndexOf.
+// It returns an error describing the first divergence found, or nil if the builds are consistent.
+// The configuration is not modified, and its HashFunc must be concurrent safe.
+func CheckConsistency(config *Config, blocks []DataBlock) error {
+	if config == nil {
+		config = new(Config)
+	}
+	var (
+		ref       *MerkleTree
+		refProofs []*Proof
+		refName   consistencyVariant
+	)
+	for _, variant := range consistencyVariants() {
+		c := *config
+		c.Mode, c.RunInParallel, c.NumRoutines = variant.mode, variant.parallel, variant.numRoutines
+		c.AutoParallel = false
+		// The exact number of workers is used, even above GOMAXPROCS, to vary the parallel chunk boundaries.
+		m, err := newWithContext(context.Background(), &c, blocks, variant.numRoutines)
+		if err != nil {
+			return fmt.Errorf("%v: %w", variant, err)
+		}
+		// The proofs not selected by ProofIndices in ModeProofGen are nil, and skipped.
+		proofs := make([]*Proof, m.NumLeaves)
+		for i := range proofs {
+			proofs[i], _ = m.ProofOf(i)
+		}
+		// With DuplicateLeafCollapse, several data blocks map to the same leaf.
+		for blockIdx, block := range blocks {
+			i, err := m.LeafIndexOf(blockIdx)
+			if err != nil {
+				return fmt.Errorf("%v: %w", variant, err)
+			}
+			if proofs[i] == nil {
+				continue
+			}
+			ok, err := Verify(block, proofs[i], m.Root, &c)
+			if err != nil {
+				return fmt.Errorf("%v: verifying the proof of leaf %d of data block %d: %w", variant, i, blockIdx, err)
+			}
+			if !ok {
+				return fmt.Errorf("%v: the proof of leaf %d of data block %d does not verify against the root",
+					variant, i, blockIdx)
+			}
+		}
+		// The padding leaves of PaddingIndexed follow the leaves of the data blocks.
+		for i := m.NumLeaves; i < m.treeLeafCount(); i++ {
+			proof, _ := m.ProofOf(i)
+			if proof == nil {
+				continue
+			}
+			ok, err := VerifyPadding(proof, m.Root, &c)
+			if err != nil {
+				return fmt.Errorf("%v: verifying the proof of padding leaf %d: %w", variant, i, err)
+			}
+			if !ok {
+				return fmt.Errorf("%v: the proof of padding leaf %d does not verify against the root", variant, i)
+			}
+		}
+		if ref == nil {
+			ref, refProofs, refName = m, proofs, variant
+			continue
+		}
+		if !bytes.Equal(m.Root, ref.Root) {
+			return fmt.Errorf("%v: root %x differs from root %x of %v", variant, m.Root, ref.Root, refName)
+		}
+		for i := range proofs {
+			if !bytes.Equal(m.Leaves[i], ref.Leaves[i]) {
+				return fmt.Errorf("%v: leaf %d differs from %v", variant, i, refName)
+			}
+			if proofs[i] != nil && refProofs[i] != nil && !proofs[i].Equal(refProofs[i]) {
+				return fmt.Errorf("%v: the proof of leaf %d differs from %v", variant, i, refName)
+			}
+		}
+	}
+	return nil
+}
+
+// Equal reports whether the Merkle Trees have identical roots, numbers of leaves, depths and leaves,
+// and, when both trees are built with their nodes, identical nodes at all levels.
+// The configurations and the proofs are not compared.
+func Equal(a, b *MerkleTree) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !bytes.Equal(a.Root, b.Root) || a.NumLeaves != b.NumLeaves || a.Depth != b.Depth ||
+		len(a.Leaves) != len(b.Leaves) {
+		return false
+	}
+	for i := range a.Leaves {
+		if !bytes.Equal(a.Leaves[i], b.Leaves[i]) {
+			return false
+		}
+	}
+	if a.nodes == nil || b.nodes == nil {
+		return true
+	}
+	aNodes, aErr := a.treeNodes()
+	bNodes, bErr := b.treeNodes()
+	if aErr != nil || bErr != nil || len(aNodes) != len(bNodes) {
+		return false
+	}
+	for level := range aNodes {
+		if len(aNodes[level]) != len(bNodes[level]) {
+			return false
+		}
+		for i := range aNodes[level] {
+			if !bytes.Equal(aNodes[level][i], bNodes[level][i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"flag"
+	"math/rand"
+	"testing"
+)
+
+// consistencySeed seeds the random sizes and configurations of TestCheckConsistency, fixed so that the runs
+// are reproducible, e.g. go test -run TestCheckConsistency -consistency-seed 42 to explore other inputs.
+var consistencySeed = flag.Int64("consistency-seed", 1,
+	"seed of the random sizes and configurations of TestCheckConsistency")
+
+func TestCheckConsistency(t *testing.T) {
+	t.Logf("seed: %d", *consistencySeed)
+	r := rand.New(rand.NewSource(*consistencySeed))
+	// Sizes around powers of two and multiples of the routine numbers, and random sizes up to 3000.
+	sizes := []int{2, 3, 4, 5, 6, 7, 9, 15, 16, 17, 31, 33, 63, 65, 127, 129, 255, 257, 1023, 1025, 2047, 2049}
+	numRandom := 10
+	if testing.Short() {
+		numRandom = 3
+	}
+	for i := 0; i < numRandom; i++ {
+		sizes = append(sizes, 2+r.Intn(2999))
+	}
+	// The padding strategies alternate over the sizes, so that each one is checked.
+	strategies := []TypePaddingStrategy{PaddingDuplicate, PaddingPromote, PaddingIndexed}
+	for i, size := range sizes {
+		blocks := dataBlocks(size)
+		config := &Config{
+			PaddingStrategy:    strategies[i%len(strategies)],
+			SortSiblingPairs:   r.Intn(2) == 0,
+			DisableLeafHashing: r.Intn(4) == 0,
+			BindLeafIndex:      r.Intn(2) == 0,
+		}
+		// NoDuplicates is not supported with PaddingPromote.
+		config.NoDuplicates = config.PaddingStrategy != PaddingPromote && r.Intn(4) == 0
+		if err := CheckConsistency(config, blocks); err != nil {
+			t.Errorf("CheckConsistency() with %d blocks and config %+v error = %v", size, *config, err)
+		}
+	}
+}
+
+func TestCheckConsistency_paddingIndexed(t *testing.T) {
+	// The proofs of the padding leaves follow those of the data blocks.
+	for _, size := range []int{3, 5, 9, 16} {
+		if err := CheckConsistency(&Config{PaddingStrategy: PaddingIndexed}, dataBlocks(size)); err != nil {
+			t.Errorf("CheckConsistency() with %d blocks and PaddingIndexed error = %v", size, err)
+		}
+	}
+}
+
+func TestCheckConsistency_proofIndices(t *testing.T) {
+	// The proofs not selected in ModeProofGen are skipped.
+	config := &Config{ProofIndices: []int{0, 3, 10}}
+	if err := CheckConsistency(config, dataBlocks(11)); err != nil {
+		t.Errorf("CheckConsistency() with ProofIndices error = %v", err)
+	}
+}
+
+func TestCheckConsistency_duplicateLeafCollapse(t *testing.T) {
+	// The duplicated data blocks are verified against the proof of their collapsed leaf.
+	blocks := dataBlocks(7)
+	blocks = append(blocks, blocks[1], blocks[4], blocks[1])
+	config := &Config{OnDuplicateLeaf: DuplicateLeafCollapse}
+	if err := CheckConsistency(config, blocks); err != nil {
+		t.Errorf("CheckConsistency() with DuplicateLeafCollapse error = %v", err)
+	}
+}
+
+func TestCheckConsistency_divergence(t *testing.T) {
+	blocks := dataBlocks(11)
+	if err := CheckConsistency(nil, blocks); err != nil {
+		t.Fatalf("CheckConsistency() error = %v", err)
+	}
+	// A hash function with random outputs gives a different tree for each build.
+	config := &Config{
+		HashFunc: func([]byte) ([]byte, error) {
+			h := make([]byte, sha256.Size)
+			_, err := crand.Read(h)
+			return h, err
+		},
+	}
+	if err := CheckConsistency(config, blocks); err == nil {
+		t.Errorf("CheckConsistency() error = nil, want error for a non-deterministic hash function")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	blocks := dataBlocks(7)
+	build := func(config *Config, blocks []DataBlock) *MerkleTree {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		return m
+	}
+	tree := build(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+	tamperedNodes := build(&Config{Mode: ModeTreeBuild}, blocks)
+	tamperedNodes.nodes[1][0] = tamperedNodes.nodes[1][1]
+	otherBlocks := append([]DataBlock{blocks[1], blocks[0]}, blocks[2:]...)
+	tests := []struct {
+		name string
+		a, b *MerkleTree
+		want bool
+	}{
+		{"nil", nil, nil, true},
+		{"one_nil", tree, nil, false},
+		{"same_tree", tree, tree, true},
+		{"parallel_build", tree, build(&Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true}, blocks), true},
+		{"without_nodes", tree, build(nil, blocks), true},
+		{"different_blocks", tree, build(&Config{Mode: ModeProofGenAndTreeBuild}, otherBlocks), false},
+		{"different_size", tree, build(&Config{Mode: ModeProofGenAndTreeBuild}, blocks[:6]), false},
+		{"different_nodes", tree, tamperedNodes, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
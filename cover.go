@@ -0,0 +1,71 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MinimalCover returns the minimal set of tree nodes from which the root can be recomputed
+// together with the leaves at the indexes, e.g. the nodes to publish for the sampled leaves
+// of a data availability sampling scheme. It is the node set of a multiproof of the leaves.
+// The nodes are keyed by their level and their index in the level, level 0 being the leaf level.
+// The leaves at the indexes are not part of the cover, nor are the nodes computed from them.
+// Odd levels are padded, so the padding node of a level is keyed by the index after the last node,
+// except with PaddingPromote, where the node promoted from an odd level has no sibling in the cover.
+// The tree nodes are required, so the Merkle Tree must be built in ModeTreeBuild or ModeProofGenAndTreeBuild.
+func (m *MerkleTree) MinimalCover(indices []int) (map[[2]int][]byte, error) {
+	if m.Config.Mode != ModeTreeBuild && m.Config.Mode != ModeProofGenAndTreeBuild {
+		return nil, fmt.Errorf("merkle Tree is not built in %v, could not compute the cover", m.Config.Mode)
+	}
+	if m.nodes == nil {
+		return nil, errors.New("merkle Tree nodes are not available, could not compute the cover")
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("the number of leaf indexes must be greater than 0")
+	}
+	known := make(map[int]struct{}, len(indices))
+	for _, idx := range indices {
+		if idx < 0 || idx >= m.NumLeaves {
+			return nil, fmt.Errorf("leaf index %d is out of range [0, %d)", idx, m.NumLeaves)
+		}
+		known[idx] = struct{}{}
+	}
+	nodes, err := m.treeNodes()
+	if err != nil {
+		return nil, err
+	}
+	cover := make(map[[2]int][]byte)
+	for level := 0; level < int(m.Depth); level++ {
+		parents := make(map[int]struct{}, (len(known)+1)/2)
+		for idx := range known {
+			if _, ok := known[idx^1]; !ok && nodes[level][idx^1] != nil {
+				cover[[2]int{level, idx ^ 1}] = nodes[level][idx^1]
+			}
+			parents[idx>>1] = struct{}{}
+		}
+		known = parents
+	}
+	return cover, nil
+}
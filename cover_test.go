@@ -0,0 +1,147 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// rootFromCover recomputes the root from the leaves at the indexes and the cover.
+func rootFromCover(t *testing.T, m *MerkleTree, leaves map[int][]byte, cover map[[2]int][]byte) []byte {
+	t.Helper()
+	known := leaves
+	for level := 0; level < int(m.Depth); level++ {
+		parents := make(map[int][]byte)
+		for idx, node := range known {
+			sibling, ok := known[idx^1]
+			if !ok {
+				if sibling, ok = cover[[2]int{level, idx ^ 1}]; !ok {
+					t.Fatalf("node (%d, %d) is missing from the cover", level, idx^1)
+				}
+			}
+			left, right := node, sibling
+			if idx&1 == 1 {
+				left, right = sibling, node
+			}
+			parent, err := m.HashFunc(m.concatFunc(append([]byte{}, left...), right))
+			if err != nil {
+				t.Fatalf("HashFunc() error = %v", err)
+			}
+			parents[idx>>1] = parent
+		}
+		known = parents
+	}
+	return known[0]
+}
+
+func TestMerkleTree_MinimalCover(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, config := range []*Config{
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+	} {
+		m, err := New(config, dataBlocks(32))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for round := 0; round < 100; round++ {
+			indices := rng.Perm(m.NumLeaves)[:1+rng.Intn(m.NumLeaves)]
+			cover, err := m.MinimalCover(indices)
+			if err != nil {
+				t.Fatalf("MinimalCover() error = %v", err)
+			}
+			leaves := make(map[int][]byte, len(indices))
+			for _, idx := range indices {
+				leaves[idx] = m.Leaves[idx]
+			}
+			if root := rootFromCover(t, m, leaves, cover); !bytes.Equal(root, m.Root) {
+				t.Fatalf("root from the cover of %v = %x, want %x", indices, root, m.Root)
+			}
+			// The sampled leaves are computed by the verifier, so they are not part of the cover.
+			for key := range cover {
+				if _, ok := leaves[key[1]]; key[0] == 0 && ok {
+					t.Errorf("cover contains the sampled leaf %d", key[1])
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTree_MinimalCover_size(t *testing.T) {
+	m, err := New(&Config{Mode: ModeTreeBuild}, dataBlocks(32))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		indices []int
+		want    int
+	}{
+		{"single_leaf", []int{5}, 5},
+		{"sibling_leaves", []int{4, 5}, 4},
+		{"duplicate_index", []int{5, 5}, 5},
+		{"half", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, 1},
+		{"all", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+			16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cover, err := m.MinimalCover(tt.indices)
+			if err != nil {
+				t.Fatalf("MinimalCover() error = %v", err)
+			}
+			if len(cover) != tt.want {
+				t.Errorf("len(MinimalCover()) = %d, want %d", len(cover), tt.want)
+			}
+		})
+	}
+}
+
+func TestMerkleTree_MinimalCover_errors(t *testing.T) {
+	blocks := dataBlocks(5)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = m.MinimalCover([]int{0}); err == nil {
+		t.Error("MinimalCover() in ModeProofGen: error = nil")
+	}
+	if m, err = New(&Config{Mode: ModeTreeBuild}, blocks); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, indices := range [][]int{nil, {-1}, {5}, {0, 6}} {
+		if _, err = m.MinimalCover(indices); err == nil {
+			t.Errorf("MinimalCover(%v) error = nil", indices)
+		}
+	}
+	// The padding node of the odd leaf level is addressed by the index after the last leaf.
+	cover, err := m.MinimalCover([]int{4})
+	if err != nil {
+		t.Fatalf("MinimalCover() error = %v", err)
+	}
+	if node := cover[[2]int{0, 5}]; !bytes.Equal(node, m.Leaves[4]) {
+		t.Errorf("padding node = %x, want the duplicated leaf %x", node, m.Leaves[4])
+	}
+}
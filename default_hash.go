@@ -22,24 +22,91 @@
 
 package merkletree
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+)
 
-// sha256Digest is the reusable digest for defaultHashFunc.
-var sha256Digest = sha256.New()
+// DefaultHashAlgorithm names the algorithm of DefaultHashFunc.
+const DefaultHashAlgorithm = "SHA-256"
 
-// defaultHashFunc is used when no user hash function is specified.
-// It implements SHA256 hash function.
-func defaultHashFunc(data []byte) ([]byte, error) {
-	defer sha256Digest.Reset()
-	sha256Digest.Write(data)
-	return sha256Digest.Sum(nil), nil
+// DefaultHashFunc is used when no user hash function is specified.
+// It implements SHA256 hash function: it returns the 32-byte SHA-256 digest (FIPS 180-4) of the data,
+// with no prefix, suffix or domain separation. The same function hashes the leaves and the internal nodes.
+// It is concurrent safe, so that trees can be built concurrently with the default configuration.
+func DefaultHashFunc(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return digest[:], nil
 }
 
 // defaultHashFuncParallel is used by parallel algorithms when no user hash function is specified.
 // It implements SHA256 hash function.
 // When implementing hash functions for paralleled algorithms, please make sure it is concurrent safe.
 func defaultHashFuncParallel(data []byte) ([]byte, error) {
-	digest := sha256.New()
-	digest.Write(data)
-	return digest.Sum(nil), nil
+	digest := sha256.Sum256(data)
+	return digest[:], nil
+}
+
+// isDefaultHashFunc reports whether the hash function is one of the default SHA256 hash functions.
+func isDefaultHashFunc(hashFunc TypeHashFunc) bool {
+	ptr := reflect.ValueOf(hashFunc).Pointer()
+	return ptr == reflect.ValueOf(DefaultHashFunc).Pointer() || ptr == reflect.ValueOf(defaultHashFuncParallel).Pointer()
+}
+
+// LeafHash computes the leaf of the serialized data block with the configuration, as New does:
+// LeafTransform is applied to the data if set, then the data is hashed with the Hasher if set, otherwise with
+// the HashFunc, DefaultHashFunc by default, unless DisableLeafHashing is true, in which case the data is the leaf.
+// With BindLeafIndex, the leaf depends on the leaf index, which New prefixes to the data
+// as a big-endian uint64, so LeafHash returns an error.
+// The configuration is not modified.
+func LeafHash(config *Config, data []byte) ([]byte, error) {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if c.BindLeafIndex {
+		return nil, errors.New("leaf hash depends on the leaf index when BindLeafIndex is set")
+	}
+	return leafFromBytes(data, 0, verifyConfig(&c))
+}
+
+// NodeHash computes the parent node of the left and right child nodes with the configuration, as New does:
+// the HashFunc, DefaultHashFunc by default, of the concatenation of the left and right nodes,
+// or of the smaller node and the larger node in byte order if SortSiblingPairs is true,
+// or the PairHashFunc of the nodes if set. If right is nil, left is the last node of an odd level, padded as New does:
+// with PaddingPromote, left is promoted and returned, with NoDuplicates, its right sibling is the node derived from it
+// by NoDuplicatesFiller, and otherwise, left is duplicated as its right sibling.
+// With LevelHashFunc, the parent node is at level 1, see NodeHashAtLevel.
+// The configuration is not modified.
+func NodeHash(config *Config, left, right []byte) ([]byte, error) {
+	return NodeHashAtLevel(config, 1, left, right)
+}
+
+// NodeHashAtLevel computes the parent node at the level of the left and right child nodes as NodeHash does,
+// with the hash function of the level if LevelHashFunc is set. Level 1 is the level of the parents of the leaves.
+func NodeHashAtLevel(config *Config, level int, left, right []byte) ([]byte, error) {
+	if level < 1 {
+		return nil, fmt.Errorf("invalid node level %d", level)
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	verifyConfig(&c)
+	if right == nil {
+		switch {
+		case c.PaddingStrategy == PaddingPromote:
+			return left, nil
+		case c.NoDuplicates:
+			var err error
+			if right, err = c.noDuplicatesFiller(c.HashFunc, left); err != nil {
+				return nil, err
+			}
+		default:
+			right = left
+		}
+	}
+	return c.pairHash(level, left, right)
 }
@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Diff returns the indexes, in ascending order, of the leaves that differ between two Merkle Trees of numLeaves leaves
+// built with the same configuration, given their roots and functions fetching their nodes on demand,
+// e.g. from remote peers in a sync protocol. The nodes are indexed as by NodeAt, level 0 being the leaf level,
+// and NodeAt of the trees may be passed as the fetch functions.
+// Only the subtrees whose roots differ are descended into, so the number of fetched nodes is about
+// twice the number of differing leaves times the depth. The roots are not fetched, and the padding nodes are not
+// fetched, so the fetch functions are only called with the positions of the nodes computed from the leaves.
+func Diff(numLeaves int, rootA, rootB []byte, fetchA, fetchB func(level, index int) ([]byte, error)) ([]int, error) {
+	if numLeaves <= 1 {
+		return nil, errors.New("the number of leaves must be greater than 1")
+	}
+	if fetchA == nil || fetchB == nil {
+		return nil, errors.New("fetch function is nil")
+	}
+	if bytes.Equal(rootA, rootB) {
+		return nil, nil
+	}
+	var (
+		diff  []int
+		visit func(level, index int) error
+	)
+	visit = func(level, index int) error {
+		if index >= (numLeaves+1<<level-1)>>level {
+			return nil // The node is a padding node or is not in the tree.
+		}
+		a, err := fetchA(level, index)
+		if err != nil {
+			return fmt.Errorf("fetch node %d at level %d of tree A: %w", index, level, err)
+		}
+		b, err := fetchB(level, index)
+		if err != nil {
+			return fmt.Errorf("fetch node %d at level %d of tree B: %w", index, level, err)
+		}
+		if bytes.Equal(a, b) {
+			return nil
+		}
+		if level == 0 {
+			diff = append(diff, index)
+			return nil
+		}
+		if err = visit(level-1, index<<1); err != nil {
+			return err
+		}
+		return visit(level-1, index<<1+1)
+	}
+	depth := int(calTreeDepth(numLeaves))
+	for index := 0; index < 2; index++ {
+		if err := visit(depth-1, index); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
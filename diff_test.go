@@ -0,0 +1,116 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestDiff(t *testing.T) {
+	configs := []Config{
+		{Mode: ModeTreeBuild},
+		{Mode: ModeTreeBuild, PaddingStrategy: PaddingPromote},
+		{Mode: ModeTreeBuild, PaddingStrategy: PaddingIndexed},
+		{Mode: ModeTreeBuild, NoDuplicates: true},
+	}
+	tests := []struct {
+		numBlocks int
+		changed   []int
+	}{
+		{13, []int{3, 11}},
+		{13, []int{0, 12}},
+		{13, []int{5, 6}},
+		{8, []int{1, 7}},
+		{2, []int{1}},
+		{9, nil},
+	}
+	for i, config := range configs {
+		for _, tt := range tests {
+			name := fmt.Sprintf("config %d/%d blocks/%v", i, tt.numBlocks, tt.changed)
+			blocksA := dataBlocks(tt.numBlocks)
+			blocksB := make([]DataBlock, len(blocksA))
+			copy(blocksB, blocksA)
+			for _, idx := range tt.changed {
+				blocksB[idx] = &mock.DataBlock{Data: []byte(fmt.Sprintf("changed %d", idx))}
+			}
+			configA, configB := config, config
+			a, err := New(&configA, blocksA)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			b, err := New(&configB, blocksB)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			var fetches int
+			fetchA := func(level, index int) ([]byte, error) {
+				fetches++
+				return a.NodeAt(level, index)
+			}
+			got, err := Diff(a.NumLeaves, a.Root, b.Root, fetchA, b.NodeAt)
+			if err != nil {
+				t.Fatalf("%s: Diff() error = %v", name, err)
+			}
+			if !reflect.DeepEqual(got, tt.changed) {
+				t.Errorf("%s: Diff() = %v, want %v", name, got, tt.changed)
+			}
+			if limit := 2 * len(tt.changed) * int(a.Depth); fetches > limit {
+				t.Errorf("%s: Diff() fetched %d nodes, want at most %d", name, fetches, limit)
+			}
+		}
+	}
+}
+
+func TestDiff_errors(t *testing.T) {
+	blocks := dataBlocks(6)
+	a, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b, err := New(&Config{Mode: ModeTreeBuild}, dataBlocks(6))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	errFetch := errors.New("fetch error")
+	failing := func(level, index int) ([]byte, error) {
+		return nil, errFetch
+	}
+	if _, err = Diff(a.NumLeaves, a.Root, b.Root, a.NodeAt, failing); !errors.Is(err, errFetch) {
+		t.Errorf("Diff() error = %v, want %v", err, errFetch)
+	}
+	if _, err = Diff(1, a.Root, b.Root, a.NodeAt, b.NodeAt); err == nil {
+		t.Errorf("Diff() of 1 leaf error = nil, want error")
+	}
+	if _, err = Diff(a.NumLeaves, a.Root, b.Root, nil, b.NodeAt); err == nil {
+		t.Errorf("Diff() with a nil fetch function error = nil, want error")
+	}
+	// Identical roots are not descended into.
+	if got, err := Diff(a.NumLeaves, a.Root, a.Root, failing, failing); err != nil || got != nil {
+		t.Errorf("Diff() of identical roots = %v, %v, want nil, nil", got, err)
+	}
+}
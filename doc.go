@@ -0,0 +1,44 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package merkletree implements a high-performance Merkle Tree, with parallel tree building,
+// proof generation and verification.
+//
+// # Proof verification
+//
+// A proof is verified by recomputing the root from the data block and the siblings, and comparing it with
+// the Merkle root. With the compact encoding of Proof.CompactEncode, an external verifier only needs the hash
+// function and the hash size. The verification algorithm in pseudocode is:
+//
+//	index, n := uvarint(proof)
+//	siblings := proof[n:], split into hashSize-byte siblings
+//	node := Hash(data block)
+//	for level, sibling in siblings:
+//	    if bit level of index is 0:
+//	        node = Hash(node || sibling)
+//	    else:
+//	        node = Hash(sibling || node)
+//	valid := node == root, compared in constant time
+//
+// If SortSiblingPairs is set, node and sibling are sorted before the concatenation, and if BindLeafIndex is set,
+// the leaf is Hash(uint64 big-endian index || data block). If DisableLeafHashing is set, the leaf is the data block.
+package merkletree
@@ -0,0 +1,129 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TypeDuplicateLeaf is the type in the Merkle Tree configuration indicating how duplicate leaves are handled.
+type TypeDuplicateLeaf int
+
+const (
+	// DuplicateLeafAllow keeps the duplicate leaves, each with its own proof.
+	DuplicateLeafAllow TypeDuplicateLeaf = iota
+	// DuplicateLeafError fails the tree building with ErrDuplicateLeaf at the first duplicate leaf.
+	DuplicateLeafError
+	// DuplicateLeafCollapse removes the duplicates of a leaf after its first occurrence,
+	// so the tree commits to the set of the leaves, and maps the data blocks to their leaves in LeafIndexes.
+	DuplicateLeafCollapse
+)
+
+// ErrDuplicateLeaf is returned when a leaf duplicates a previous leaf and OnDuplicateLeaf is DuplicateLeafError.
+var ErrDuplicateLeaf = errors.New("duplicate leaf")
+
+// String returns the name of the duplicate leaf handling.
+func (t TypeDuplicateLeaf) String() string {
+	switch t {
+	case DuplicateLeafAllow:
+		return "DuplicateLeafAllow"
+	case DuplicateLeafError:
+		return "DuplicateLeafError"
+	case DuplicateLeafCollapse:
+		return "DuplicateLeafCollapse"
+	default:
+		return fmt.Sprintf("TypeDuplicateLeaf(%d)", int(t))
+	}
+}
+
+// checkDuplicateLeaf checks that the duplicate leaf handling of the configuration is valid.
+func checkDuplicateLeaf(config *Config) error {
+	switch config.OnDuplicateLeaf {
+	case DuplicateLeafAllow, DuplicateLeafError, DuplicateLeafCollapse:
+		return nil
+	default:
+		return fmt.Errorf("invalid duplicate leaf handling %v", config.OnDuplicateLeaf)
+	}
+}
+
+// handleDuplicateLeaves applies OnDuplicateLeaf to the leaves of the data blocks.
+// It returns the leaves of the tree and, if duplicate leaves are collapsed, the leaf index of each data block,
+// with the additional leaves and the key map updated alike.
+func (m *MerkleTree) handleDuplicateLeaves(leaves [][]byte) ([][]byte, []int, error) {
+	if m.OnDuplicateLeaf == DuplicateLeafAllow {
+		return leaves, nil, nil
+	}
+	// firsts maps the leaves to the index of their first occurrence, in the input for DuplicateLeafError,
+	// and in the collapsed leaves for DuplicateLeafCollapse.
+	firsts := make(map[string]int, len(leaves))
+	if m.OnDuplicateLeaf == DuplicateLeafError {
+		for i, leaf := range leaves {
+			if first, ok := firsts[string(leaf)]; ok {
+				return nil, nil, fmt.Errorf("%w: leaf %d duplicates leaf %d", ErrDuplicateLeaf, i, first)
+			}
+			firsts[string(leaf)] = i
+		}
+		return leaves, nil, nil
+	}
+	var (
+		kept    = make([][]byte, 0, len(leaves))
+		indexes = make([]int, len(leaves))
+	)
+	for i, leaf := range leaves {
+		if idx, ok := firsts[string(leaf)]; ok {
+			indexes[i] = idx
+			continue
+		}
+		firsts[string(leaf)], indexes[i] = len(kept), len(kept)
+		if m.additionalLeaves != nil {
+			m.additionalLeaves[len(kept)] = m.additionalLeaves[i]
+		}
+		kept = append(kept, leaf)
+	}
+	if len(kept) == len(leaves) {
+		return leaves, nil, nil
+	}
+	if m.additionalLeaves != nil {
+		m.additionalLeaves = m.additionalLeaves[:len(kept)]
+	}
+	for key, idx := range m.keyMap {
+		m.keyMap[key] = indexes[idx]
+	}
+	return kept, indexes, nil
+}
+
+// LeafIndexOf returns the leaf index of the data block at the index of the input,
+// which differs from the index if duplicate leaves are collapsed.
+func (m *MerkleTree) LeafIndexOf(blockIdx int) (int, error) {
+	if m.LeafIndexes == nil {
+		if blockIdx < 0 || blockIdx >= m.NumLeaves {
+			return 0, fmt.Errorf("data block index %d is out of range [0, %d)", blockIdx, m.NumLeaves)
+		}
+		return blockIdx, nil
+	}
+	if blockIdx < 0 || blockIdx >= len(m.LeafIndexes) {
+		return 0, fmt.Errorf("data block index %d is out of range [0, %d)", blockIdx, len(m.LeafIndexes))
+	}
+	return m.LeafIndexes[blockIdx], nil
+}
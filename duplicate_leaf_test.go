@@ -0,0 +1,153 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// duplicateBlocks returns 6 data blocks where the block 4 is identical to the block 1.
+func duplicateBlocks() []DataBlock {
+	blocks := dataBlocks(6)
+	blocks[4] = &mock.DataBlock{Data: append([]byte(nil), blocks[1].(*mock.DataBlock).Data...)}
+	return blocks
+}
+
+func TestConfig_OnDuplicateLeaf_allow(t *testing.T) {
+	blocks := duplicateBlocks()
+	m, err := New(&Config{OnDuplicateLeaf: DuplicateLeafAllow}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.NumLeaves != 6 || m.LeafIndexes != nil {
+		t.Errorf("NumLeaves, LeafIndexes = %d, %v, want 6, nil", m.NumLeaves, m.LeafIndexes)
+	}
+	if !bytes.Equal(m.Leaves[1], m.Leaves[4]) {
+		t.Errorf("leaves 1 and 4 differ, want identical leaves")
+	}
+}
+
+func TestConfig_OnDuplicateLeaf_error(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		_, err := New(&Config{OnDuplicateLeaf: DuplicateLeafError, RunInParallel: parallel}, duplicateBlocks())
+		if !errors.Is(err, ErrDuplicateLeaf) {
+			t.Fatalf("New() error = %v, want ErrDuplicateLeaf", err)
+		}
+		if want := "leaf 4 duplicates leaf 1"; !strings.Contains(err.Error(), want) {
+			t.Errorf("New() error = %v, want it to contain %q", err, want)
+		}
+	}
+	if _, err := New(&Config{OnDuplicateLeaf: DuplicateLeafError}, dataBlocks(6)); err != nil {
+		t.Errorf("New() without duplicates error = %v", err)
+	}
+	// With BindLeafIndex, identical data blocks have different leaves.
+	if _, err := New(&Config{OnDuplicateLeaf: DuplicateLeafError, BindLeafIndex: true}, duplicateBlocks()); err != nil {
+		t.Errorf("New() with BindLeafIndex error = %v", err)
+	}
+}
+
+func TestConfig_OnDuplicateLeaf_collapse(t *testing.T) {
+	blocks := duplicateBlocks()
+	for _, config := range []*Config{
+		{OnDuplicateLeaf: DuplicateLeafCollapse},
+		{OnDuplicateLeaf: DuplicateLeafCollapse, Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+		{OnDuplicateLeaf: DuplicateLeafCollapse, AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		// The tree is that of the data blocks without the later duplicate.
+		unique := append(append([]DataBlock(nil), blocks[:4]...), blocks[5])
+		want, err := New(&Config{AdditionalHashFuncs: config.AdditionalHashFuncs}, unique)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if m.NumLeaves != 5 || !bytes.Equal(m.Root, want.Root) {
+			t.Errorf("NumLeaves, Root = %d, %x, want 5, %x", m.NumLeaves, m.Root, want.Root)
+		}
+		if len(config.AdditionalHashFuncs) > 0 && !bytes.Equal(m.AdditionalRoots()[0], want.AdditionalRoots()[0]) {
+			t.Errorf("AdditionalRoots() = %x, want %x", m.AdditionalRoots(), want.AdditionalRoots())
+		}
+		wantIndexes := []int{0, 1, 2, 3, 1, 4}
+		for i, block := range blocks {
+			idx, err := m.LeafIndexOf(i)
+			if err != nil || idx != wantIndexes[i] {
+				t.Errorf("LeafIndexOf(%d) = %d, %v, want %d", i, idx, err, wantIndexes[i])
+			}
+			proof, err := m.ProofOf(idx)
+			if err != nil {
+				t.Fatalf("ProofOf() error = %v", err)
+			}
+			if ok, err := m.Verify(block, proof); err != nil || !ok {
+				t.Errorf("Verify() of block %d = %v, %v, want true", i, ok, err)
+			}
+		}
+		if _, err := m.LeafIndexOf(6); err == nil {
+			t.Errorf("LeafIndexOf(6) error = nil, want error")
+		}
+	}
+
+	// The root of the sub range is collapsed alike.
+	root, err := SubRoot(blocks, 0, 6, &Config{OnDuplicateLeaf: DuplicateLeafCollapse})
+	if err != nil {
+		t.Fatalf("SubRoot() error = %v", err)
+	}
+	m, err := New(&Config{OnDuplicateLeaf: DuplicateLeafCollapse}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(root, m.Root) {
+		t.Errorf("SubRoot() = %x, want %x", root, m.Root)
+	}
+
+	// Collapsing to a single leaf fails.
+	same := []DataBlock{blocks[1], blocks[4]}
+	if _, err := New(&Config{OnDuplicateLeaf: DuplicateLeafCollapse}, same); err == nil {
+		t.Errorf("New() collapsed to a single leaf error = nil, want error")
+	}
+}
+
+func TestConfig_OnDuplicateLeaf_invalid(t *testing.T) {
+	if _, err := New(&Config{OnDuplicateLeaf: 7}, dataBlocks(4)); err == nil {
+		t.Errorf("New() with an invalid duplicate leaf handling error = nil, want error")
+	}
+	if got := TypeDuplicateLeaf(7).String(); got != "TypeDuplicateLeaf(7)" {
+		t.Errorf("String() = %q, want %q", got, "TypeDuplicateLeaf(7)")
+	}
+	m, err := New(nil, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if idx, err := m.LeafIndexOf(2); err != nil || idx != 2 {
+		t.Errorf("LeafIndexOf(2) = %d, %v, want 2", idx, err)
+	}
+	if _, err := m.LeafIndexOf(4); err == nil {
+		t.Errorf("LeafIndexOf(4) error = nil, want error")
+	}
+}
@@ -0,0 +1,86 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// EstimateNodes returns the number of internal nodes of the Merkle Tree of n leaves under the padding strategy,
+// i.e. of the nodes of the levels above the leaves up to the root, including the nodes promoted by PaddingPromote
+// and excluding the padding nodes appended to the odd levels. With PaddingIndexed, the leaves are first padded
+// to a power of two.
+func EstimateNodes(n int, strategy TypePaddingStrategy) int {
+	var numNodes int
+	for levelLen := estimateTreeLeaves(n, strategy); levelLen > 1; levelLen = (levelLen + 1) >> 1 {
+		numNodes += (levelLen + 1) >> 1
+	}
+	return numNodes
+}
+
+// EstimateHashCalls returns the number of calls to HashFunc by New for n data blocks under the padding strategy
+// and the mode, the other fields of the configuration being unset: one call for each leaf, including the padding
+// leaves of PaddingIndexed, and one for each internal node, except the nodes promoted by PaddingPromote.
+// The count is the same in every mode, as ModeProofGenAndTreeBuild derives the proofs from the tree nodes.
+// It returns 0 if n is less than 2, as no tree is built.
+func EstimateHashCalls(n int, strategy TypePaddingStrategy, mode TypeConfigMode) int {
+	if n <= 1 {
+		return 0
+	}
+	treeLeaves := estimateTreeLeaves(n, strategy)
+	return treeLeaves + estimatePairHashes(treeLeaves, strategy)
+}
+
+// EstimateProofLen returns the number of siblings of the proofs of the Merkle Tree of n leaves under the padding
+// strategy, i.e. the depth of the tree. With PaddingPromote, it is the maximum, as the proofs of the leaves under
+// promoted nodes have fewer siblings. It returns 0 if n is less than 2, as no tree is built.
+func EstimateProofLen(n int, strategy TypePaddingStrategy) int {
+	if n <= 1 {
+		return 0
+	}
+	return int(calTreeDepth(n))
+}
+
+// estimateTreeLeaves returns the number of leaves of the tree of n leaves, including the padding leaves of
+// PaddingIndexed.
+func estimateTreeLeaves(n int, strategy TypePaddingStrategy) int {
+	if strategy == PaddingIndexed && n > 1 {
+		return indexedPaddingLen(n)
+	}
+	return n
+}
+
+// estimatePairHashes returns the number of sibling pairs hashed into the internal nodes of the tree of treeLeaves
+// leaves, padding included, under the padding strategy: a pair for each internal node, except the promoted nodes.
+func estimatePairHashes(treeLeaves int, strategy TypePaddingStrategy) int {
+	if strategy != PaddingPromote {
+		return EstimateNodes(treeLeaves, strategy)
+	}
+	var numPairs int
+	for levelLen := treeLeaves; levelLen > 1; levelLen = (levelLen + 1) >> 1 {
+		numPairs += levelLen >> 1
+	}
+	return numPairs
+}
+
+// paddedLevelCap returns the capacity of a level of levelLen nodes, leaving room for the padding node
+// appended by fixOdd to an odd level, so that the padding does not reallocate the level.
+func paddedLevelCap(levelLen int) int {
+	return levelLen + levelLen&1
+}
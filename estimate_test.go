@@ -0,0 +1,90 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	var numHashes atomic.Int64
+	countingHashFunc := func(data []byte) ([]byte, error) {
+		numHashes.Add(1)
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}
+	sizes := []int{127, 128, 129, 500, 999, 1000}
+	for n := 2; n <= 64; n++ {
+		sizes = append(sizes, n)
+	}
+	strategies := []TypePaddingStrategy{PaddingDuplicate, PaddingPromote, PaddingIndexed}
+	modes := []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild}
+	for _, n := range sizes {
+		blocks := dataBlocks(n)
+		for _, strategy := range strategies {
+			for _, mode := range modes {
+				name := fmt.Sprintf("%d blocks/%v/%v", n, strategy, mode)
+				config := &Config{HashFunc: countingHashFunc, PaddingStrategy: strategy, Mode: mode}
+				numHashes.Store(0)
+				m, err := New(config, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() error = %v", name, err)
+				}
+				if got, want := EstimateHashCalls(n, strategy, mode), int(numHashes.Load()); got != want {
+					t.Errorf("%s: EstimateHashCalls() = %d, want %d", name, got, want)
+				}
+				if got, want := EstimateProofLen(n, strategy), int(m.Depth); got != want {
+					t.Errorf("%s: EstimateProofLen() = %d, want %d", name, got, want)
+				}
+				if mode == ModeProofGen {
+					continue
+				}
+				// The internal nodes are the tree nodes above the leaves, without padding, and the root.
+				want := 1
+				for level := 1; level < int(m.Depth); level++ {
+					want += (m.PaddedLeafCount() + 1<<level - 1) >> level
+				}
+				if got := EstimateNodes(n, strategy); got != want {
+					t.Errorf("%s: EstimateNodes() = %d, want %d", name, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestEstimate_noTree(t *testing.T) {
+	for _, n := range []int{-1, 0, 1} {
+		if got := EstimateHashCalls(n, PaddingDuplicate, ModeProofGen); got != 0 {
+			t.Errorf("EstimateHashCalls(%d) = %d, want 0", n, got)
+		}
+		if got := EstimateNodes(n, PaddingIndexed); got != 0 {
+			t.Errorf("EstimateNodes(%d) = %d, want 0", n, got)
+		}
+		if got := EstimateProofLen(n, PaddingPromote); got != 0 {
+			t.Errorf("EstimateProofLen(%d) = %d, want 0", n, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// VerificationReport is the detailed trace of the verification of a data block with its proof,
+// for diagnosing verification failures. The expected values are nil when they are unknown.
+type VerificationReport struct {
+	// Leaf is the leaf computed from the data block, and ExpectedLeaf is the leaf in the Merkle Tree.
+	Leaf, ExpectedLeaf []byte
+	// Steps are the hashing steps from the leaf to the root, one for each sibling of the proof.
+	Steps []VerificationStep
+	// Root is the root computed from the leaf and the proof, truncated to RootBytes if set,
+	// and ExpectedRoot is the Merkle root verified against.
+	Root, ExpectedRoot []byte
+	// FingerprintErr is the configuration fingerprint check error, if the proof carries a fingerprint.
+	FingerprintErr error
+}
+
+// VerificationStep is one hashing step of the verification, combining the current node with a sibling.
+type VerificationStep struct {
+	Level         int    // tree level of the sibling, 0 for the leaf level.
+	Sibling       []byte // sibling from the proof.
+	SiblingOnLeft bool   // whether the sibling is concatenated on the left of the current node.
+	Hash          []byte // parent node computed from the current node and the sibling.
+	// ExpectedSibling and ExpectedHash are the sibling and the parent node in the Merkle Tree.
+	ExpectedSibling, ExpectedHash []byte
+}
+
+// Verified reports whether the computed root matches the expected root.
+func (r *VerificationReport) Verified() bool {
+	return r.FingerprintErr == nil && bytes.Equal(r.Root, r.ExpectedRoot)
+}
+
+// Mismatch describes the first difference from the expected values, or returns an empty string if there is none.
+func (r *VerificationReport) Mismatch() string {
+	if r.FingerprintErr != nil {
+		return r.FingerprintErr.Error()
+	}
+	if r.ExpectedLeaf != nil && !bytes.Equal(r.Leaf, r.ExpectedLeaf) {
+		return fmt.Sprintf("leaf %x differs from the expected leaf %x, check the data block serialization", r.Leaf,
+			r.ExpectedLeaf)
+	}
+	for _, step := range r.Steps {
+		if step.ExpectedSibling != nil && !bytes.Equal(step.Sibling, step.ExpectedSibling) {
+			return fmt.Sprintf("sibling %x at level %d differs from the expected sibling %x", step.Sibling, step.Level,
+				step.ExpectedSibling)
+		}
+		if step.ExpectedHash != nil && !bytes.Equal(step.Hash, step.ExpectedHash) {
+			return fmt.Sprintf("hash %x at level %d differs from the expected hash %x", step.Hash, step.Level+1,
+				step.ExpectedHash)
+		}
+	}
+	if !bytes.Equal(r.Root, r.ExpectedRoot) {
+		return fmt.Sprintf("computed root %x differs from the expected root %x", r.Root, r.ExpectedRoot)
+	}
+	return ""
+}
+
+// ExplainVerification verifies the data block with the proof against the Merkle root like Verify,
+// and returns the trace of the computation: the leaf, the sibling and the hash of each step, and the root.
+// Root-to-leaf proofs are reversed first, so the steps are always from the leaf up to the root.
+// It is a diagnostics API, use Verify to verify proofs.
+func ExplainVerification(dataBlock DataBlock, proof *Proof, root []byte, config *Config) (*VerificationReport, error) {
+	if err := checkVerifyInput(dataBlock, proof, root); err != nil {
+		return nil, err
+	}
+	if proof.RootToLeaf {
+		proof = proof.Reverse()
+	}
+	config = verifyConfig(config)
+	report := &VerificationReport{
+		ExpectedRoot:   root,
+		FingerprintErr: checkFingerprint(proof.Fingerprint, config),
+		Steps:          make([]VerificationStep, len(proof.Siblings)),
+	}
+	leaf, err := leafFromBlock(dataBlock, proof.Index, config)
+	if err != nil {
+		return nil, err
+	}
+	report.Leaf = leaf
+	node := leaf
+	for level, sibling := range proof.Siblings {
+		step := VerificationStep{Level: level, Sibling: sibling, SiblingOnLeft: (proof.Path>>level)&1 == 0}
+		if step.SiblingOnLeft {
+			node, err = config.pairHash(level+1, sibling, node)
+		} else {
+			node, err = config.pairHash(level+1, node, sibling)
+		}
+		if err != nil {
+			return nil, err
+		}
+		step.Hash = node
+		report.Steps[level] = step
+	}
+	report.Root = truncateRoot(node, config)
+	return report, nil
+}
+
+// ExplainVerification explains the verification of the data block with the proof against the Merkle root,
+// filling the expected leaf, siblings and hashes from the Merkle Tree at the leaf index of the proof.
+// The expected hashes of the intermediate steps are only known if the tree is built.
+func (m *MerkleTree) ExplainVerification(dataBlock DataBlock, proof *Proof) (*VerificationReport, error) {
+	report, err := ExplainVerification(dataBlock, proof, m.Root, m.Config)
+	if err != nil {
+		return nil, err
+	}
+	idx := proof.Index
+	if idx < 0 || idx >= m.NumLeaves {
+		return report, nil
+	}
+	report.ExpectedLeaf = m.Leaves[idx]
+	// The expected proof is nil if it is not available, e.g. not selected by ProofIndices in ModeProofGen.
+	expected, _ := m.ProofOf(idx)
+	levels := m.siblingLevels(idx)
+	nodes, err := m.treeNodes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range report.Steps {
+		step := &report.Steps[i]
+		if expected != nil && i < len(expected.Siblings) {
+			step.ExpectedSibling = expected.Siblings[i]
+		}
+		if i >= len(levels) {
+			continue
+		}
+		// The node computed at a step is the parent at the level above its sibling.
+		if level := levels[i]; i == len(levels)-1 {
+			if m.RootBytes == 0 {
+				step.ExpectedHash = m.Root
+			}
+		} else if nodes != nil {
+			step.ExpectedHash = nodes[level+1][idx>>(level+1)]
+		}
+	}
+	return report, nil
+}
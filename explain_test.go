@@ -0,0 +1,117 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestMerkleTree_ExplainVerification(t *testing.T) {
+	blocks := dataBlocks(11)
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		m, err := New(&Config{Mode: mode}, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		var proof *Proof
+		if m.Proofs != nil {
+			proof = m.Proofs[6]
+		} else if proof, err = m.Proof(blocks[6]); err != nil {
+			t.Fatalf("Proof() error = %v", err)
+		}
+		tamperedSibling := &Proof{Siblings: append([][]byte(nil), proof.Siblings...), Path: proof.Path, Index: 6}
+		tamperedSibling.Siblings[2] = bytes.Repeat([]byte{1}, defaultHashLen)
+		tests := []struct {
+			name     string
+			block    DataBlock
+			proof    *Proof
+			verified bool
+			mismatch string
+		}{
+			{"verified", blocks[6], proof, true, ""},
+			{"serialization_differs", &mock.DataBlock{Data: append(blocks[6].(*mock.DataBlock).Data, 0)}, proof,
+				false, "leaf"},
+			{"sibling_differs", blocks[6], tamperedSibling, false, "sibling"},
+		}
+		for _, tt := range tests {
+			t.Run(mode.String()+"/"+tt.name, func(t *testing.T) {
+				report, err := m.ExplainVerification(tt.block, tt.proof)
+				if err != nil {
+					t.Fatalf("ExplainVerification() error = %v", err)
+				}
+				if report.Verified() != tt.verified {
+					t.Errorf("Verified() = %v, want %v", report.Verified(), tt.verified)
+				}
+				ok, _ := m.Verify(tt.block, tt.proof)
+				if ok != report.Verified() {
+					t.Errorf("Verified() = %v, differs from Verify() = %v", report.Verified(), ok)
+				}
+				if got := report.Mismatch(); !strings.HasPrefix(got, tt.mismatch) {
+					t.Errorf("Mismatch() = %q, want prefix %q", got, tt.mismatch)
+				}
+				if len(report.Steps) != int(m.Depth) {
+					t.Fatalf("report has %d steps, want %d", len(report.Steps), m.Depth)
+				}
+				if !bytes.Equal(report.Steps[len(report.Steps)-1].Hash, report.Root) {
+					t.Errorf("last step hash differs from the computed root")
+				}
+				if tt.verified {
+					for _, step := range report.Steps {
+						if step.ExpectedHash != nil && !bytes.Equal(step.Hash, step.ExpectedHash) {
+							t.Errorf("step %d hash differs from the tree node", step.Level)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExplainVerification(t *testing.T) {
+	blocks := dataBlocks(5)
+	m, err := New(&Config{SortSiblingPairs: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := ExplainVerification(blocks[2], m.Proofs[2], m.Root, &Config{SortSiblingPairs: true})
+	if err != nil {
+		t.Fatalf("ExplainVerification() error = %v", err)
+	}
+	if !report.Verified() || report.Mismatch() != "" {
+		t.Errorf("ExplainVerification() = %+v, want verified", report)
+	}
+	report, err = ExplainVerification(blocks[2], m.Proofs[2], make([]byte, defaultHashLen), &Config{SortSiblingPairs: true})
+	if err != nil {
+		t.Fatalf("ExplainVerification() error = %v", err)
+	}
+	if report.Verified() || !strings.HasPrefix(report.Mismatch(), "computed root") {
+		t.Errorf("Mismatch() = %q, want root mismatch", report.Mismatch())
+	}
+	if _, err := ExplainVerification(nil, m.Proofs[2], m.Root, nil); err == nil {
+		t.Errorf("ExplainVerification() error = nil, want error for nil data block")
+	}
+}
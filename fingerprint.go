@@ -0,0 +1,79 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+const (
+	// fingerprintLen is the length of the configuration fingerprint in bytes.
+	fingerprintLen = 8
+	// fingerprintProbe is the input hashed to identify the hash function in the configuration fingerprint.
+	fingerprintProbe = "go-merkletree config fingerprint"
+)
+
+// ErrConfigMismatch is returned by Verify when the configuration fingerprint embedded in the proof
+// does not match the configuration of the verifier, e.g. a different hash function or sorting flag.
+var ErrConfigMismatch = errors.New("proof configuration fingerprint does not match the verifier configuration")
+
+// configFingerprint computes a short hash of the configuration fields relevant to verification:
+// the hash function, identified by its output on a fixed input, and the flags changing the leaves and nodes.
+// The configuration must be initialized with its hash function.
+func configFingerprint(config *Config) ([]byte, error) {
+	probe, err := config.HashFunc([]byte(fingerprintProbe))
+	if err != nil {
+		return nil, err
+	}
+	var flags byte
+	for i, flag := range []bool{
+		config.SortSiblingPairs, config.DisableLeafHashing, config.BindLeafIndex, config.LeafTransform != nil,
+		config.PairHashFunc != nil, config.LevelHashFunc != nil,
+	} {
+		if flag {
+			flags |= 1 << i
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte{flags})
+	h.Write(probe)
+	return h.Sum(nil)[:fingerprintLen], nil
+}
+
+// checkFingerprint returns ErrConfigMismatch if the fingerprint embedded in a proof does not match the configuration.
+// Proofs without a fingerprint are not checked.
+func checkFingerprint(fingerprint []byte, config *Config) error {
+	if fingerprint == nil {
+		return nil
+	}
+	want, err := configFingerprint(config)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(fingerprint, want) {
+		return ErrConfigMismatch
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha512"
+	"errors"
+	"testing"
+)
+
+func TestVerify_embedFingerprint(t *testing.T) {
+	sha512HashFunc := func(data []byte) ([]byte, error) {
+		h := sha512.Sum512(data)
+		return h[:], nil
+	}
+	blocks := dataBlocks(5)
+	tests := []struct {
+		name         string
+		config       *Config
+		verifyConfig *Config
+		wantErr      error
+	}{
+		{"matching", &Config{EmbedFingerprint: true}, nil, nil},
+		{"matching_sorted", &Config{EmbedFingerprint: true, SortSiblingPairs: true, RunInParallel: true},
+			&Config{SortSiblingPairs: true}, nil},
+		{"matching_tree_build", &Config{EmbedFingerprint: true, Mode: ModeTreeBuild}, nil, nil},
+		{"mismatched_sorting", &Config{EmbedFingerprint: true, SortSiblingPairs: true}, nil, ErrConfigMismatch},
+		{"mismatched_sorting_verifier", &Config{EmbedFingerprint: true},
+			&Config{SortSiblingPairs: true}, ErrConfigMismatch},
+		{"mismatched_hash_func", &Config{EmbedFingerprint: true, HashFunc: sha512HashFunc},
+			nil, ErrConfigMismatch},
+		{"mismatched_leaf_index", &Config{EmbedFingerprint: true}, &Config{BindLeafIndex: true}, ErrConfigMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for i, block := range blocks {
+				var proof *Proof
+				if m.Proofs != nil {
+					proof = m.Proofs[i]
+				} else if proof, err = m.Proof(block); err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if len(proof.Fingerprint) != fingerprintLen {
+					t.Fatalf("proof fingerprint length = %d, want %d", len(proof.Fingerprint), fingerprintLen)
+				}
+				ok, err := Verify(block, proof, m.Root, tt.verifyConfig)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				if ok != (tt.wantErr == nil) {
+					t.Errorf("Verify() = %v, want %v", ok, tt.wantErr == nil)
+				}
+			}
+		})
+	}
+	m, err := New(&Config{BindLeafIndex: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.Proofs[0].Fingerprint != nil {
+		t.Errorf("proof fingerprint = %x, want nil without EmbedFingerprint", m.Proofs[0].Fingerprint)
+	}
+	// Without the fingerprint, the mismatch is a silent verification failure.
+	if ok, err := Verify(blocks[0], m.Proofs[0], m.Root, nil); ok || err != nil {
+		t.Errorf("Verify() = %v, %v, want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,67 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "errors"
+
+// FlatProofs returns the proofs of all the leaves laid out in one contiguous byte slice, e.g. for batch
+// processing on a GPU or in a zero-knowledge circuit, without a slice of slices.
+// data[offsets[i]:offsets[i+1]] is the concatenation of the siblings of the proof of leaf i, from the leaf level
+// up, so offsets has NumLeaves+1 entries. The proof of each leaf has Depth siblings, fewer with PaddingPromote,
+// and their directions are packed in directions: the path bit l of the proof of leaf i is
+// bit (i*Depth+l)%8 of directions[(i*Depth+l)/8], 1 meaning that the sibling is on the right.
+// The proofs are taken from Proofs if available, otherwise they are generated from the tree nodes.
+func (m *MerkleTree) FlatProofs() (data []byte, offsets []int, directions []byte, err error) {
+	if m.Proofs == nil && m.nodes == nil {
+		return nil, nil, nil, errors.New("merkle Tree proofs and nodes are not available")
+	}
+	proofs := make([]*Proof, m.NumLeaves)
+	for i := range proofs {
+		if proofs[i], err = m.ProofOf(i); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	var (
+		depth = int(m.Depth)
+		size  int
+	)
+	for _, proof := range proofs {
+		for _, sibling := range proof.Siblings {
+			size += len(sibling)
+		}
+	}
+	data = make([]byte, 0, size)
+	offsets = make([]int, len(proofs)+1)
+	directions = make([]byte, (len(proofs)*depth+7)/8)
+	for i, proof := range proofs {
+		for l, sibling := range proof.Siblings {
+			data = append(data, sibling...)
+			if proof.Path>>l&1 == 1 {
+				bit := i*depth + l
+				directions[bit>>3] |= 1 << (bit & 7)
+			}
+		}
+		offsets[i+1] = len(data)
+	}
+	return data, offsets, directions, nil
+}
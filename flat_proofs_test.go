@@ -0,0 +1,70 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "testing"
+
+func TestMerkleTree_FlatProofs(t *testing.T) {
+	blocks := dataBlocks(11)
+	for _, config := range []*Config{
+		{},
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, SortSiblingPairs: true},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		data, offsets, directions, err := m.FlatProofs()
+		if err != nil {
+			t.Fatalf("FlatProofs() error = %v", err)
+		}
+		if len(offsets) != m.NumLeaves+1 || offsets[m.NumLeaves] != len(data) {
+			t.Fatalf("FlatProofs() offsets = %v, want %d entries ending at %d", offsets, m.NumLeaves+1, len(data))
+		}
+		depth := int(m.Depth)
+		for i, block := range blocks {
+			siblingData := data[offsets[i]:offsets[i+1]]
+			hashSize := len(siblingData) / depth
+			proof := &Proof{Index: i, Siblings: make([][]byte, depth)}
+			for l := 0; l < depth; l++ {
+				proof.Siblings[l] = siblingData[l*hashSize : (l+1)*hashSize]
+				bit := i*depth + l
+				if directions[bit>>3]>>(bit&7)&1 == 1 {
+					proof.Path |= 1 << l
+				}
+			}
+			if m.Proofs != nil && !proof.Equal(m.Proofs[i]) {
+				t.Errorf("proof %d from the flat layout differs from Proofs[%d]", i, i)
+			}
+			ok, err := Verify(block, proof, m.Root, config)
+			if err != nil || !ok {
+				t.Errorf("Verify() of the proof %d from the flat layout = %v, %v, want true", i, ok, err)
+			}
+		}
+	}
+	m := &MerkleTree{Config: &Config{}}
+	if _, _, _, err := m.FlatProofs(); err == nil {
+		t.Error("FlatProofs() without proofs and nodes: error = nil")
+	}
+}
@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// hashFuncFromHasher derives a hash function from the streaming hash constructor.
+// The derived hash function is concurrent safe, as every invocation uses a new hash.Hash.
+func hashFuncFromHasher(hasher func() hash.Hash) TypeHashFunc {
+	return func(data []byte) ([]byte, error) {
+		h := hasher()
+		if _, err := h.Write(data); err != nil {
+			return nil, err
+		}
+		return h.Sum(nil), nil
+	}
+}
+
+// hashLeafStreaming hashes the serialized data block at the leaf index with the configured Hasher,
+// writing the data in ChunkSize increments if ChunkSize is set.
+func hashLeafStreaming(config *Config, blockBytes []byte, idx int) ([]byte, error) {
+	h, err := newLeafHash(config, idx)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeChunked(h, blockBytes, config.ChunkSize); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// newLeafHash returns a new hash.Hash of the configured Hasher for the leaf at the leaf index,
+// with the leaf index written first if BindLeafIndex is true.
+func newLeafHash(config *Config, idx int) (hash.Hash, error) {
+	h := config.Hasher()
+	if config.BindLeafIndex {
+		var idxBytes [8]byte
+		binary.BigEndian.PutUint64(idxBytes[:], uint64(idx))
+		if _, err := h.Write(idxBytes[:]); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// writeChunked writes the data to the hash in chunks of the chunk size, or at once if the chunk size is not positive.
+func writeChunked(h hash.Hash, data []byte, chunkSize int) error {
+	if chunkSize <= 0 {
+		_, err := h.Write(data)
+		return err
+	}
+	for start := 0; start < len(data); start += chunkSize {
+		end := min(start+chunkSize, len(data))
+		if _, err := h.Write(data[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
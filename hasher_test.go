@@ -0,0 +1,133 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"sync"
+	"testing"
+)
+
+// chunkRecorder is a SHA256 hash recording the size of each write.
+type chunkRecorder struct {
+	hash.Hash
+	mu     *sync.Mutex
+	writes *[]int
+}
+
+func (c chunkRecorder) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	*c.writes = append(*c.writes, len(p))
+	c.mu.Unlock()
+	return c.Hash.Write(p)
+}
+
+func newChunkRecorder() (func() hash.Hash, *[]int) {
+	var (
+		mu     sync.Mutex
+		writes []int
+	)
+	return func() hash.Hash {
+		return chunkRecorder{Hash: sha256.New(), mu: &mu, writes: &writes}
+	}, &writes
+}
+
+func Test_hashLeafStreaming(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	want, err := DefaultHashFunc(data)
+	if err != nil {
+		t.Fatalf("DefaultHashFunc() error = %v", err)
+	}
+	for _, chunkSize := range []int{0, 1, 7, 64, 999, 1000, 4096} {
+		hasher, writes := newChunkRecorder()
+		got, err := hashLeafStreaming(&Config{Hasher: hasher, ChunkSize: chunkSize}, data, 0)
+		if err != nil {
+			t.Fatalf("hashLeafStreaming() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("chunk size %d: hashLeafStreaming() = %x, want %x", chunkSize, got, want)
+		}
+		for _, size := range *writes {
+			if chunkSize > 0 && size > chunkSize {
+				t.Errorf("chunk size %d: wrote %d bytes at once", chunkSize, size)
+			}
+		}
+	}
+}
+
+func TestNew_hasher(t *testing.T) {
+	blocks := dataBlocks(9)
+	tests := []struct {
+		name   string
+		config *Config
+		want   *Config
+	}{
+		{
+			name:   "test_hasher",
+			config: &Config{Hasher: sha256.New},
+			want:   &Config{},
+		},
+		{
+			name:   "test_hasher_chunked",
+			config: &Config{Hasher: sha256.New, ChunkSize: 16},
+			want:   &Config{},
+		},
+		{
+			name:   "test_hasher_chunked_parallel",
+			config: &Config{Hasher: sha256.New, ChunkSize: 16, RunInParallel: true, NumRoutines: 4},
+			want:   &Config{},
+		},
+		{
+			name:   "test_hasher_chunked_bind_leaf_index",
+			config: &Config{Hasher: sha256.New, ChunkSize: 3, BindLeafIndex: true},
+			want:   &Config{BindLeafIndex: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			want, err := New(tt.want, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(got.Root, want.Root) {
+				t.Errorf("New() root = %x, want %x", got.Root, want.Root)
+			}
+			for i, block := range blocks {
+				ok, err := Verify(block, got.Proofs[i], got.Root,
+					&Config{Hasher: sha256.New, ChunkSize: 5, BindLeafIndex: tt.config.BindLeafIndex})
+				if err != nil || !ok {
+					t.Errorf("Verify() = %v, %v, want true", ok, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// ProofGenerator generates the Merkle proofs of data blocks, e.g. a Merkle Tree.
+type ProofGenerator interface {
+	GenerateProof(dataBlock DataBlock) (*Proof, error)
+}
+
+// RootProvider provides a Merkle root.
+// The method is named MerkleRoot, as Root is a field of MerkleTree.
+type RootProvider interface {
+	MerkleRoot() []byte
+}
+
+// Verifier verifies data blocks with their Merkle proofs, against its own Merkle root and configuration.
+type Verifier interface {
+	Verify(dataBlock DataBlock, proof *Proof) (bool, error)
+}
+
+// Tree is the interface of the Merkle Tree variants, so that the applications are programmed against it
+// and the variants are interchangeable.
+type Tree interface {
+	ProofGenerator
+	RootProvider
+	Verifier
+}
+
+var _ Tree = (*MerkleTree)(nil)
@@ -0,0 +1,74 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+var (
+	_ ProofGenerator = (*MerkleTree)(nil)
+	_ RootProvider   = (*MerkleTree)(nil)
+	_ Verifier       = (*MerkleTree)(nil)
+	_ Tree           = (*MerkleTree)(nil)
+)
+
+func TestTree(t *testing.T) {
+	blocks := dataBlocks(7)
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		m, err := New(&Config{Mode: mode}, blocks)
+		if err != nil {
+			t.Fatalf("%v: New() error = %v", mode, err)
+		}
+		var tree Tree = m
+		if !bytes.Equal(tree.MerkleRoot(), m.Root) {
+			t.Errorf("%v: MerkleRoot() = %x, want %x", mode, tree.MerkleRoot(), m.Root)
+		}
+		for i, block := range blocks {
+			proof, err := tree.GenerateProof(block)
+			if err != nil {
+				t.Fatalf("%v: GenerateProof() error = %v", mode, err)
+			}
+			if proof.Index != i {
+				t.Errorf("%v: GenerateProof() of block %d has index %d", mode, i, proof.Index)
+			}
+			if ok, err := tree.Verify(block, proof); err != nil || !ok {
+				t.Errorf("%v: Verify() of block %d = %v, %v, want true", mode, i, ok, err)
+			}
+		}
+		if _, err := tree.GenerateProof(dataBlocks(1)[0]); err == nil {
+			t.Errorf("%v: GenerateProof() of a non-member error = nil, want error", mode)
+		}
+		if _, err := tree.GenerateProof(nil); err == nil {
+			t.Errorf("%v: GenerateProof() of nil error = nil, want error", mode)
+		}
+	}
+	m, err := New(&Config{BindLeafIndex: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := m.GenerateProof(blocks[0]); err == nil {
+		t.Errorf("GenerateProof() with BindLeafIndex error = nil, want error")
+	}
+}
@@ -0,0 +1,47 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package reference implements the canonical Merkle Tree building algorithm of go-merkletree
+// in its simplest form, recursive and serial, as the reference the optimized tree building is tested against.
+package reference
+
+// BuildRoot returns the Merkle root of the leaf hashes, combining each pair of sibling nodes into their parent
+// with combine, e.g. hashing their concatenation. A level of an odd number of nodes is padded
+// by duplicating its last node, and the root of a single node is the node itself.
+// It returns nil if there are no leaf hashes. The leaf hashes are not modified.
+func BuildRoot(leafHashes [][]byte, combine func(left, right []byte) []byte) []byte {
+	switch len(leafHashes) {
+	case 0:
+		return nil
+	case 1:
+		return leafHashes[0]
+	}
+	level := leafHashes
+	if len(level)%2 == 1 {
+		level = append(level[:len(level):len(level)], level[len(level)-1])
+	}
+	parents := make([][]byte, len(level)/2)
+	for i := range parents {
+		parents[i] = combine(level[2*i], level[2*i+1])
+	}
+	return BuildRoot(parents, combine)
+}
@@ -0,0 +1,58 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package reference
+
+import (
+	"bytes"
+	"testing"
+)
+
+// concat combines the nodes by concatenation, so that the root spells out the tree.
+func concat(left, right []byte) []byte {
+	return append(append([]byte{'('}, left...), append(append([]byte{' '}, right...), ')')...)
+}
+
+func TestBuildRoot(t *testing.T) {
+	tests := []struct {
+		leaves string
+		want   string
+	}{
+		{"", ""},
+		{"a", "a"},
+		{"ab", "(a b)"},
+		{"abc", "((a b) (c c))"},
+		{"abcd", "((a b) (c d))"},
+		{"abcde", "(((a b) (c d)) ((e e) (e e)))"},
+		{"abcdefghi", "((((a b) (c d)) ((e f) (g h))) (((i i) (i i)) ((i i) (i i))))"},
+	}
+	for _, tt := range tests {
+		leaves := make([][]byte, len(tt.leaves))
+		for i := range leaves {
+			leaves[i] = []byte{tt.leaves[i]}
+		}
+		got := BuildRoot(leaves, concat)
+		if want := []byte(tt.want); !bytes.Equal(got, want) && !(len(got) == 0 && len(want) == 0) {
+			t.Errorf("BuildRoot(%q) = %q, want %q", tt.leaves, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxJournalRecordSize is the maximum size in bytes of the payload of a journal record,
+// so that a corrupted length prefix does not allocate an arbitrary amount of memory.
+const maxJournalRecordSize = 1 << 24
+
+var (
+	// ErrJournalTruncated is returned when the final record of a proof journal is incomplete,
+	// e.g. after a crash during an append. The records before it are valid.
+	ErrJournalTruncated = errors.New("proof journal record is truncated")
+	// ErrJournalCorrupted is returned when a record of a proof journal does not match its checksum,
+	// i.e. the record or a record before it was modified.
+	ErrJournalCorrupted = errors.New("proof journal record is corrupted")
+)
+
+// JournalEntry is a record of a proof journal: the leaf hash of a processed data block, its proof,
+// and the Merkle root at that time.
+type JournalEntry struct {
+	LeafHash []byte
+	Proof    *Proof
+	Root     []byte
+}
+
+// ProofJournal writes journal entries to an append-only file, e.g. to archive the proof of every processed record.
+//
+// Each record is the payload length as a big-endian uint32, the payload, and a 32-byte checksum.
+// The payload is the leaf hash and the root, each prefixed with its length as a uvarint,
+// followed by the binary encoding of the proof. The checksum is the SHA-256 hash of the checksum
+// of the previous record, or 32 zero bytes for the first record, followed by the payload,
+// so the checksums chain the records, and modifying or removing a record invalidates the following ones.
+// ProofJournal is not safe for concurrent use.
+type ProofJournal struct {
+	w        io.Writer
+	checksum [sha256.Size]byte
+}
+
+// NewProofJournal returns a proof journal writing a new journal to the writer.
+func NewProofJournal(w io.Writer) *ProofJournal {
+	return &ProofJournal{w: w}
+}
+
+// ResumeProofJournal returns a proof journal appending to the journal read by the reader,
+// which must have been read to the end without error other than ErrJournalTruncated.
+// The writer must append after the valid records, e.g. to the file truncated to r.Offset() bytes,
+// so that a record truncated by a crash is overwritten.
+func ResumeProofJournal(w io.Writer, r *JournalReader) *ProofJournal {
+	return &ProofJournal{w: w, checksum: r.checksum}
+}
+
+// Append writes the entry as one record to the journal with a single Write call.
+func (j *ProofJournal) Append(entry JournalEntry) error {
+	if entry.Proof == nil {
+		return errors.New("journal entry has no proof")
+	}
+	proofBytes, err := entry.Proof.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	payloadSize := uvarintLen(uint64(len(entry.LeafHash))) + len(entry.LeafHash) +
+		uvarintLen(uint64(len(entry.Root))) + len(entry.Root) + len(proofBytes)
+	if payloadSize > maxJournalRecordSize {
+		return fmt.Errorf("journal entry of %d bytes exceeds %d bytes", payloadSize, maxJournalRecordSize)
+	}
+	record := make([]byte, 4, 4+payloadSize+sha256.Size)
+	binary.BigEndian.PutUint32(record, uint32(payloadSize))
+	record = binary.AppendUvarint(record, uint64(len(entry.LeafHash)))
+	record = append(record, entry.LeafHash...)
+	record = binary.AppendUvarint(record, uint64(len(entry.Root)))
+	record = append(record, entry.Root...)
+	record = append(record, proofBytes...)
+	checksum := chainChecksum(j.checksum, record[4:])
+	if _, err = j.w.Write(append(record, checksum[:]...)); err != nil {
+		return err
+	}
+	j.checksum = checksum
+	return nil
+}
+
+// chainChecksum returns the checksum of the payload chained to the checksum of the previous record.
+func chainChecksum(prev [sha256.Size]byte, payload []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(prev[:])
+	h.Write(payload)
+	var checksum [sha256.Size]byte
+	h.Sum(checksum[:0])
+	return checksum
+}
+
+// JournalReader reads the entries of a proof journal written by ProofJournal.
+type JournalReader struct {
+	r        *bufio.Reader
+	checksum [sha256.Size]byte
+	offset   int64
+	count    int
+}
+
+// NewJournalReader returns a reader of the proof journal read from the reader.
+func NewJournalReader(r io.Reader) *JournalReader {
+	return &JournalReader{r: bufio.NewReader(r)}
+}
+
+// Offset returns the size in bytes of the valid records read so far.
+func (r *JournalReader) Offset() int64 {
+	return r.offset
+}
+
+// Next returns the next entry of the journal, or io.EOF at the end of the journal.
+// It returns ErrJournalTruncated if the final record is incomplete, and ErrJournalCorrupted
+// if a record does not match its checksum, with the index of the record.
+func (r *JournalReader) Next() (*JournalEntry, error) {
+	var header [4]byte
+	if n, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.EOF && n == 0 {
+			return nil, io.EOF
+		}
+		return nil, r.readError(err)
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxJournalRecordSize {
+		return nil, fmt.Errorf("%w: record %d has invalid size %d", ErrJournalCorrupted, r.count, size)
+	}
+	record := make([]byte, int(size)+sha256.Size)
+	if _, err := io.ReadFull(r.r, record); err != nil {
+		return nil, r.readError(err)
+	}
+	payload := record[:size]
+	checksum := chainChecksum(r.checksum, payload)
+	if !bytes.Equal(record[size:], checksum[:]) {
+		return nil, fmt.Errorf("%w: record %d does not match its checksum", ErrJournalCorrupted, r.count)
+	}
+	d := proofDecoder{data: payload}
+	entry := &JournalEntry{LeafHash: d.bytes(), Root: d.bytes()}
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: record %d: %v", ErrJournalCorrupted, r.count, d.err)
+	}
+	entry.Proof = new(Proof)
+	if err := entry.Proof.UnmarshalBinary(d.data); err != nil {
+		return nil, fmt.Errorf("%w: record %d: %v", ErrJournalCorrupted, r.count, err)
+	}
+	r.checksum, r.offset, r.count = checksum, r.offset+4+int64(len(record)), r.count+1
+	return entry, nil
+}
+
+// readError converts the end of the journal in the middle of a record into ErrJournalTruncated.
+func (r *JournalReader) readError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: record %d at offset %d", ErrJournalTruncated, r.count, r.offset)
+	}
+	return err
+}
+
+// VerifyAll reads the remaining entries of the journal and verifies the proof of every entry
+// from its leaf hash against its root with the configuration, stopping at the first invalid entry.
+// It returns the number of entries verified, and an error describing the first invalid entry,
+// or wrapping ErrJournalTruncated if the final record is incomplete while all the entries before it are valid.
+func (r *JournalReader) VerifyAll(config *Config) (int, error) {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	verifier := verifyConfig(&c)
+	verified := 0
+	for {
+		index := r.count
+		entry, err := r.Next()
+		if err == io.EOF {
+			return verified, nil
+		}
+		if err != nil {
+			return verified, err
+		}
+		if err = checkProofDepth(entry.Proof, verifier); err != nil {
+			return verified, fmt.Errorf("journal entry %d: %w", index, err)
+		}
+		if err = checkFingerprint(entry.Proof.Fingerprint, verifier); err != nil {
+			return verified, fmt.Errorf("journal entry %d: %w", index, err)
+		}
+		root, err := rootFromLeaf(entry.LeafHash, entry.Proof, verifier)
+		if err != nil {
+			return verified, fmt.Errorf("journal entry %d: %w", index, err)
+		}
+		if !rootMatches(root, entry.Root, verifier) {
+			return verified, fmt.Errorf("journal entry %d: proof does not verify against the root", index)
+		}
+		verified++
+	}
+}
@@ -0,0 +1,160 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// writeJournal writes the entries of the proofs of the Merkle Tree to a new journal.
+func writeJournal(t *testing.T, m *MerkleTree) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	j := NewProofJournal(&buf)
+	for i, proof := range m.Proofs {
+		if err := j.Append(JournalEntry{LeafHash: m.Leaves[i], Proof: proof, Root: m.Root}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	return &buf
+}
+
+func TestProofJournal(t *testing.T) {
+	config := &Config{EmbedFingerprint: true}
+	m, err := New(config, dataBlocks(6))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data := writeJournal(t, m).Bytes()
+
+	r := NewJournalReader(bytes.NewReader(data))
+	for i := range m.Proofs {
+		entry, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if !bytes.Equal(entry.LeafHash, m.Leaves[i]) || !bytes.Equal(entry.Root, m.Root) ||
+			!entry.Proof.Equal(m.Proofs[i]) {
+			t.Errorf("Next() entry %d = %+v, want the entry of proof %d", i, entry, i)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next() at the end error = %v, want io.EOF", err)
+	}
+	if r.Offset() != int64(len(data)) {
+		t.Errorf("Offset() = %d, want %d", r.Offset(), len(data))
+	}
+
+	n, err := NewJournalReader(bytes.NewReader(data)).VerifyAll(config)
+	if err != nil || n != len(m.Proofs) {
+		t.Errorf("VerifyAll() = %d, %v, want %d, nil", n, err, len(m.Proofs))
+	}
+}
+
+func TestProofJournal_truncated(t *testing.T) {
+	m, err := New(nil, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data := writeJournal(t, m).Bytes()
+	r := NewJournalReader(bytes.NewReader(data))
+	for range m.Proofs[:3] {
+		if _, err := r.Next(); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+	}
+	validSize := r.Offset()
+	// Every truncation of the final record is detected, and the records before it are valid.
+	for size := validSize + 1; size < int64(len(data)); size++ {
+		n, err := NewJournalReader(bytes.NewReader(data[:size])).VerifyAll(nil)
+		if n != 3 || !errors.Is(err, ErrJournalTruncated) {
+			t.Fatalf("VerifyAll() of %d bytes = %d, %v, want 3, ErrJournalTruncated", size, n, err)
+		}
+	}
+
+	// The journal is resumed after the valid records, overwriting the truncated record.
+	r = NewJournalReader(bytes.NewReader(data[:len(data)-5]))
+	for {
+		if _, err := r.Next(); err != nil {
+			if !errors.Is(err, ErrJournalTruncated) {
+				t.Fatalf("Next() error = %v, want ErrJournalTruncated", err)
+			}
+			break
+		}
+	}
+	resumed := bytes.NewBuffer(append([]byte(nil), data[:r.Offset()]...))
+	j := ResumeProofJournal(resumed, r)
+	if err := j.Append(JournalEntry{LeafHash: m.Leaves[3], Proof: m.Proofs[3], Root: m.Root}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if !bytes.Equal(resumed.Bytes(), data) {
+		t.Errorf("resumed journal differs from the journal written at once")
+	}
+}
+
+func TestProofJournal_invalid(t *testing.T) {
+	m, err := New(nil, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data := writeJournal(t, m).Bytes()
+	recordSize := len(data) / 4
+
+	// Modifying a record invalidates its checksum.
+	tampered := append([]byte(nil), data...)
+	tampered[recordSize+10] ^= 1
+	n, err := NewJournalReader(bytes.NewReader(tampered)).VerifyAll(nil)
+	if n != 1 || !errors.Is(err, ErrJournalCorrupted) {
+		t.Errorf("VerifyAll() of a tampered journal = %d, %v, want 1, ErrJournalCorrupted", n, err)
+	}
+
+	// Removing a record breaks the checksum chain.
+	removed := append(append([]byte(nil), data[:recordSize]...), data[2*recordSize:]...)
+	n, err = NewJournalReader(bytes.NewReader(removed)).VerifyAll(nil)
+	if n != 1 || !errors.Is(err, ErrJournalCorrupted) {
+		t.Errorf("VerifyAll() of a journal with a removed record = %d, %v, want 1, ErrJournalCorrupted", n, err)
+	}
+
+	// A well-formed entry whose proof does not verify is reported.
+	var buf bytes.Buffer
+	j := NewProofJournal(&buf)
+	for _, entry := range []JournalEntry{
+		{LeafHash: m.Leaves[0], Proof: m.Proofs[0], Root: m.Root},
+		{LeafHash: m.Leaves[1], Proof: m.Proofs[2], Root: m.Root},
+	} {
+		if err := j.Append(entry); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	n, err = NewJournalReader(&buf).VerifyAll(nil)
+	if n != 1 || err == nil {
+		t.Errorf("VerifyAll() with an invalid proof = %d, %v, want 1, error", n, err)
+	}
+
+	if err := NewProofJournal(io.Discard).Append(JournalEntry{}); err == nil {
+		t.Errorf("Append() without proof error = nil, want error")
+	}
+}
@@ -0,0 +1,93 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// KeyedDataBlock is a data block addressable by a user-defined key, e.g. an order number.
+// If all the keys are unique, proofs can be fetched by key with GenerateProofByKey.
+// Data blocks not implementing the interface are not addressable by key.
+type KeyedDataBlock interface {
+	DataBlock
+	Key() string
+}
+
+// buildKeyMap maps the keys of the keyed data blocks to their leaf indexes.
+// It returns nil if no data block is keyed, and an error listing the keys used more than once.
+func buildKeyMap(blocks []DataBlock) (map[string]int, error) {
+	var (
+		keyMap     map[string]int
+		duplicates map[string]struct{}
+	)
+	for i, block := range blocks {
+		keyed, ok := block.(KeyedDataBlock)
+		if !ok {
+			continue
+		}
+		if keyMap == nil {
+			keyMap = make(map[string]int)
+		}
+		key := keyed.Key()
+		if _, ok := keyMap[key]; ok {
+			if duplicates == nil {
+				duplicates = make(map[string]struct{})
+			}
+			duplicates[key] = struct{}{}
+			continue
+		}
+		keyMap[key] = i
+	}
+	if duplicates != nil {
+		keys := make([]string, 0, len(duplicates))
+		for key := range duplicates {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return nil, errors.New("duplicate data block keys: " + strings.Join(keys, ", "))
+	}
+	return keyMap, nil
+}
+
+// IndexOfKey returns the leaf index of the data block with the key.
+func (m *MerkleTree) IndexOfKey(key string) (int, error) {
+	idx, ok := m.keyMap[key]
+	if !ok {
+		return 0, errors.New("no data block with key: " + key)
+	}
+	return idx, nil
+}
+
+// GenerateProofByKey returns the proof of the data block with the key.
+// In ModeProofGen and ModeProofGenAndTreeBuild, the proof generated during the tree building process is returned.
+// In ModeTreeBuild, the proof is generated from the cached tree nodes.
+func (m *MerkleTree) GenerateProofByKey(key string) (*Proof, error) {
+	idx, err := m.IndexOfKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return m.ProofOf(idx)
+}
@@ -0,0 +1,134 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type keyedBlock struct {
+	key  string
+	data []byte
+}
+
+func (b *keyedBlock) Serialize() ([]byte, error) {
+	return b.data, nil
+}
+
+func (b *keyedBlock) Key() string {
+	return b.key
+}
+
+func keyedBlocks(keys ...string) []DataBlock {
+	blocks := make([]DataBlock, len(keys))
+	for i, key := range keys {
+		blocks[i] = &keyedBlock{key: key, data: []byte("data of " + key)}
+	}
+	return blocks
+}
+
+func TestMerkleTree_GenerateProofByKey(t *testing.T) {
+	keys := make([]string, 11)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("order-%d", i)
+	}
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{
+			name:   "test_proof_gen",
+			config: &Config{Mode: ModeProofGen},
+		},
+		{
+			name:   "test_tree_build",
+			config: &Config{Mode: ModeTreeBuild},
+		},
+		{
+			name:   "test_proof_gen_and_tree_build",
+			config: &Config{Mode: ModeProofGenAndTreeBuild},
+		},
+		{
+			name:   "test_tree_build_parallel",
+			config: &Config{Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := keyedBlocks(keys...)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for i, key := range keys {
+				idx, err := m.IndexOfKey(key)
+				if err != nil {
+					t.Fatalf("IndexOfKey() error = %v", err)
+				}
+				if idx != i {
+					t.Errorf("IndexOfKey() = %d, want %d", idx, i)
+				}
+				proof, err := m.GenerateProofByKey(key)
+				if err != nil {
+					t.Fatalf("GenerateProofByKey() error = %v", err)
+				}
+				if ok, err := m.Verify(blocks[i], proof); err != nil || !ok {
+					t.Errorf("Verify() = %v, %v, want true", ok, err)
+				}
+			}
+			if _, err := m.GenerateProofByKey("order-unknown"); err == nil {
+				t.Errorf("GenerateProofByKey() unknown key error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNew_duplicateKeys(t *testing.T) {
+	_, err := New(nil, keyedBlocks("b", "a", "c", "a", "b", "b"))
+	if err == nil {
+		t.Fatalf("New() error = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "a, b") {
+		t.Errorf("New() error = %v, want the duplicate keys listed", err)
+	}
+}
+
+func TestMerkleTree_IndexOfKey_notKeyed(t *testing.T) {
+	blocks := append(dataBlocks(3), keyedBlocks("only")...)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if idx, err := m.IndexOfKey("only"); err != nil || idx != 3 {
+		t.Errorf("IndexOfKey() = %d, %v, want 3", idx, err)
+	}
+	m, err = New(nil, dataBlocks(3))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := m.IndexOfKey(""); err == nil {
+		t.Errorf("IndexOfKey() error = nil, want error")
+	}
+}
@@ -0,0 +1,130 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkNoGoroutineLeak fails the test if the number of goroutines does not return to the number before,
+// retrying for a while to give the exiting goroutines time to finish.
+func checkNoGoroutineLeak(t *testing.T, before int) {
+	t.Helper()
+	var after int
+	for retry := 0; retry < 100; retry++ {
+		if after = runtime.NumGoroutine(); after <= before {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	buf := make([]byte, 1<<16)
+	t.Errorf("goroutine leak: %d goroutines before, %d after\n%s", before, after, buf[:runtime.Stack(buf, true)])
+}
+
+func TestNew_noGoroutineLeak(t *testing.T) {
+	// The hash errors from the existing test tables, failing on the leaves,
+	// and failing on the internal nodes only, after the leaves are generated.
+	hashFuncs := map[string]TypeHashFunc{
+		"ok": nil,
+		"leaf_hash_func_error": func([]byte) ([]byte, error) {
+			return nil, fmt.Errorf("hash func error")
+		},
+		"node_hash_func_error": func(data []byte) ([]byte, error) {
+			if len(data) == 2*defaultHashLen {
+				return nil, fmt.Errorf("hash func error")
+			}
+			return defaultHashFuncParallel(data)
+		},
+	}
+	blocks := dataBlocks(1001)
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		for _, parallel := range []bool{false, true} {
+			for name, hashFunc := range hashFuncs {
+				config := &Config{HashFunc: hashFunc, Mode: mode, RunInParallel: parallel, NumRoutines: 4}
+				wantErr := hashFunc != nil
+				t.Run(fmt.Sprintf("mode_%d_parallel_%v_%s", mode, parallel, name), func(t *testing.T) {
+					before := runtime.NumGoroutine()
+					if _, err := New(config, blocks); (err != nil) != wantErr {
+						t.Fatalf("New() error = %v, wantErr %v", err, wantErr)
+					}
+					checkNoGoroutineLeak(t, before)
+				})
+			}
+		}
+	}
+}
+
+func TestBuildAsync_noGoroutineLeak(t *testing.T) {
+	blocks := dataBlocks(10000)
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		for _, parallel := range []bool{false, true} {
+			t.Run(fmt.Sprintf("mode_%d_parallel_%v", mode, parallel), func(t *testing.T) {
+				before := runtime.NumGoroutine()
+				config := &Config{HashFunc: slowHashFunc, Mode: mode, RunInParallel: parallel, NumRoutines: 4}
+				f := BuildAsync(context.Background(), config, blocks)
+				time.Sleep(5 * time.Millisecond)
+				f.Cancel()
+				if _, err := f.Result(); err == nil {
+					t.Fatalf("Result() error = nil, want context canceled")
+				}
+				checkNoGoroutineLeak(t, before)
+			})
+		}
+	}
+}
+
+func TestNew_firstWorkerErrorCancelsOthers(t *testing.T) {
+	const numBlocks, numRoutines = 4096, 4
+	blocks := dataBlocks(numBlocks)
+	errHash := errors.New("hash func error")
+	// Fail on a leaf, and on an internal node after the leaves are generated.
+	for _, k := range []int64{100, numBlocks + 100} {
+		for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+			t.Run(fmt.Sprintf("mode_%d_call_%d", mode, k), func(t *testing.T) {
+				var calls atomic.Int64
+				hashFunc := func(data []byte) ([]byte, error) {
+					if calls.Add(1) == k {
+						return nil, errHash
+					}
+					return defaultHashFuncParallel(data)
+				}
+				config := &Config{HashFunc: hashFunc, Mode: mode, RunInParallel: true, NumRoutines: numRoutines}
+				before := runtime.NumGoroutine()
+				if _, err := New(config, blocks); !errors.Is(err, errHash) {
+					t.Fatalf("New() error = %v, want %v", err, errHash)
+				}
+				// Each of the other workers finishes at most its current item after the error.
+				if got := calls.Load(); got > k+numRoutines {
+					t.Errorf("hash func called %d times, want at most %d", got, k+numRoutines)
+				}
+				checkNoGoroutineLeak(t, before)
+			})
+		}
+	}
+}
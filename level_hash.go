@@ -0,0 +1,54 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "errors"
+
+// levelHash returns the hash function of the nodes at the level, level 0 being the leaves:
+// the hash function returned by LevelHashFunc for the level if set and not nil, and HashFunc otherwise.
+func (c *Config) levelHash(level int) TypeHashFunc {
+	if c.LevelHashFunc != nil {
+		if hashFunc := c.LevelHashFunc(level); hashFunc != nil {
+			return hashFunc
+		}
+	}
+	return c.HashFunc
+}
+
+// checkLevelHashFunc checks that LevelHashFunc, if set, is compatible with the other fields of the configuration.
+func checkLevelHashFunc(c *Config) error {
+	if c.LevelHashFunc == nil {
+		return nil
+	}
+	switch {
+	case c.PaddingStrategy == PaddingPromote:
+		return errors.New("LevelHashFunc is not supported with PaddingPromote, as the levels of the siblings are unknown")
+	case c.PairHashFunc != nil:
+		return errors.New("LevelHashFunc is not supported with PairHashFunc")
+	case len(c.AdditionalHashFuncs) > 0:
+		return errors.New("AdditionalHashFuncs is not supported with LevelHashFunc")
+	case c.Hasher != nil:
+		return errors.New("LevelHashFunc is not supported with Hasher, which hashes the leaves")
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// fastNodeHashFunc stands in for a faster hash function of the internal nodes, of the size of DefaultHashFunc.
+func fastNodeHashFunc(data []byte) ([]byte, error) {
+	digest := sha512.Sum512_256(data)
+	return digest[:], nil
+}
+
+// twoLevelHashFunc hashes the leaves with the hash function of the configuration and the internal nodes
+// with fastNodeHashFunc.
+func twoLevelHashFunc(level int) TypeHashFunc {
+	if level == 0 {
+		return nil
+	}
+	return fastNodeHashFunc
+}
+
+func TestNew_levelHashFunc(t *testing.T) {
+	configs := []Config{
+		{},
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, NoDuplicates: true},
+		{PaddingStrategy: PaddingIndexed, BindLeafIndex: true},
+		{RunInParallel: true, NumRoutines: 4, SortSiblingPairs: true},
+	}
+	for _, numBlocks := range []int{2, 5, 16, 100} {
+		blocks := dataBlocks(numBlocks)
+		for i := range configs {
+			name := fmt.Sprintf("%d blocks/config %d", numBlocks, i)
+			single := configs[i]
+			singleTree, err := New(&single, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			fast := configs[i]
+			fast.HashFunc = fastNodeHashFunc
+			fastTree, err := New(&fast, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			config := configs[i]
+			config.LevelHashFunc = twoLevelHashFunc
+			m, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() with LevelHashFunc error = %v", name, err)
+			}
+			if bytes.Equal(m.Root, singleTree.Root) || bytes.Equal(m.Root, fastTree.Root) {
+				t.Errorf("%s: root with LevelHashFunc equals the root with a single hash function", name)
+			}
+			for idx, block := range blocks {
+				proof, err := m.ProofOf(idx)
+				if err != nil {
+					t.Fatalf("%s: ProofOf(%d) error = %v", name, idx, err)
+				}
+				for _, p := range []*Proof{proof, proof.Reverse()} {
+					if ok, err := Verify(block, p, m.Root, &config); err != nil || !ok {
+						t.Errorf("%s: Verify(%d) = %v, %v, want true", name, idx, ok, err)
+					}
+				}
+				single := configs[i]
+				if ok, _ := Verify(block, proof, m.Root, &single); ok {
+					t.Errorf("%s: Verify(%d) without LevelHashFunc = true, want false", name, idx)
+				}
+			}
+		}
+	}
+}
+
+func TestLevelHashFunc_consistency(t *testing.T) {
+	blocks := dataBlocks(11)
+	// Each level has its own hash function, so that a level computed with another function changes the root.
+	config := &Config{LevelHashFunc: func(level int) TypeHashFunc {
+		return func(data []byte) ([]byte, error) {
+			digest := sha256.Sum256(append([]byte{byte(level)}, data...))
+			return digest[:], nil
+		}
+	}}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	root, err := ComputeRoot(config, sliceIterator(blocks))
+	if err != nil || !bytes.Equal(root, m.Root) {
+		t.Errorf("ComputeRoot() = %x, %v, want %x", root, err, m.Root)
+	}
+	sub, err := New(config, blocks[3:9])
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if root, err = SubRoot(blocks, 3, 9, config); err != nil || !bytes.Equal(root, sub.Root) {
+		t.Errorf("SubRoot() = %x, %v, want %x", root, err, sub.Root)
+	}
+	pair, err := New(config, blocks[:2])
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if root, err = NodeHash(config, pair.Leaves[0], pair.Leaves[1]); err != nil || !bytes.Equal(root, pair.Root) {
+		t.Errorf("NodeHash() = %x, %v, want %x", root, err, pair.Root)
+	}
+	if _, err = NodeHashAtLevel(config, 0, pair.Leaves[0], pair.Leaves[1]); err == nil {
+		t.Error("NodeHashAtLevel() at level 0: error = nil")
+	}
+	tree, err := New(&Config{Mode: ModeTreeBuild, LevelHashFunc: config.LevelHashFunc}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	updated := append([]DataBlock(nil), blocks...)
+	updated[6] = &mock.DataBlock{Data: []byte("updated")}
+	if err = tree.UpdateLeaf(6, updated[6]); err != nil {
+		t.Fatalf("UpdateLeaf() error = %v", err)
+	}
+	want, err := New(config, updated)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(tree.Root, want.Root) {
+		t.Errorf("root after UpdateLeaf() = %x, want %x", tree.Root, want.Root)
+	}
+}
+
+func TestNew_levelHashFuncErrors(t *testing.T) {
+	blocks := dataBlocks(5)
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{"padding_promote", &Config{PaddingStrategy: PaddingPromote}},
+		{"pair_hash_func", &Config{PairHashFunc: ReversedPairHashFunc(nil)}},
+		{"additional_hash_funcs", &Config{AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}}},
+		{"hasher", &Config{Hasher: sha256.New}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LevelHashFunc = twoLevelHashFunc
+			if _, err := New(tt.config, blocks); err == nil {
+				t.Error("New() error = nil")
+			}
+			if _, err := NewStreamBuilder(tt.config); err == nil {
+				t.Error("NewStreamBuilder() error = nil")
+			}
+		})
+	}
+}
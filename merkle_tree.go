@@ -24,10 +24,16 @@ package merkletree
 
 import (
 	"bytes"
-	"crypto/rand"
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"hash"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/txaty/gool"
 )
@@ -43,7 +49,33 @@ const (
 	defaultHashLen = 32
 )
 
-var wp *gool.Pool[argType, error]
+const (
+	// PaddingDuplicate pads the odd levels with a duplicate of their last node,
+	// or with the node derived from it by NoDuplicatesFiller if NoDuplicates is true.
+	PaddingDuplicate TypePaddingStrategy = iota
+	// PaddingPromote promotes the last node of the odd levels to the next level unchanged, without padding,
+	// giving the tree shape of RFC 6962 (without its domain separation prefixes).
+	PaddingPromote
+	// PaddingIndexed pads the leaf level to a power of two with distinct leaves, the leaf at index i being
+	// HashFunc(i) of the big-endian uint64 index, e.g. for fixed-size vector commitments, so that the padded
+	// positions are individually provable, see PaddingLeaf and VerifyPadding. No level above is odd.
+	PaddingIndexed
+)
+
+// ErrInconsistentHashSize is returned when the hash function returns outputs of different sizes or an empty output,
+// which makes the concatenation of the sibling nodes ambiguous, or when verifying a proof whose siblings
+// differ in size from the hash outputs.
+var ErrInconsistentHashSize = errors.New("inconsistent hash output size")
+
+// AutoParallelMinBlocks is the number of data blocks from which the tree building runs in parallel
+// if AutoParallel is true.
+const AutoParallelMinBlocks = 10000
+
+// errWorkersAborted is returned by the workers aborted by the failure of another worker.
+var errWorkersAborted = errors.New("aborted by the failure of another worker")
+
+// ErrUnexpectedLeafSize is returned when a data block does not serialize to ExpectLeafSize bytes.
+var ErrUnexpectedLeafSize = errors.New("unexpected serialized data block size")
 
 // argType is used as the arguments for the handler functions when performing parallel computations.
 // All the handler functions use this universal argument struct to eliminate interface conversion overhead.
@@ -54,6 +86,8 @@ type argType struct {
 	byteField1     [][]byte
 	byteField2     [][]byte
 	dataBlockField []DataBlock
+	intSliceField  []int
+	batcher        *pairBatcher
 	intField1      int
 	intField2      int
 	intField3      int
@@ -65,11 +99,50 @@ type argType struct {
 // TypeConfigMode is the type in the Merkle Tree configuration indicating what operations are performed.
 type TypeConfigMode int
 
+// String returns the name of the configuration mode.
+func (t TypeConfigMode) String() string {
+	switch t {
+	case ModeProofGen:
+		return "ModeProofGen"
+	case ModeTreeBuild:
+		return "ModeTreeBuild"
+	case ModeProofGenAndTreeBuild:
+		return "ModeProofGenAndTreeBuild"
+	default:
+		return fmt.Sprintf("TypeConfigMode(%d)", int(t))
+	}
+}
+
+// TypePaddingStrategy is the type in the Merkle Tree configuration indicating how the odd levels are handled.
+type TypePaddingStrategy int
+
+// String returns the name of the padding strategy.
+func (t TypePaddingStrategy) String() string {
+	switch t {
+	case PaddingDuplicate:
+		return "PaddingDuplicate"
+	case PaddingPromote:
+		return "PaddingPromote"
+	case PaddingIndexed:
+		return "PaddingIndexed"
+	default:
+		return fmt.Sprintf("TypePaddingStrategy(%d)", int(t))
+	}
+}
+
 // DataBlock is the interface of input data blocks to generate the Merkle Tree.
 type DataBlock interface {
 	Serialize() ([]byte, error)
 }
 
+// byteBlock is a data block of raw bytes, e.g. a Merkle root used as a data block of another tree.
+type byteBlock []byte
+
+// Serialize returns the raw bytes.
+func (b byteBlock) Serialize() ([]byte, error) {
+	return b, nil
+}
+
 // TypeHashFunc is the signature of the hash functions used for Merkle Tree generation.
 type TypeHashFunc func([]byte) ([]byte, error)
 
@@ -82,22 +155,151 @@ type Config struct {
 	concatFunc func([]byte, []byte) []byte
 	// Customizable hash function used for tree generation.
 	HashFunc TypeHashFunc
+	// HashName is the name HashFunc is registered under by RegisterHashFuncName, recorded by MarshalJSON
+	// and MarshalStructure to restore the hash function. It is HashSHA256 by default with the default hash function.
+	HashName string
+	// HashID is the id HashFunc is registered under by RegisterHashFunc, to be set as the HashID of the proofs
+	// verified by VerifyAuto. It is HashIDSHA256 by default with the default hash function.
+	HashID byte
 	// Number of goroutines run in parallel.
 	// If RunInParallel is true and NumRoutine is set to 0, use number of CPU as the number of goroutines.
+	// The number of goroutines is capped to GOMAXPROCS, as more goroutines only add scheduling overhead.
 	NumRoutines int
 	// Mode of the Merkle Tree generation.
 	Mode TypeConfigMode
 	// If RunInParallel is true, the generation runs in parallel, otherwise runs without parallelization.
 	// This increase the performance for the calculation of large number of data blocks, e.g. over 10,000 blocks.
 	RunInParallel bool
-	// If true, generate a dummy node with random hash value.
+	// If true, RunInParallel of the tree is set by New for each build from the number of data blocks: to true
+	// if there are at least AutoParallelMinBlocks data blocks, as the parallelization only pays off for large inputs,
+	// and to false otherwise, leaving the configuration of the caller unchanged. It is not applied by NewFromReader,
+	// as the number of data blocks is not known in advance.
+	AutoParallel bool
+	// If true, the odd levels are padded with a node derived from their last node by NoDuplicatesFiller,
+	// so that no node is duplicated while the root stays deterministic.
 	// Otherwise, then the odd node situation is handled by duplicating the previous node.
 	NoDuplicates bool
+	// PaddingStrategy is how the odd levels are handled, PaddingDuplicate by default.
+	// With PaddingPromote, the proofs of the leaves under promoted nodes have fewer siblings than Depth,
+	// and the bits of their paths are indexed by sibling instead of by level, so they cannot be derived
+	// from the leaf index, e.g. by MergeAdjacentProofs and CompactEncode. NoDuplicates is not supported.
+	// With PaddingIndexed, the padding leaves follow the leaves of the data blocks in the tree, but not in Leaves,
+	// Proofs and NumLeaves: their proofs are returned by ProofOf, and their number by PaddedLeafCount.
+	PaddingStrategy TypePaddingStrategy
 	// SortSiblingPairs is the parameter for OpenZeppelin compatibility.
 	// If set to `true`, the hashing sibling pairs are sorted.
 	SortSiblingPairs bool
 	// If true, the leaf nodes are NOT hashed before being added to the Merkle Tree.
 	DisableLeafHashing bool
+	// If true, each leaf commits to its position: the leaf is computed from the big-endian uint64 leaf index
+	// followed by the serialized data block, i.e. Hash(index || data), preventing the reordering of the leaves.
+	BindLeafIndex bool
+	// Hasher is the constructor of the streaming hash used for leaf hashing, e.g. sha256.New.
+	// If set, the leaves are hashed by writing the serialized data blocks to a new hash.Hash,
+	// and HashFunc, if not set, is derived from it. Otherwise, the leaves are hashed with HashFunc.
+	Hasher func() hash.Hash
+	// ChunkSize is the number of bytes written to the Hasher at a time when hashing a leaf,
+	// e.g. to hand the data to a hardware hash accelerator in fixed chunks.
+	// If ChunkSize is 0, the data is written at once. It does not change the hash result.
+	ChunkSize int
+	// If true, each proof carries the fingerprint of the configuration fields relevant to verification,
+	// and Verify returns ErrConfigMismatch if the fingerprint does not match the configuration of the verifier.
+	EmbedFingerprint bool
+	// AdditionalHashFuncs are hash functions the tree is also computed with, e.g. to publish the roots under
+	// both the old and the new hash function during a migration. Each data block is serialized once and
+	// its bytes are hashed by HashFunc and by every additional hash function. The additional roots are returned
+	// by AdditionalRoots, while the proofs and the tree structure are only generated for HashFunc.
+	// Each additional hash function adds the cost of hashing the leaves and the internal nodes once more,
+	// and the additional leaves are kept in memory until the additional roots are computed.
+	AdditionalHashFuncs []TypeHashFunc
+	// ExpectLeafSize is the size in bytes every data block must serialize to, e.g. for fixed-width records,
+	// so that schema violations and corrupted data are caught before building a misleading root.
+	// If a data block serializes to a different size, the tree building returns ErrUnexpectedLeafSize
+	// with the leaf index. If ExpectLeafSize is 0, the data blocks may have any size.
+	ExpectLeafSize int
+	// OnNode is called with every internal node computed during the tree building process, including the root
+	// at level Depth, e.g. to persist the tree incrementally to an external store. Level 0 is the leaf level.
+	// The padding nodes appended to odd levels are not computed, so they are not reported,
+	// and with PaddingPromote, the promoted nodes are reported at every level they are promoted to.
+	// The calls are never concurrent. The levels are reported in ascending order and the root last,
+	// and within a level, the nodes are reported in ascending index order, unless RunInParallel is true.
+	// The hash must not be modified.
+	OnNode func(level, index int, hash []byte)
+	// RootBytes is the length in bytes the Merkle root is truncated to, e.g. to publish a 20-byte root,
+	// and Verify only compares the first RootBytes bytes of the computed root with the root.
+	// A truncated root reduces the security accordingly: the collision resistance of a root of n bytes
+	// is at most 4n bits, and its second preimage resistance at most 8n bits.
+	// If RootBytes is 0 or not smaller than the hash size, the root is not truncated.
+	RootBytes int
+	// LeafTransform is applied to every serialized data block before the leaf is computed, e.g. to prepend
+	// a domain separation tag or to hash a key-value pair in several steps, and Verify applies the same transform.
+	// The transformed bytes are bound to the leaf index, if BindLeafIndex is true, and hashed, or used as the leaf
+	// if DisableLeafHashing is true. ExpectLeafSize applies to the serialized data block before the transform.
+	// The transform must be concurrent safe if RunInParallel is true, and must not modify or retain its input.
+	LeafTransform func(data []byte) ([]byte, error)
+	// PairHashFunc, if set, computes the parent node of each sibling pair instead of HashFunc of their concatenation,
+	// e.g. ReversedPairHashFunc or SeparatorPairHashFunc, in the tree building, the proof generation and Verify,
+	// while the leaves are still computed with HashFunc. Its outputs must all be of the same size.
+	// The pair is sorted first if SortSiblingPairs is true. AdditionalHashFuncs is not supported.
+	// It must be concurrent safe if RunInParallel is true, and must not modify or retain its input.
+	PairHashFunc TypePairHashFunc
+	// LevelHashFunc, if set, returns the hash function of the nodes at each level, level 0 being the leaves,
+	// e.g. a strong hash function for the leaves and a faster one for the internal nodes.
+	// The node at level l > 0 is the hash of the concatenation of its children at level l-1, as with HashFunc,
+	// which is used for the levels LevelHashFunc returns nil for, and for the padding nodes of NoDuplicates.
+	// Verify computes the node of the sibling i of a proof at level i+1, from the leaf up.
+	// The hash functions must output hashes of the same size. PaddingPromote, PairHashFunc,
+	// AdditionalHashFuncs and Hasher are not supported. The hash functions must be concurrent safe
+	// if RunInParallel is true.
+	LevelHashFunc func(level int) TypeHashFunc
+	// If true, the proofs are also stored in the root-to-leaf orientation, as converted by Reverse,
+	// in ReversedProofs, e.g. for consumers expecting the siblings from the root down to the leaf.
+	// It is ignored in ModeTreeBuild, as no proof is generated.
+	StoreReversedProofs bool
+	// OnDuplicateLeaf is how the duplicate leaves, e.g. of identical data blocks, are handled when the tree is built,
+	// DuplicateLeafAllow by default. With DuplicateLeafError, the tree building fails with ErrDuplicateLeaf
+	// at the first duplicate. With DuplicateLeafCollapse, the duplicates of a leaf after its first occurrence
+	// are removed, e.g. for set commitments, and LeafIndexes maps the data blocks to their leaves.
+	// With BindLeafIndex, identical data blocks have different leaves, so they are not duplicates.
+	// The updates of UpdateBatch are not checked.
+	OnDuplicateLeaf TypeDuplicateLeaf
+	// OnAnomaly is called when the computation of a leaf is anomalous, e.g. to find the pathological data blocks
+	// stalling the workers: when a Serialize call or the hashing of a leaf exceeds AnomalyDuration,
+	// or when a data block serializes to more than AnomalyLeafSize bytes. A threshold of 0 disables its check,
+	// and the calls are only timed if OnAnomaly is set and AnomalyDuration is positive.
+	// The internal nodes and the verification are not observed. The calls are never concurrent.
+	OnAnomaly func(anomaly Anomaly)
+	// AnomalyDuration is the duration threshold of the Serialize calls and of the leaf hashing for OnAnomaly.
+	AnomalyDuration time.Duration
+	// AnomalyLeafSize is the size threshold in bytes of the serialized data blocks for OnAnomaly.
+	AnomalyLeafSize int
+	// If true, every hash function, i.e. HashFunc and the additional hash functions, is called with a fresh copy
+	// of its input, e.g. to guard against hash functions modifying or retaining their input: a serialized
+	// data block is hashed once by each hash function, and with Verify, it is hashed from the bytes
+	// returned by Serialize, which may be owned by the data block. It costs one copy for each hash.
+	ParanoidHashInput bool
+	// MaxProofDepth is the maximum number of siblings of the proofs accepted by the verification,
+	// DefaultMaxProofDepth if 0, so that the oversized proofs of untrusted peers are rejected
+	// with ErrProofTooDeep before any hashing. See VerifyWithLimit.
+	MaxProofDepth int
+	// ProofIndices are the indexes of the leaves whose proofs are generated, if not nil, e.g. to save the memory
+	// of the proofs of the other leaves. The tree is computed as usual, but the other Proofs are nil, and in
+	// ModeProofGen, where there are no tree nodes to generate them from, ProofOf returns an error for them.
+	// It is ignored in ModeTreeBuild.
+	ProofIndices []int
+	// ParallelBatchSize is the number of sibling pairs of a level a worker hashes for each task it grabs
+	// with RunInParallel, trading the scheduling overhead of small tasks against the load balance of the workers.
+	// If 0, the pairs of each level are divided evenly among the workers, one task each.
+	ParallelBatchSize int
+	// If true, the proofs of ModeProofGen reference the nodes computed by the build as their siblings, so that
+	// the siblings shared by several proofs, e.g. the upper-level nodes, are stored once, instead of being copied
+	// into a buffer owned by each proof. The siblings and the fingerprint are then aliased across the proofs,
+	// so they must not be modified. In ModeProofGenAndTreeBuild, the siblings are always shared with the tree nodes.
+	ShareSiblings bool
+	// If true, the tree building returns ErrConfigConflict, naming both fields, when a field is ignored
+	// or contradicted by another field, e.g. NumRoutines without RunInParallel, or ProofIndices in ModeTreeBuild,
+	// instead of silently ignoring it.
+	Strict bool
 }
 
 // MerkleTree implements the Merkle Tree structure.
@@ -105,86 +307,252 @@ type MerkleTree struct {
 	*Config
 	// leafMap is the map of the leaf hash to the index in the Tree slice.
 	// It is only available when config mode is ModeTreeBuild or ModeProofGenAndTreeBuild.
-	leafMap sync.Map
+	// If leafMapShared is true, the map is shared with a snapshot, and copied before it is modified.
+	leafMap       *sync.Map
+	leafMapShared bool
 	// nodes contains Merkle Tree's tree structure.
 	// It is only available when config mode is ModeTreeBuild or ModeProofGenAndTreeBuild.
-	nodes [][][]byte
+	// If the tree is pruned, only the leaves and the nodes at keptLevel are retained, see Prune.
+	nodes     [][][]byte
+	pruned    bool
+	keptLevel int
+	// keyMap is the map of the data block key to the leaf index.
+	// It is only available when the data blocks implement KeyedDataBlock.
+	keyMap map[string]int
 	// Root is the Merkle root hash.
 	Root []byte
 	// Leaves are Merkle Tree leaves, i.e. the hashes of the data blocks for tree generation.
 	Leaves [][]byte
 	// Proofs are proofs to the data blocks generated during the tree building process.
+	// Proofs[i] is the proof of Leaves[i], i.e. of the data block at index i of the input, in every mode
+	// and parallelization setting, unless duplicate leaves are collapsed, see LeafIndexes.
+	// Proofs is nil in ModeTreeBuild, as no proof is generated, see ProofOf.
+	// If ProofIndices is set, only the proofs of the selected leaves are not nil.
 	Proofs []*Proof
+	// LeafIndexes maps the index of each data block of the input to its leaf index
+	// if duplicate leaves are collapsed by DuplicateLeafCollapse, and is nil otherwise. See LeafIndexOf.
+	LeafIndexes []int
+	// ReversedProofs are the proofs in the root-to-leaf orientation, stored if StoreReversedProofs is true.
+	ReversedProofs []*Proof
 	// Depth is the Merkle Tree depth.
 	Depth uint32
 	// NumLeaves is the number of tree leaves, it is fixed when the tree is built.
+	// It excludes the padding leaf appended to an odd leaf level, which is not a leaf of its own:
+	// no proof or lookup refers to its index. It also excludes the padding leaves of PaddingIndexed.
+	// See PaddedLeafCount.
 	NumLeaves int
+	// wp is the worker pool for parallel computations, only available during the tree building process.
+	wp *gool.Pool[argType, error]
+	// ctx is the context of the tree building process, and done is its done channel.
+	// They are checked between units of work so that the cancellation stops the workers promptly.
+	ctx  context.Context
+	done <-chan struct{}
+	// hashSize is the size of the hash outputs, detected and checked during the tree building process.
+	hashSize atomic.Int64
+	// fingerprint is the configuration fingerprint embedded in the proofs if EmbedFingerprint is true.
+	fingerprint []byte
+	// numWorkers is the number of workers of the parallel computations,
+	// NumRoutines clamped to GOMAXPROCS.
+	numWorkers int
+	// proofBufs are the buffers the siblings of the proofs are copied into in ModeProofGen, one for each proof,
+	// and proofSiblingSize is the size of the siblings in the buffers.
+	proofBufs        [][]byte
+	proofSiblingSize int
+	// additionalLeaves are the leaves under the additional hash functions, indexed by leaf and then by hash function,
+	// only available during the tree building process.
+	additionalLeaves [][][]byte
+	// additionalRoots are the roots under the additional hash functions.
+	additionalRoots [][]byte
+	// onNodeMu serializes the calls to OnNode of the parallel computations.
+	onNodeMu sync.Mutex
+	// onAnomalyMu serializes the calls to OnAnomaly of the parallel computations.
+	onAnomalyMu sync.Mutex
+	// aborted is closed when a worker of the parallel computations fails, and abortErr is the first error,
+	// so that the other workers stop at their next cancellation check. abortMu guards abortErr.
+	aborted  chan struct{}
+	abortErr error
+	abortMu  sync.Mutex
+	// numPaddingLeaves is the number of padding leaves of PaddingIndexed, and paddingProofs are their proofs
+	// generated in ModeProofGen, as the tree has no nodes to generate them from.
+	numPaddingLeaves int
+	paddingProofs    []*Proof
+	// proofsByLeafHash caches the proofs keyed by hex leaf hash, built by ProofsByLeafHash
+	// and reset when the leaves change. proofsByLeafHashMu guards it.
+	proofsByLeafHash   map[string]*Proof
+	proofsByLeafHashMu sync.Mutex
 }
 
 // Proof implements the Merkle Tree proof.
 type Proof struct {
 	Siblings [][]byte // sibling nodes to the Merkle Tree path of the data block.
 	Path     uint32   // path variable indicating whether the neighbor is on the left or right.
+	Index    int      // index of the leaf of the data block.
+	// Fingerprint of the configuration of the Merkle Tree, set if EmbedFingerprint is true in the configuration.
+	Fingerprint []byte
+	// HashID identifies the hash function of the Merkle Tree, as registered by RegisterHashFunc, e.g. HashID
+	// of the configuration, so that VerifyAuto selects the hash function of the proof.
+	// It is 0 if not set, and carried by the binary encoding.
+	HashID byte
+	// RootToLeaf reports whether the siblings, and the bits of the path, are ordered from the root down to the leaf,
+	// as converted by Reverse. Otherwise, they are ordered from the leaf up to the root.
+	RootToLeaf bool
+}
+
+// Equal reports whether the proof and the other proof have identical siblings, paths, leaf indexes, fingerprints,
+// hash ids and orientations.
+func (p *Proof) Equal(other *Proof) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	if p.Path != other.Path || p.Index != other.Index || len(p.Siblings) != len(other.Siblings) ||
+		!bytes.Equal(p.Fingerprint, other.Fingerprint) || p.HashID != other.HashID ||
+		p.RootToLeaf != other.RootToLeaf {
+		return false
+	}
+	for i := range p.Siblings {
+		if !bytes.Equal(p.Siblings[i], other.Siblings[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 // New generates a new Merkle Tree with specified configuration.
+// It hashes the leaves as HashLeaves does and builds the tree from them as NewFromLeafHashes does,
+// both steps sharing the same code.
+// The data blocks are released once their leaves are hashed: the tree references neither the slice
+// nor the data blocks, so they can be collected during the tree building if the caller drops them,
+// unless DisableLeafHashing makes the leaves the serialized data blocks, which may share their memory.
 func New(config *Config, blocks []DataBlock) (m *MerkleTree, err error) {
+	return newWithContext(context.Background(), config, blocks, 0)
+}
+
+// newWithContext generates a new Merkle Tree with specified configuration,
+// stopping the generation with the context error when the context is canceled.
+// If numWorkers is positive, the parallel computations use exactly numWorkers workers,
+// otherwise the number of workers is derived from NumRoutines.
+func newWithContext(ctx context.Context, config *Config, blocks []DataBlock, numWorkers int) (*MerkleTree, error) {
 	if len(blocks) <= 1 {
 		return nil, errors.New("the number of data blocks must be greater than 1")
 	}
-	if config == nil {
-		config = new(Config)
+	keyMap, err := buildKeyMap(blocks)
+	if err != nil {
+		return nil, err
 	}
-	m = &MerkleTree{Config: config, NumLeaves: len(blocks), Depth: calTreeDepth(len(blocks))}
-	// Hash function initialization.
-	if m.HashFunc == nil {
-		if m.RunInParallel {
-			m.HashFunc = defaultHashFuncParallel // Parallelized hash function must be concurrent safe.
-		} else {
-			m.HashFunc = defaultHashFunc
+	return build(ctx, config, len(blocks), numWorkers, func(m *MerkleTree) ([][]byte, error) {
+		m.NumLeaves, m.keyMap = len(blocks), keyMap
+		leaves, err := m.generateLeaves(blocks)
+		// The closure outlives the leaf hashing, so it drops the data blocks for them to be collected.
+		blocks = nil
+		return leaves, err
+	})
+}
+
+// build generates a new Merkle Tree with specified configuration from the leaves returned by genLeaves,
+// which is called with the configuration initialized and the worker pool started.
+// The tree has its own copy of the configuration, so the defaults and RunInParallel decided by AutoParallel
+// are not set on the configuration of the caller. AutoParallel applies if numBlocks, the number of data blocks,
+// is known before the leaves are generated, i.e. positive.
+func build(ctx context.Context, config *Config, numBlocks, numWorkers int,
+	genLeaves func(m *MerkleTree) ([][]byte, error)) (m *MerkleTree, err error) {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if c.AutoParallel && numBlocks > 0 {
+		c.RunInParallel = numBlocks >= AutoParallelMinBlocks
+	}
+	config = &c
+	if config.Strict {
+		if err = checkStrict(config); err != nil {
+			return nil, err
 		}
 	}
-	// Hash concatenation function initialization.
-	if m.concatFunc == nil {
-		if m.SortSiblingPairs {
-			m.concatFunc = concatSortHash
-		} else {
-			m.concatFunc = concatHash
+	m = &MerkleTree{Config: config, numWorkers: numWorkers}
+	m.ctx, m.done = ctx, ctx.Done()
+	defer func(m *MerkleTree) {
+		// The context is only used during the tree building process.
+		m.ctx, m.done = nil, nil
+	}(m)
+	m.initConfig()
+	if err = m.checkPaddingStrategy(); err != nil {
+		return nil, err
+	}
+	if m.RootBytes < 0 {
+		return nil, fmt.Errorf("invalid root length %d", m.RootBytes)
+	}
+	if m.PairHashFunc != nil && len(m.AdditionalHashFuncs) > 0 {
+		return nil, errors.New("AdditionalHashFuncs is not supported with PairHashFunc")
+	}
+	if err = checkLevelHashFunc(m.Config); err != nil {
+		return nil, err
+	}
+	if m.ParallelBatchSize < 0 {
+		return nil, fmt.Errorf("invalid parallel batch size %d", m.ParallelBatchSize)
+	}
+	if err = checkDuplicateLeaf(m.Config); err != nil {
+		return nil, err
+	}
+	if m.StoreReversedProofs {
+		defer func() {
+			if err == nil && m.Proofs != nil {
+				m.ReversedProofs = reverseProofs(m.Proofs)
+			}
+		}()
+	}
+	if m.EmbedFingerprint {
+		if m.fingerprint, err = configFingerprint(m.Config); err != nil {
+			return nil, err
 		}
 	}
-	// Configuration for parallelization.
 	if m.RunInParallel {
-		// If NumRoutines is not set or invalid, set it to the number of CPU.
-		if m.NumRoutines <= 0 {
-			m.NumRoutines = runtime.NumCPU()
-		}
-		// Generic wait group initialization (for parallelized computation) and leaf generation.
-		// Task channel capacity is passed as 0, so use the default value: 2 * numWorkers.
-		wp = gool.NewPool[argType, error](m.NumRoutines, 0)
-		defer wp.Close()
-		if m.Leaves, err = m.leafGenParallel(blocks); err != nil {
+		m.startWorkerPool()
+		defer m.stopWorkerPool()
+	}
+	if m.Leaves, err = genLeaves(m); err != nil {
+		return nil, err
+	}
+	if m.Leaves, m.LeafIndexes, err = m.handleDuplicateLeaves(m.Leaves); err != nil {
+		return nil, err
+	}
+	if len(m.Leaves) <= 1 {
+		return nil, errors.New("the number of data blocks must be greater than 1")
+	}
+	if m.PaddingStrategy == PaddingIndexed {
+		if m.Leaves, err = m.padIndexed(m.Leaves); err != nil {
 			return nil, err
 		}
-	} else {
-		if m.Leaves, err = m.leafGen(blocks); err != nil {
+		// The tree is built with the padding leaves counted in NumLeaves, and they are split off at the end.
+		defer func() {
+			if err == nil {
+				m.splitIndexedPadding()
+			}
+		}()
+	}
+	m.NumLeaves, m.Depth = len(m.Leaves), calTreeDepth(len(m.Leaves))
+	if err = m.checkProofIndices(); err != nil {
+		return nil, err
+	}
+	if len(m.AdditionalHashFuncs) > 0 {
+		if err = m.additionalRootsGen(); err != nil {
 			return nil, err
 		}
 	}
 
 	// Mode defined actions.
 	// If the configuration mode is not set, then set it to ModeProofGen by default.
-	if m.Mode == 0 {
-		m.Mode = ModeProofGen
+	if m.Config.Mode == 0 {
+		m.Config.Mode = ModeProofGen
 	}
-	if m.Mode == ModeProofGen {
+	if m.Config.Mode == ModeProofGen {
 		err = m.proofGen()
 		return
 	}
-	if m.Mode == ModeTreeBuild {
+	if m.Config.Mode == ModeTreeBuild {
 		err = m.treeBuild()
 		return
 	}
-	if m.Mode == ModeProofGenAndTreeBuild {
+	if m.Config.Mode == ModeProofGenAndTreeBuild {
 		if err = m.treeBuild(); err != nil {
 			return
 		}
@@ -204,15 +572,155 @@ func New(config *Config, blocks []DataBlock) (m *MerkleTree, err error) {
 	return nil, errors.New("invalid configuration mode")
 }
 
+// HashLeaves computes the leaves of the data blocks in input order, without building the tree.
+// It runs the leaf generation phase of New, with the same configuration handling, parallelization and errors,
+// so the leaves are identical to the Leaves of a tree built by New with the same configuration.
+// The configuration is not modified.
+func HashLeaves(config *Config, blocks []DataBlock) ([][]byte, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("the number of data blocks must be greater than 0")
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	m := &MerkleTree{Config: &c, NumLeaves: len(blocks)}
+	m.initConfig()
+	if m.RunInParallel {
+		m.startWorkerPool()
+		defer m.stopWorkerPool()
+	}
+	return m.generateLeaves(blocks)
+}
+
+// initConfig initializes the unset configuration fields with the default values.
+func (m *MerkleTree) initConfig() {
+	// Hash function initialization.
+	if m.HashFunc == nil {
+		if m.Hasher != nil {
+			m.HashFunc = hashFuncFromHasher(m.Hasher)
+		} else if m.RunInParallel {
+			m.HashFunc = defaultHashFuncParallel // Parallelized hash function must be concurrent safe.
+		} else {
+			m.HashFunc = DefaultHashFunc
+		}
+	}
+	if isDefaultHashFunc(m.HashFunc) {
+		if m.HashName == "" {
+			m.HashName = HashSHA256
+		}
+		if m.HashID == 0 {
+			m.HashID = HashIDSHA256
+		}
+	}
+	// Hash concatenation function initialization.
+	if m.concatFunc == nil {
+		if m.SortSiblingPairs {
+			m.concatFunc = concatSortHash
+		} else {
+			m.concatFunc = concatHash
+		}
+	}
+	if m.RunInParallel {
+		// If NumRoutines is not set or invalid, set it to the number of CPU.
+		if m.NumRoutines <= 0 {
+			m.NumRoutines = runtime.NumCPU()
+		}
+		// Hashing is CPU-bound, so more workers than GOMAXPROCS only add scheduling overhead.
+		if m.numWorkers <= 0 {
+			m.numWorkers = m.NumRoutines
+			if maxProcs := runtime.GOMAXPROCS(0); m.numWorkers > maxProcs {
+				m.numWorkers = maxProcs
+			}
+		}
+	}
+}
+
+// checkPaddingStrategy checks that the padding strategy is valid and compatible with NoDuplicates.
+func (m *MerkleTree) checkPaddingStrategy() error {
+	switch m.PaddingStrategy {
+	case PaddingDuplicate:
+		return nil
+	case PaddingPromote:
+		if m.NoDuplicates {
+			return errors.New("NoDuplicates is not supported with PaddingPromote")
+		}
+		return nil
+	case PaddingIndexed:
+		if len(m.AdditionalHashFuncs) > 0 {
+			return errors.New("AdditionalHashFuncs is not supported with PaddingIndexed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid padding strategy %v", m.PaddingStrategy)
+	}
+}
+
+// startWorkerPool starts the worker pool for parallel computations.
+// The pool has numWorkers workers, which bounds the concurrency of the whole computation.
+func (m *MerkleTree) startWorkerPool() {
+	// Task channel capacity is passed as 0, so use the default value: 2 * numWorkers.
+	m.wp = gool.NewPool[argType, error](m.numWorkers, 0)
+	m.aborted, m.abortErr = make(chan struct{}), nil
+}
+
+// stopWorkerPool stops the worker pool, so that no worker goroutine outlives the computation.
+func (m *MerkleTree) stopWorkerPool() {
+	m.wp.Close()
+	m.wp, m.aborted = nil, nil
+}
+
+// mapWorkers runs the handler with each argument on the worker pool, and returns the first error of the workers.
+// Like errgroup, the first error aborts the other workers at their next cancellation check.
+func (m *MerkleTree) mapWorkers(handler func(arg argType) error, argList []argType) error {
+	m.wp.Map(func(arg argType) error {
+		err := handler(arg)
+		if err != nil {
+			m.abortWorkers(err)
+		}
+		return err
+	}, argList)
+	m.abortMu.Lock()
+	defer m.abortMu.Unlock()
+	return m.abortErr
+}
+
+// abortWorkers records the error if it is the first error of the workers, and aborts the other workers.
+func (m *MerkleTree) abortWorkers(err error) {
+	m.abortMu.Lock()
+	defer m.abortMu.Unlock()
+	if m.abortErr == nil {
+		m.abortErr = err
+		close(m.aborted)
+	}
+}
+
+// generateLeaves generates the leaves from the data blocks, in parallel if RunInParallel is true.
+func (m *MerkleTree) generateLeaves(blocks []DataBlock) ([][]byte, error) {
+	if len(m.AdditionalHashFuncs) > 0 {
+		m.additionalLeaves = make([][][]byte, len(blocks))
+	}
+	if m.RunInParallel {
+		return m.leafGenParallel(blocks)
+	}
+	if m.isSmallTreeLeaves() {
+		return m.leafGenSmall(blocks)
+	}
+	return m.leafGen(blocks)
+}
+
+// concatHash concatenates the two hashes.
+// The capacity of b1 is limited to its length, so that the concatenation never writes past b1 into its backing array,
+// e.g. into the next sibling when the siblings are sub-slices of one buffer.
 func concatHash(b1 []byte, b2 []byte) []byte {
-	return append(b1, b2...)
+	return append(b1[:len(b1):len(b1)], b2...)
 }
 
 func concatSortHash(b1 []byte, b2 []byte) []byte {
 	if bytes.Compare(b1, b2) < 0 {
-		return append(b1, b2...)
+		return concatHash(b1, b2)
 	}
-	return append(b2, b1...)
+	return concatHash(b2, b1)
 }
 
 // calTreeDepth calculates the tree depth.
@@ -226,17 +734,74 @@ func calTreeDepth(blockLen int) uint32 {
 	return depth
 }
 
+// initProofs allocates the proofs of the leaves, or only those of the leaves selected by ProofIndices if set.
 func (m *MerkleTree) initProofs() {
 	m.Proofs = make([]*Proof, m.NumLeaves)
-	for i := 0; i < m.NumLeaves; i++ {
-		m.Proofs[i] = new(Proof)
+	initProof := func(i int) {
+		m.Proofs[i] = &Proof{Index: i, Fingerprint: m.fingerprint}
 		m.Proofs[i].Siblings = make([][]byte, 0, m.Depth)
 	}
+	if m.ProofIndices != nil {
+		for _, i := range m.ProofIndices {
+			if m.Proofs[i] == nil {
+				initProof(i)
+			}
+		}
+		return
+	}
+	for i := 0; i < m.NumLeaves; i++ {
+		initProof(i)
+	}
+}
+
+// initProofBuffers allocates one buffer for each proof, holding its siblings and its fingerprint,
+// so that the proofs do not reference the intermediate nodes and their memory is owned by the proofs.
+func (m *MerkleTree) initProofBuffers(hashSize int) {
+	m.proofBufs, m.proofSiblingSize = make([][]byte, m.NumLeaves), hashSize
+	siblingsLen := int(m.Depth) * hashSize
+	for i, proof := range m.Proofs {
+		if proof == nil {
+			continue
+		}
+		buf := make([]byte, siblingsLen+len(m.fingerprint))
+		if m.fingerprint != nil {
+			proof.Fingerprint = buf[siblingsLen:]
+			copy(proof.Fingerprint, m.fingerprint)
+		}
+		m.proofBufs[i] = buf[:siblingsLen:siblingsLen]
+	}
+}
+
+// proofSibling returns the sibling node of the proof at the level, copied into the proof buffer if any.
+func (m *MerkleTree) proofSibling(proofIdx, level int, node []byte) []byte {
+	if m.proofBufs == nil || len(node) != m.proofSiblingSize {
+		return node
+	}
+	offset := level * m.proofSiblingSize
+	sibling := m.proofBufs[proofIdx][offset : offset+m.proofSiblingSize : offset+m.proofSiblingSize]
+	copy(sibling, node)
+	return sibling
 }
 
 func (m *MerkleTree) proofGen() (err error) {
+	if m.isUnrolledTree() || m.isSmallTree() {
+		if m.isUnrolledTree() {
+			err = m.proofGenUnrolled()
+		} else {
+			err = m.proofGenSmall()
+		}
+		// The proofs of a small tree are generated together, so the unselected proofs are dropped afterwards.
+		m.dropUnselectedProofs()
+		return
+	}
 	m.initProofs()
-	buf := make([][]byte, m.NumLeaves)
+	if hashSize := m.HashSize(); hashSize > 0 && !m.DisableLeafHashing && !m.ShareSiblings {
+		m.initProofBuffers(hashSize)
+		defer func() {
+			m.proofBufs, m.proofSiblingSize = nil, 0
+		}()
+	}
+	buf := make([][]byte, m.NumLeaves, paddedLevelCap(m.NumLeaves))
 	copy(buf, m.Leaves)
 	var prevLen int
 	if buf, prevLen, err = m.fixOdd(buf, m.NumLeaves); err != nil {
@@ -245,27 +810,15 @@ func (m *MerkleTree) proofGen() (err error) {
 	if m.RunInParallel {
 		buff := make([][]byte, prevLen>>1)
 		m.updateProofsParallel(buf, m.NumLeaves, 0)
-		numRoutines := m.NumRoutines
 		for step := 1; step < int(m.Depth); step++ {
-			if numRoutines > prevLen {
-				numRoutines = prevLen
-			}
-			argList := make([]argType, numRoutines)
-			for i := 0; i < numRoutines; i++ {
-				argList[i] = argType{
-					mt:         m,
-					byteField1: buf,
-					byteField2: buff,
-					intField1:  i << 1, // starting index
-					intField2:  prevLen,
-					intField3:  numRoutines,
-				}
-			}
-			errList := wp.Map(proofGenHandler, argList)
-			for _, err = range errList {
-				if err != nil {
-					return
-				}
+			argList := m.pairTasks(prevLen>>1, argType{
+				mt:         m,
+				byteField1: buf,
+				byteField2: buff,
+				intField4:  step, // tree level of the computed nodes
+			})
+			if err = m.mapWorkers(proofGenHandler, argList); err != nil {
+				return
 			}
 			buf, buff = buff, buf
 			prevLen >>= 1
@@ -278,7 +831,10 @@ func (m *MerkleTree) proofGen() (err error) {
 		m.updateProofs(buf, m.NumLeaves, 0)
 		for step := 1; step < int(m.Depth); step++ {
 			for idx := 0; idx < prevLen; idx += 2 {
-				buf[idx>>1], err = m.HashFunc(m.concatFunc(buf[idx], buf[idx+1]))
+				if err = m.checkCanceled(); err != nil {
+					return
+				}
+				buf[idx>>1], err = m.hashNode(step, idx>>1, buf[idx], buf[idx+1])
 				if err != nil {
 					return
 				}
@@ -291,42 +847,58 @@ func (m *MerkleTree) proofGen() (err error) {
 		}
 	}
 
-	m.Root, err = m.HashFunc(m.concatFunc(buf[0], buf[1]))
+	if m.Root, err = m.hashNode(int(m.Depth), 0, buf[0], buf[1]); err != nil {
+		return
+	}
+	m.Root = truncateRoot(m.Root, m.Config)
 	return
 }
 
 // proofGenHandler generates the proofs in parallel.
-func proofGenHandler(arg argType) error {
+func proofGenHandler(arg argType) (err error) {
+	defer recoverError(&err)
 	var (
-		hashFunc    = arg.mt.HashFunc
-		concatFunc  = arg.mt.concatFunc
-		buf1        = arg.byteField1
-		buf2        = arg.byteField2
-		start       = arg.intField1
-		prevLen     = arg.intField2
-		numRoutines = arg.intField3
+		mt      = arg.mt // the Merkle Tree instance
+		buf1    = arg.byteField1
+		buf2    = arg.byteField2
+		batcher = arg.batcher
+		level   = arg.intField4
 	)
-	for i := start; i < prevLen; i += numRoutines << 1 {
-		newHash, err := hashFunc(concatFunc(buf1[i], buf1[i+1]))
-		if err != nil {
-			return err
+	for start, end, ok := batcher.grab(); ok; start, end, ok = batcher.grab() {
+		for i := start; i < end; i += 2 {
+			if err = mt.checkCanceled(); err != nil {
+				return
+			}
+			newHash, err := mt.hashNode(level, i>>1, buf1[i], buf1[i+1])
+			if err != nil {
+				return err
+			}
+			buf2[i>>1] = newHash
 		}
-		buf2[i>>1] = newHash
 	}
 	return nil
 }
 
 // fixOdd fixes the odd-length slice by appending a node to it.
-// If NoDuplicates is true, append a node by duplicating the previous node.
-// Otherwise, append a node by random.
+// If NoDuplicates is true, append the node derived from the previous node by NoDuplicatesFiller.
+// Otherwise, append a node by duplicating the previous node.
+// With PaddingPromote, append a nil node, so that the previous node is promoted by hashPair.
 func (m *MerkleTree) fixOdd(buf [][]byte, prevLen int) ([][]byte, int, error) {
+	return m.fixOddWith(m.HashFunc, buf, prevLen)
+}
+
+// fixOddWith fixes the odd-length slice as fixOdd does, deriving the NoDuplicates padding node with the hash function,
+// e.g. one of the AdditionalHashFuncs.
+func (m *MerkleTree) fixOddWith(hashFunc TypeHashFunc, buf [][]byte, prevLen int) ([][]byte, int, error) {
 	if prevLen&1 == 0 {
 		return buf, prevLen, nil
 	}
 	var appendNode []byte
-	if m.NoDuplicates {
+	if m.PaddingStrategy == PaddingPromote {
+		appendNode = nil
+	} else if m.NoDuplicates {
 		var err error
-		if appendNode, err = dummyHash(); err != nil {
+		if appendNode, err = m.noDuplicatesFiller(hashFunc, buf[prevLen-1]); err != nil {
 			return nil, 0, err
 		}
 	} else {
@@ -351,53 +923,55 @@ func (m *MerkleTree) updateProofs(buf [][]byte, bufLen, step int) {
 // updateProofHandler updates the proofs in parallel.
 func updateProofHandler(arg argType) error {
 	var (
-		mt          = arg.mt // The Merkle Tree instance
-		buf         = arg.byteField1
-		start       = arg.intField1
-		batch       = arg.intField2
-		step        = arg.intField3
-		bufLen      = arg.intField4
-		numRoutines = arg.intField5
+		mt      = arg.mt // The Merkle Tree instance
+		buf     = arg.byteField1
+		batcher = arg.batcher
+		step    = arg.intField3
 	)
-	for i := start; i < bufLen; i += numRoutines << 1 {
-		mt.updatePairProofs(buf, i, batch, step)
+	for start, end, ok := batcher.grab(); ok; start, end, ok = batcher.grab() {
+		for i := start; i < end; i += 2 {
+			mt.updatePairProofs(buf, i, 1<<step, step)
+		}
 	}
 	// return the nil error to be compatible with the handler type
 	return nil
 }
 
 func (m *MerkleTree) updateProofsParallel(buf [][]byte, bufLen, step int) {
-	batch := 1 << step
-	numRoutines := m.NumRoutines
-	if numRoutines > bufLen {
-		numRoutines = bufLen
-	}
-	argList := make([]argType, numRoutines)
-	for i := 0; i < numRoutines; i++ {
-		argList[i] = argType{
-			mt:         m,
-			byteField1: buf,
-			intField1:  i << 1, // starting index
-			intField2:  batch,
-			intField3:  step,
-			intField4:  bufLen,
-			intField5:  numRoutines,
-		}
-	}
-	wp.Map(updateProofHandler, argList)
+	// An odd last node is paired with the padding node after it.
+	argList := m.pairTasks((bufLen+1)>>1, argType{
+		mt:         m,
+		byteField1: buf,
+		intField3:  step,
+	})
+	m.wp.Map(updateProofHandler, argList)
 }
 
+// updatePairProofs appends the nodes of the pair at the index as the siblings of the proofs of the leaves
+// under the other node of the pair. The path bits are indexed by sibling,
+// as the proofs have no sibling at the levels where their node is promoted.
 func (m *MerkleTree) updatePairProofs(buf [][]byte, idx, batch, step int) {
+	if buf[idx+1] == nil {
+		return // The node is promoted.
+	}
 	start := idx * batch
 	end := min(start+batch, len(m.Proofs))
 	for i := start; i < end; i++ {
-		m.Proofs[i].Path += 1 << step
-		m.Proofs[i].Siblings = append(m.Proofs[i].Siblings, buf[idx+1])
+		proof := m.Proofs[i]
+		if proof == nil {
+			continue
+		}
+		proof.Path += 1 << len(proof.Siblings)
+		proof.Siblings = append(proof.Siblings, m.proofSibling(i, len(proof.Siblings), buf[idx+1]))
 	}
 	start += batch
 	end = min(start+batch, len(m.Proofs))
 	for i := start; i < end; i++ {
-		m.Proofs[i].Siblings = append(m.Proofs[i].Siblings, buf[idx])
+		proof := m.Proofs[i]
+		if proof == nil {
+			continue
+		}
+		proof.Siblings = append(proof.Siblings, m.proofSibling(i, len(proof.Siblings), buf[idx]))
 	}
 }
 
@@ -408,44 +982,154 @@ func min(a, b int) int {
 	return b
 }
 
-// dummyHash generates a dummy hash to make odd-length buffer even.
-func dummyHash() ([]byte, error) {
-	dummyBytes := make([]byte, defaultHashLen)
-	if _, err := rand.Read(dummyBytes); err != nil {
-		return nil, err
-	}
-	return dummyBytes, nil
-}
-
 func (m *MerkleTree) leafGen(blocks []DataBlock) ([][]byte, error) {
 	var (
 		leaves = make([][]byte, m.NumLeaves)
 		err    error
 	)
 	for i := 0; i < m.NumLeaves; i++ {
-		if leaves[i], err = leafFromBlock(blocks[i], m.Config); err != nil {
+		if err = m.checkCanceled(); err != nil {
+			return nil, err
+		}
+		if leaves[i], err = m.blockLeaves(blocks, i); err != nil {
 			return nil, err
 		}
+		if !m.DisableLeafHashing {
+			if err = m.checkHashSize(leaves[i]); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return leaves, nil
 }
 
-func leafFromBlock(block DataBlock, config *Config) ([]byte, error) {
+// blockLeaves computes the leaf of the data block at the leaf index and,
+// if there are additional hash functions, the additional leaves, serializing the data block only once.
+func (m *MerkleTree) blockLeaves(blocks []DataBlock, idx int) ([]byte, error) {
+	leaf, additional, err := m.dataBlockLeaves(blocks[idx], idx)
+	if additional != nil {
+		m.additionalLeaves[idx] = additional
+	}
+	return leaf, err
+}
+
+// dataBlockLeaves computes the leaf and the additional leaves of the data block at the leaf index,
+// streaming MmapBlock data blocks, and reports the anomalies to OnAnomaly.
+func (m *MerkleTree) dataBlockLeaves(block DataBlock, idx int) ([]byte, [][]byte, error) {
+	if streamed, ok := streamedBlock(block, m.Config); ok {
+		leaf, err := m.streamedLeaf(streamed, idx)
+		return leaf, nil, err
+	}
+	start := m.anomalyStart()
+	blockBytes, err := block.Serialize()
+	if err != nil {
+		m.checkSlow(AnomalySlowSerialize, idx, start, -1)
+		return nil, nil, err
+	}
+	m.checkSlow(AnomalySlowSerialize, idx, start, int64(len(blockBytes)))
+	m.checkLarge(idx, int64(len(blockBytes)))
+	start = m.anomalyStart()
+	leaf, additional, err := m.leavesFromBytes(blockBytes, idx)
+	m.checkSlow(AnomalySlowHash, idx, start, int64(len(blockBytes)))
+	return leaf, additional, err
+}
+
+// leavesFromBytes checks the size of the serialized data block at the leaf index, and computes its leaf and,
+// if there are additional hash functions, its additional leaves, transforming the data block only once.
+func (m *MerkleTree) leavesFromBytes(blockBytes []byte, idx int) ([]byte, [][]byte, error) {
+	if err := m.checkLeafSize(blockBytes, idx); err != nil {
+		return nil, nil, err
+	}
+	blockBytes, err := transformLeaf(blockBytes, m.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	leaf, err := leafFromTransformed(blockBytes, idx, m.Config)
+	if err != nil || len(m.AdditionalHashFuncs) == 0 {
+		return leaf, nil, err
+	}
+	additional, err := m.additionalLeavesFromBytes(leaf, blockBytes, idx)
+	return leaf, additional, err
+}
+
+// checkLeafSize checks that the serialized data block at the leaf index is of ExpectLeafSize bytes, if set.
+func (m *MerkleTree) checkLeafSize(blockBytes []byte, idx int) error {
+	if m.ExpectLeafSize > 0 && len(blockBytes) != m.ExpectLeafSize {
+		return fmt.Errorf("%w: data block at leaf index %d is %d bytes, want %d bytes",
+			ErrUnexpectedLeafSize, idx, len(blockBytes), m.ExpectLeafSize)
+	}
+	return nil
+}
+
+// leafFromBlock computes the leaf of the data block at the leaf index.
+func leafFromBlock(block DataBlock, idx int, config *Config) ([]byte, error) {
+	if streamed, ok := streamedBlock(block, config); ok {
+		return hashStreamedBlock(streamed, idx, config, nil)
+	}
 	blockBytes, err := block.Serialize()
 	if err != nil {
 		return nil, err
 	}
+	return leafFromBytes(blockBytes, idx, config)
+}
+
+// leafFromBytes computes the leaf of the serialized data block at the leaf index.
+func leafFromBytes(blockBytes []byte, idx int, config *Config) ([]byte, error) {
+	blockBytes, err := transformLeaf(blockBytes, config)
+	if err != nil {
+		return nil, err
+	}
+	return leafFromTransformed(blockBytes, idx, config)
+}
+
+// transformLeaf applies LeafTransform, if set, to the serialized data block.
+func transformLeaf(blockBytes []byte, config *Config) ([]byte, error) {
+	if config.LeafTransform == nil {
+		return blockBytes, nil
+	}
+	transformed, err := config.LeafTransform(blockBytes)
+	if err != nil {
+		return nil, fmt.Errorf("leaf transform: %w", err)
+	}
+	return transformed, nil
+}
+
+// leafFromTransformed computes the leaf of the transformed data block at the leaf index.
+func leafFromTransformed(blockBytes []byte, idx int, config *Config) ([]byte, error) {
+	if config.Hasher != nil && !config.DisableLeafHashing {
+		return hashLeafStreaming(config, blockBytes, idx)
+	}
+	if config.BindLeafIndex {
+		blockBytes = bindLeafIndex(blockBytes, idx)
+	}
 	if config.DisableLeafHashing {
 		// copy the value so that the original byte slice is not modified
 		leaf := make([]byte, len(blockBytes))
 		copy(leaf, blockBytes)
 		return leaf, nil
 	}
-	return config.HashFunc(blockBytes)
+	return config.hashWith(config.levelHash(0), blockBytes)
+}
+
+// hashWith hashes the data with the hash function, copying the data first if ParanoidHashInput is true.
+func (c *Config) hashWith(hashFunc TypeHashFunc, data []byte) ([]byte, error) {
+	if c.ParanoidHashInput {
+		data = append(make([]byte, 0, len(data)), data...)
+	}
+	return hashFunc(data)
+}
+
+// bindLeafIndex prefixes the serialized data block with its big-endian uint64 leaf index.
+func bindLeafIndex(blockBytes []byte, idx int) []byte {
+	bound := make([]byte, 8+len(blockBytes))
+	binary.BigEndian.PutUint64(bound, uint64(idx))
+	copy(bound[8:], blockBytes)
+	return bound
 }
 
 // leafGenHandler generates the leaves in parallel.
-func leafGenHandler(arg argType) error {
+func leafGenHandler(arg argType) (err error) {
+	defer recoverError(&err)
 	var (
 		blocks      = arg.dataBlockField
 		leaves      = arg.byteField1
@@ -453,20 +1137,30 @@ func leafGenHandler(arg argType) error {
 		lenLeaves   = arg.intField2
 		numRoutines = arg.intField3
 	)
-	var err error
 	for i := start; i < lenLeaves; i += numRoutines {
-		if leaves[i], err = leafFromBlock(blocks[i], arg.mt.Config); err != nil {
+		if err = arg.mt.checkCanceled(); err != nil {
+			return
+		}
+		if leaves[i], err = arg.mt.blockLeaves(blocks, i); err != nil {
 			return err
 		}
+		if !arg.mt.DisableLeafHashing {
+			if err = arg.mt.checkHashSize(leaves[i]); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// leafGenParallel generates the leaves in parallel, striding the leaf indexes over the workers.
+// Every leaf is computed from its data block and leaf index alone, and stored at the leaf index,
+// so the leaves do not depend on the number of workers or on which worker computes them.
 func (m *MerkleTree) leafGenParallel(blocks []DataBlock) ([][]byte, error) {
 	var (
 		lenLeaves   = len(blocks)
 		leaves      = make([][]byte, lenLeaves)
-		numRoutines = m.NumRoutines
+		numRoutines = m.numWorkers
 	)
 	if numRoutines > lenLeaves {
 		numRoutines = lenLeaves
@@ -482,41 +1176,65 @@ func (m *MerkleTree) leafGenParallel(blocks []DataBlock) ([][]byte, error) {
 			intField3:      numRoutines,
 		}
 	}
-	errList := wp.Map(leafGenHandler, argList)
-	for _, err := range errList {
-		if err != nil {
-			return nil, err
-		}
+	if err := m.mapWorkers(leafGenHandler, argList); err != nil {
+		return nil, err
 	}
 	return leaves, nil
 }
 
 func (m *MerkleTree) treeBuild() (err error) {
+	m.leafMap = &sync.Map{}
 	finishMap := make(chan struct{})
+	// done is closed when the tree building returns, so that the map generation goroutine
+	// never blocks on sending to finishMap after an error.
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
 		for i := 0; i < m.NumLeaves; i++ {
-			m.leafMap.Store(string(m.Leaves[i]), i)
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// The first of identical leaves is kept, as in the lookup among the leaves.
+			m.leafMap.LoadOrStore(string(m.Leaves[i]), i)
+		}
+		select {
+		case finishMap <- struct{}{}: // empty channel to serve as a wait group for map generation
+		case <-done:
 		}
-		finishMap <- struct{}{} // empty channel to serve as a wait group for map generation
 	}()
 	m.nodes = make([][][]byte, m.Depth)
-	m.nodes[0] = make([][]byte, m.NumLeaves)
+	m.nodes[0] = make([][]byte, m.NumLeaves, paddedLevelCap(m.NumLeaves))
 	copy(m.nodes[0], m.Leaves)
 	var prevLen int
 	if m.nodes[0], prevLen, err = m.fixOdd(m.nodes[0], m.NumLeaves); err != nil {
 		return
 	}
 	if m.RunInParallel {
-		if err := m.computeTreeNodeParallel(prevLen); err != nil {
-			return err
-		}
+		err = m.computeTreeNodeParallel(prevLen)
+	} else {
+		err = m.computeTreeNode(prevLen)
 	}
+	if err != nil {
+		return
+	}
+	if m.Root, err = m.hashNode(int(m.Depth), 0, m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1]); err != nil {
+		return
+	}
+	m.Root = truncateRoot(m.Root, m.Config)
+	<-finishMap
+	return
+}
+
+func (m *MerkleTree) computeTreeNode(prevLen int) (err error) {
 	for i := uint32(0); i < m.Depth-1; i++ {
-		m.nodes[i+1] = make([][]byte, prevLen>>1)
+		m.nodes[i+1] = make([][]byte, prevLen>>1, paddedLevelCap(prevLen>>1))
 		for j := 0; j < prevLen; j += 2 {
-			if m.nodes[i+1][j>>1], err = m.HashFunc(
-				m.concatFunc(m.nodes[i][j], m.nodes[i][j+1]),
-			); err != nil {
+			if err = m.checkCanceled(); err != nil {
+				return
+			}
+			if m.nodes[i+1][j>>1], err = m.hashNode(int(i+1), j>>1, m.nodes[i][j], m.nodes[i][j+1]); err != nil {
 				return
 			}
 		}
@@ -524,37 +1242,18 @@ func (m *MerkleTree) treeBuild() (err error) {
 			return
 		}
 	}
-	if m.Root, err = m.HashFunc(m.concatFunc(
-		m.nodes[m.Depth-1][0], m.nodes[m.Depth-1][1],
-	)); err != nil {
-		return
-	}
-	<-finishMap
-	return
+	return nil
 }
 
 func (m *MerkleTree) computeTreeNodeParallel(prevLen int) error {
 	for i := uint32(0); i < m.Depth-1; i++ {
-		m.nodes[i+1] = make([][]byte, prevLen>>1)
-		numRoutines := m.NumRoutines
-		if numRoutines > prevLen {
-			numRoutines = prevLen
-		}
-		argList := make([]argType, numRoutines)
-		for j := 0; j < numRoutines; j++ {
-			argList[j] = argType{
-				mt:          m,
-				intField1:   j << 1, // starting index
-				intField2:   prevLen,
-				intField3:   m.NumRoutines,
-				uint32Field: i, // tree depth
-			}
-		}
-		errList := wp.Map(treeBuildHandler, argList)
-		for _, err := range errList {
-			if err != nil {
-				return err
-			}
+		m.nodes[i+1] = make([][]byte, prevLen>>1, paddedLevelCap(prevLen>>1))
+		argList := m.pairTasks(prevLen>>1, argType{
+			mt:          m,
+			uint32Field: i, // tree depth
+		})
+		if err := m.mapWorkers(treeBuildHandler, argList); err != nil {
+			return err
 		}
 		var err error
 		if m.nodes[i+1], prevLen, err = m.fixOdd(m.nodes[i+1], len(m.nodes[i+1])); err != nil {
@@ -565,121 +1264,483 @@ func (m *MerkleTree) computeTreeNodeParallel(prevLen int) error {
 }
 
 // treeBuildHandler builds the tree in parallel.
-func treeBuildHandler(arg argType) error {
+func treeBuildHandler(arg argType) (err error) {
+	defer recoverError(&err)
 	var (
-		mt          = arg.mt // the Merkle Tree instance
-		start       = arg.intField1
-		prevLen     = arg.intField2
-		numRoutines = arg.intField3
-		depth       = arg.uint32Field
+		mt      = arg.mt // the Merkle Tree instance
+		batcher = arg.batcher
+		depth   = arg.uint32Field
 	)
-	for i := start; i < prevLen; i += numRoutines << 1 {
-		newHash, err := mt.HashFunc(mt.concatFunc(mt.nodes[depth][i], mt.nodes[depth][i+1]))
-		if err != nil {
-			return err
+	for start, end, ok := batcher.grab(); ok; start, end, ok = batcher.grab() {
+		for i := start; i < end; i += 2 {
+			if err = mt.checkCanceled(); err != nil {
+				return
+			}
+			newHash, err := mt.hashNode(int(depth+1), i>>1, mt.nodes[depth][i], mt.nodes[depth][i+1])
+			if err != nil {
+				return err
+			}
+			mt.nodes[depth+1][i>>1] = newHash
 		}
-		mt.nodes[depth+1][i>>1] = newHash
 	}
 	return nil
 }
 
+// hashPair computes the parent node at the level of the sibling pair, checking the size of the hash output.
+// If right is nil, i.e. the padding node of PaddingPromote, left is promoted as the parent node.
+func (m *MerkleTree) hashPair(level int, left, right []byte) ([]byte, error) {
+	if right == nil {
+		return left, nil
+	}
+	parent, err := m.pairHash(level, left, right)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.checkHashSize(parent); err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// hashNode computes the internal node at the level and index from its children, reporting it to OnNode if set.
+func (m *MerkleTree) hashNode(level, index int, left, right []byte) ([]byte, error) {
+	node, err := m.hashPair(level, left, right)
+	if err != nil || m.OnNode == nil {
+		return node, err
+	}
+	m.onNodeMu.Lock()
+	defer m.onNodeMu.Unlock()
+	m.OnNode(level, index, node)
+	return node, nil
+}
+
+// checkHashSize checks that the hash output is non-empty and of the same size as the previous hash outputs.
+// The size of the first hash output is cached as the hash size of the tree.
+// Loading before swapping keeps the check cheap when called concurrently by the workers.
+func (m *MerkleTree) checkHashSize(hash []byte) error {
+	size := int64(len(hash))
+	if size == 0 {
+		return fmt.Errorf("%w: empty hash output", ErrInconsistentHashSize)
+	}
+	cached := m.hashSize.Load()
+	if cached == size {
+		return nil
+	}
+	if cached == 0 && m.hashSize.CompareAndSwap(0, size) {
+		return nil
+	}
+	return fmt.Errorf("%w: got %d bytes, want %d bytes", ErrInconsistentHashSize, size, m.hashSize.Load())
+}
+
+// checkCanceled returns the context error if the tree building process is canceled,
+// or errWorkersAborted if another worker of the parallel computations failed.
+func (m *MerkleTree) checkCanceled() error {
+	select {
+	case <-m.done:
+		return m.ctx.Err()
+	case <-m.aborted:
+		return errWorkersAborted
+	default:
+		return nil
+	}
+}
+
+// recoverError converts a panic in the user-provided functions, e.g. HashFunc or Serialize, into an error.
+// It is deferred by the handler functions so that a panic in a worker goroutine does not crash the program.
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("recovered from panic: %v", r)
+	}
+}
+
 // Verify verifies the data block with the Merkle Tree proof
 func (m *MerkleTree) Verify(dataBlock DataBlock, proof *Proof) (bool, error) {
-	return Verify(dataBlock, proof, m.Root, m.Config)
+	return Verify(dataBlock, proof, m.MerkleRoot(), m.Config)
+}
+
+// MerkleRoot returns the Merkle root, i.e. Root, implementing RootProvider.
+func (m *MerkleTree) MerkleRoot() []byte {
+	return m.Root
 }
 
 // Verify verifies the data block with the Merkle Tree proof and Merkle root hash
+// The root is always computed through all the siblings of the proof, and compared with the Merkle root
+// in constant time, so that the verification time does not reveal where a mismatch occurs.
+// The siblings are folded in the order of the proof, on the side given by the path, without assuming
+// the shape of the tree, so proofs of trees promoting odd nodes to the next level instead of duplicating them,
+// which have no sibling at the levels where the node is promoted, are verified as well.
+// If a sibling differs in size from the hash outputs, or the hash outputs differ in size, e.g. when the verifier
+// is configured with another hash function than the Merkle Tree, it returns ErrInconsistentHashSize
+// describing the mismatch instead of false. A nil data block, proof or root returns ErrNilProof.
 func Verify(dataBlock DataBlock, proof *Proof, root []byte, config *Config) (bool, error) {
-	if dataBlock == nil {
-		return false, errors.New("data block is nil")
+	if err := checkVerifyInput(dataBlock, proof, root); err != nil {
+		return false, err
+	}
+	result, err := rootFromBlock(dataBlock, proof, config)
+	if err != nil {
+		return false, err
+	}
+	return rootMatches(result, root, config), nil
+}
+
+// VerifyAny verifies the data block with the Merkle Tree proof against several candidate Merkle roots,
+// e.g. the previous and the current roots during a root rotation.
+// The root is computed once from the data block and the proof, and compared with every candidate in constant time.
+// It returns the index of the first matching root, or -1 if no root matches.
+func VerifyAny(dataBlock DataBlock, proof *Proof, roots [][]byte, config *Config) (int, error) {
+	if len(roots) == 0 {
+		return -1, errors.New("no candidate roots")
+	}
+	for i, root := range roots {
+		if root == nil {
+			return -1, errNilInput(fmt.Sprintf("candidate root %d", i))
+		}
+	}
+	result, err := rootFromBlock(dataBlock, proof, config)
+	if err != nil {
+		return -1, err
+	}
+	matched := -1
+	for i, root := range roots {
+		if rootMatches(result, root, config) && matched < 0 {
+			matched = i
+		}
+	}
+	return matched, nil
+}
+
+// VerifyReturningLeaf verifies the data block with the Merkle Tree proof and Merkle root hash like Verify,
+// and also returns the leaf computed from the data block if the proof is valid, e.g. to index the data block
+// by its leaf hash without hashing it again. The leaf is computed as by New, with BindLeafIndex,
+// LeafTransform and DisableLeafHashing applied, so it equals Leaves[proof.Index] of the Merkle Tree.
+// The leaf is nil if the proof is not valid.
+func VerifyReturningLeaf(dataBlock DataBlock, proof *Proof, root []byte, config *Config) (bool, []byte, error) {
+	if err := checkVerifyInput(dataBlock, proof, root); err != nil {
+		return false, nil, err
+	}
+	leaf, result, err := leafAndRootFromBlock(dataBlock, proof, config)
+	if err != nil {
+		return false, nil, err
+	}
+	if !rootMatches(result, root, config) {
+		return false, nil, nil
+	}
+	return true, leaf, nil
+}
+
+// rootFromBlock computes the Merkle root from the data block and its proof.
+func rootFromBlock(dataBlock DataBlock, proof *Proof, config *Config) ([]byte, error) {
+	_, root, err := leafAndRootFromBlock(dataBlock, proof, config)
+	return root, err
+}
+
+// leafAndRootFromBlock computes the leaf of the data block and the Merkle root from the leaf and the proof.
+func leafAndRootFromBlock(dataBlock DataBlock, proof *Proof, config *Config) ([]byte, []byte, error) {
+	if isNilBlock(dataBlock) {
+		return nil, nil, errNilInput("data block")
 	}
 	if proof == nil {
-		return false, errors.New("proof is nil")
+		return nil, nil, errNilInput("proof")
+	}
+	config = verifyConfig(config)
+	if err := checkProofDepth(proof, config); err != nil {
+		return nil, nil, err
+	}
+	if err := checkFingerprint(proof.Fingerprint, config); err != nil {
+		return nil, nil, err
+	}
+	leaf, err := leafFromBlock(dataBlock, proof.Index, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := rootFromLeaf(leaf, proof, config)
+	if err != nil {
+		return nil, nil, err
 	}
+	return leaf, root, nil
+}
+
+// truncateRoot truncates the root to RootBytes bytes, if set.
+func truncateRoot(root []byte, config *Config) []byte {
+	if config != nil && config.RootBytes > 0 && config.RootBytes < len(root) {
+		return root[:config.RootBytes:config.RootBytes]
+	}
+	return root
+}
+
+// rootMatches reports whether the computed root matches the Merkle root, compared in constant time
+// after truncating the computed root to RootBytes bytes, if set.
+func rootMatches(result, root []byte, config *Config) bool {
+	return subtle.ConstantTimeCompare(truncateRoot(result, config), root) == 1
+}
+
+// verifyConfig initializes the configuration for verification with the default hash and concatenation functions.
+func verifyConfig(config *Config) *Config {
 	if config == nil {
 		config = new(Config)
 	}
 	if config.HashFunc == nil {
-		config.HashFunc = defaultHashFunc
+		if config.Hasher != nil {
+			config.HashFunc = hashFuncFromHasher(config.Hasher)
+		} else {
+			config.HashFunc = DefaultHashFunc
+		}
 	}
 	if config.concatFunc == nil {
-		config.concatFunc = concatHash
+		if config.SortSiblingPairs {
+			config.concatFunc = concatSortHash
+		} else {
+			config.concatFunc = concatHash
+		}
 	}
-	leaf, err := leafFromBlock(dataBlock, config)
-	if err != nil {
-		return false, err
+	return config
+}
+
+// rootFromLeaf computes the Merkle root from the leaf and its proof.
+// It never returns early on a diverging node, all the siblings are always processed.
+// It returns ErrInconsistentHashSize if a sibling or a hash output differs in size from the hash outputs,
+// e.g. when the verifier is configured with another hash function than the Merkle Tree.
+// Root-to-leaf proofs are reversed first, so the proofs are accepted in both orientations.
+// The depth of the proof is checked by the callers with checkProofDepth, before the leaf is hashed.
+func rootFromLeaf(leaf []byte, proof *Proof, config *Config) ([]byte, error) {
+	if proof.RootToLeaf {
+		proof = proof.Reverse()
 	}
 	// Copy the slice so that the original leaf won't be modified.
 	result := make([]byte, len(leaf))
 	copy(result, leaf)
 	path := proof.Path
-	for _, sib := range proof.Siblings {
+	// hashSize is the size of the hash outputs, unknown until the first hash if the leaf is not a hash.
+	// If the leaves are not hashed, the siblings are not checked, as a leaf may be promoted to any level.
+	var hashSize int
+	if !config.DisableLeafHashing {
+		hashSize = len(leaf)
+	}
+	var err error
+	for i, sib := range proof.Siblings {
+		if !config.DisableLeafHashing && len(sib) != hashSize {
+			return nil, fmt.Errorf("%w: sibling at level %d is %d bytes but hash outputs %d bytes",
+				ErrInconsistentHashSize, i, len(sib), hashSize)
+		}
 		if path&1 == 1 {
-			if result, err = config.HashFunc(config.concatFunc(result, sib)); err != nil {
-				return false, err
+			if result, err = config.pairHash(i+1, result, sib); err != nil {
+				return nil, err
 			}
 		} else {
-			if result, err = config.HashFunc(config.concatFunc(sib, result)); err != nil {
-				return false, err
+			if result, err = config.pairHash(i+1, sib, result); err != nil {
+				return nil, err
 			}
 		}
+		if hashSize == 0 {
+			hashSize = len(result)
+		} else if len(result) != hashSize {
+			return nil, fmt.Errorf("%w: hash outputs %d bytes at level %d but %d bytes below",
+				ErrInconsistentHashSize, len(result), i, hashSize)
+		}
 		path >>= 1
 	}
-	return bytes.Equal(result, root), nil
+	return result, nil
+}
+
+// GenerateProof generates the Merkle proof of the data block, looked up by its leaf with IndexOf.
+// The proof is one of the Proofs if they are generated, e.g. in ModeProofGen, and is generated from the tree nodes
+// otherwise. It returns an error if the data block is not a member of the Merkle Tree,
+// or if BindLeafIndex is set, as the leaf of a data block depends on its unknown index.
+func (m *MerkleTree) GenerateProof(dataBlock DataBlock) (*Proof, error) {
+	idx, err := m.IndexOf(dataBlock)
+	if err != nil {
+		return nil, err
+	}
+	return m.ProofOf(idx)
+}
+
+// IndexOf returns the leaf index of the data block, looked up by its leaf in the leaf map if the tree nodes are built,
+// and among the leaves otherwise. The index is always that of a leaf of the data blocks, below NumLeaves:
+// the padding leaf of an odd leaf level, which duplicates the last leaf by default, is never returned,
+// and if several leaves are identical, the index of the first one is returned in every mode.
+// It returns an error if the data block is not a member of the Merkle Tree, or if BindLeafIndex is set.
+func (m *MerkleTree) IndexOf(dataBlock DataBlock) (int, error) {
+	leaf, err := m.LeafHashOf(dataBlock)
+	if err != nil {
+		return 0, err
+	}
+	if m.nodes != nil {
+		if val, ok := m.leafMap.Load(string(leaf)); ok {
+			return val.(int), nil
+		}
+	} else {
+		for i := range m.Leaves {
+			if bytes.Equal(m.Leaves[i], leaf) {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.New("data block is not a member of the Merkle Tree")
+}
+
+// PaddedLeafCount returns the number of nodes at the leaf level, i.e. NumLeaves plus the padding leaves
+// of PaddingIndexed, or plus the padding leaf appended to an odd leaf level, except with PaddingPromote,
+// which does not pad the odd levels. The padding leaf of an odd level is only a sibling in the proof
+// of the last leaf, it has no proof of its own, while ProofOf returns the proofs of the padding leaves
+// of PaddingIndexed.
+func (m *MerkleTree) PaddedLeafCount() int {
+	n := m.treeLeafCount()
+	if m.PaddingStrategy == PaddingPromote {
+		return n
+	}
+	return n + n&1
+}
+
+// treeLeafCount returns the number of leaves of the tree levels once the tree is built, i.e. NumLeaves
+// plus the padding leaves of PaddingIndexed, before the padding of an odd leaf level.
+func (m *MerkleTree) treeLeafCount() int {
+	return m.NumLeaves + m.numPaddingLeaves
 }
 
 // Proof generates the Merkle proof for a data block with the Merkle Tree structure generated beforehand.
 // The method is only available when the configuration mode is ModeTreeBuild or ModeProofGenAndTreeBuild.
 // In ModeProofGen, proofs for all the data blocks are already generated, and the Merkle Tree structure is not cached.
+//
+// Deprecated: Use GenerateProof, which is also available in ModeProofGen and implements ProofGenerator.
 func (m *MerkleTree) Proof(dataBlock DataBlock) (*Proof, error) {
-	if m.Mode != ModeTreeBuild && m.Mode != ModeProofGenAndTreeBuild {
-		return nil, errors.New("merkle Tree is not in built, could not generate proof by this method")
+	if m.Config.Mode != ModeTreeBuild && m.Config.Mode != ModeProofGenAndTreeBuild {
+		return nil, fmt.Errorf("merkle Tree is not built in %v, could not generate proof by this method", m.Config.Mode)
 	}
-	leaf, err := leafFromBlock(dataBlock, m.Config)
-	if err != nil {
-		return nil, err
-	}
-	val, ok := m.leafMap.Load(string(leaf))
-	if !ok {
-		return nil, errors.New("data block is not a member of the Merkle Tree")
+	if m.nodes == nil {
+		return nil, errors.New("merkle Tree nodes are not available, could not generate proof by this method")
 	}
+	return m.GenerateProof(dataBlock)
+}
+
+// proofFromNodes generates the proof for the leaf at the index from the cached tree nodes.
+func (m *MerkleTree) proofFromNodes(idx int) *Proof {
+	return m.proofFromLevels(idx, func(level int) []byte {
+		return m.nodes[level][(idx>>level)^1]
+	})
+}
+
+// proofFromLevels generates the proof for the leaf at the index from the sibling of its path at each level.
+func (m *MerkleTree) proofFromLevels(idx int, sibling func(level int) []byte) *Proof {
 	var (
-		idx      = val.(int)
 		path     uint32
-		siblings = make([][]byte, m.Depth)
+		siblings = make([][]byte, 0, m.Depth)
 	)
-	for i := uint32(0); i < m.Depth; i++ {
-		if idx&1 == 1 {
-			siblings[i] = m.nodes[i][idx-1]
-		} else {
-			path += 1 << i
-			siblings[i] = m.nodes[i][idx+1]
+	for _, level := range m.siblingLevels(idx) {
+		if (idx>>level)&1 == 0 {
+			path += 1 << len(siblings)
 		}
-		idx >>= 1
+		siblings = append(siblings, sibling(level))
 	}
 	return &Proof{
-		Path:     path,
-		Siblings: siblings,
-	}, nil
+		Path:        path,
+		Siblings:    siblings,
+		Index:       idx,
+		Fingerprint: m.fingerprint,
+	}
 }
 
-func (m *MerkleTree) Restore(config *Config) {
-	m.Config = config
+// ProofOf returns the proof of the leaf at the index, i.e. of the data block at the index of the input,
+// unless duplicate leaves are collapsed, in which case the leaf index is returned by LeafIndexOf.
+// The proof is Proofs[i] if the proofs are generated, otherwise it is generated from the tree nodes in ModeTreeBuild.
+// With PaddingIndexed, the indexes from NumLeaves to PaddedLeafCount are those of the padding leaves,
+// whose proofs are verified by VerifyPadding.
+// It returns an error if the index is out of range, or if the tree has neither proofs nor nodes.
+func (m *MerkleTree) ProofOf(i int) (*Proof, error) {
+	if i < 0 || i >= m.treeLeafCount() {
+		return nil, fmt.Errorf("leaf index %d is out of range [0, %d)", i, m.treeLeafCount())
+	}
+	if proof := m.storedProof(i); proof != nil {
+		return proof, nil
+	}
+	if m.pruned {
+		return m.prunedProof(i)
+	}
+	if m.nodes != nil {
+		return m.proofFromNodes(i), nil
+	}
+	if m.Proofs != nil {
+		return nil, fmt.Errorf("proof of leaf %d is not selected by ProofIndices, and the Merkle Tree built in %v "+
+			"has no nodes to generate it from", i, m.Config.Mode)
+	}
+	return nil, errors.New("merkle Tree has neither proofs nor nodes, could not return the proof")
+}
 
-	// Hash function initialization.
-	if m.HashFunc == nil {
-		if m.RunInParallel {
-			m.HashFunc = defaultHashFuncParallel // Parallelized hash function must be concurrent safe.
-		} else {
-			m.HashFunc = defaultHashFunc
+// storedProof returns the generated proof of the leaf at the index, or nil if it is not generated.
+func (m *MerkleTree) storedProof(i int) *Proof {
+	if i >= m.NumLeaves {
+		if i-m.NumLeaves < len(m.paddingProofs) {
+			return m.paddingProofs[i-m.NumLeaves]
 		}
+		return nil
 	}
-	// Hash concatenation function initialization.
-	if m.concatFunc == nil {
-		if m.SortSiblingPairs {
-			m.concatFunc = concatSortHash
-		} else {
-			m.concatFunc = concatHash
+	if m.Proofs != nil {
+		return m.Proofs[i]
+	}
+	return nil
+}
+
+// siblingLevels returns the tree levels of the siblings of the proof of the leaf at the index, in proof order.
+// With PaddingPromote, the levels where the node of the leaf is promoted have no sibling.
+func (m *MerkleTree) siblingLevels(idx int) []int {
+	levels := make([]int, 0, m.Depth)
+	for level := 0; level < int(m.Depth); level++ {
+		levelLen := (m.NumLeaves + 1<<level - 1) >> level
+		if m.PaddingStrategy == PaddingPromote && (idx>>level)^1 >= levelLen {
+			continue
 		}
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// NodeAt returns the node at the level and index of the Merkle Tree, level 0 being the leaf level,
+// and the root being the only node at level Depth. The padding nodes appended to odd levels are included,
+// except with PaddingPromote, which does not pad the odd levels.
+// The tree nodes are required, so the Merkle Tree must be built in ModeTreeBuild or ModeProofGenAndTreeBuild.
+// If the tree is pruned, the pruned levels are recomputed.
+func (m *MerkleTree) NodeAt(level, index int) ([]byte, error) {
+	if m.nodes == nil {
+		return nil, errors.New("merkle Tree nodes are not available, could not get the node")
+	}
+	if level < 0 || level > int(m.Depth) {
+		return nil, fmt.Errorf("level %d is out of range [0, %d]", level, m.Depth)
+	}
+	if level == int(m.Depth) {
+		if index != 0 {
+			return nil, fmt.Errorf("index %d is out of range [0, 1) at the root level", index)
+		}
+		return m.Root, nil
+	}
+	nodes, err := m.treeNodes()
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(nodes[level]) || nodes[level][index] == nil {
+		return nil, fmt.Errorf("index %d is out of range at level %d", index, level)
+	}
+	return nodes[level][index], nil
+}
+
+// HashSize returns the size of the hash outputs in the Merkle Tree, or 0 if no hash has been computed,
+// e.g. for a restored Merkle Tree.
+func (m *MerkleTree) HashSize() int {
+	return int(m.hashSize.Load())
+}
+
+// ConfigSnapshot returns a copy of the configuration of the Merkle Tree, including the function fields.
+// Modifying the copy does not affect the Merkle Tree.
+func (m *MerkleTree) ConfigSnapshot() Config {
+	if m.Config == nil {
+		return Config{}
+	}
+	return *m.Config
+}
+
+func (m *MerkleTree) Restore(config *Config) {
+	m.Config = config
+	m.initConfig()
+	if m.EmbedFingerprint {
+		// Without a fingerprint, the proofs are verified without the configuration check.
+		m.fingerprint, _ = configFingerprint(m.Config)
 	}
 }
@@ -24,11 +24,17 @@ package merkletree
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha1"
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	mrand "math/rand"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/agiledragon/gomonkey/v2"
@@ -548,7 +554,7 @@ func TestMerkleTreeNew_proofGenAndTreeBuild(t *testing.T) {
 				return
 			}
 			for i := 0; i < len(tt.args.blocks); i++ {
-				if !reflect.DeepEqual(m.Proofs[i], m1.Proofs[i]) {
+				if !m.Proofs[i].Equal(m1.Proofs[i]) {
 					t.Errorf("proofs generated are wrong for block %d", i)
 					return
 				}
@@ -665,7 +671,7 @@ func TestMerkleTreeNew_proofGenAndTreeBuildParallel(t *testing.T) {
 				return
 			}
 			for i := 0; i < len(tt.args.blocks); i++ {
-				if !reflect.DeepEqual(m.Proofs[i], m1.Proofs[i]) {
+				if !m.Proofs[i].Equal(m1.Proofs[i]) {
 					t.Errorf("proofs generated are wrong for block %d", i)
 					return
 				}
@@ -674,42 +680,6 @@ func TestMerkleTreeNew_proofGenAndTreeBuildParallel(t *testing.T) {
 	}
 }
 
-func Test_dummyHash(t *testing.T) {
-	patches := gomonkey.NewPatches()
-	defer patches.Reset()
-	tests := []struct {
-		name    string
-		want    []byte
-		mock    func()
-		wantErr bool
-	}{
-		{
-			name: "test_dummy_hash_error",
-			mock: func() {
-				patches.ApplyFuncReturn(rand.Read, nil, errors.New("test_dummy_hash_error"))
-			},
-			want:    nil,
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.mock != nil {
-				tt.mock()
-			}
-			defer patches.Reset()
-			got, err := dummyHash()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("dummyHash() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("dummyHash() got = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func verifySetup(size int) (*MerkleTree, []DataBlock) {
 	blocks := dataBlocks(size)
 	m, err := New(nil, blocks)
@@ -925,7 +895,7 @@ func TestMerkleTree_Proof(t *testing.T) {
 				if tt.wantErr {
 					return
 				}
-				if !reflect.DeepEqual(got, m1.Proofs[idx]) && !tt.wantErr {
+				if !got.Equal(m1.Proofs[idx]) {
 					t.Errorf("Proof() %d got = %v, want %v", idx, got, m1.Proofs[idx])
 					return
 				}
@@ -943,6 +913,8 @@ func mockHashFunc(data []byte) ([]byte, error) {
 func TestMerkleTree_proofGen(t *testing.T) {
 	patches := gomonkey.NewPatches()
 	defer patches.Reset()
+	// failFiller makes the hash function fail on the NoDuplicates padding nodes.
+	var failFiller bool
 	type args struct {
 		config *Config
 		blocks []DataBlock
@@ -958,14 +930,17 @@ func TestMerkleTree_proofGen(t *testing.T) {
 			args: args{
 				config: &Config{
 					NoDuplicates: true,
+					HashFunc: func(data []byte) ([]byte, error) {
+						if failFiller && bytes.HasSuffix(data, []byte(noDuplicatesTag)) {
+							return nil, errors.New("test_no_duplicates_filler_err")
+						}
+						return DefaultHashFunc(data)
+					},
 				},
 				blocks: dataBlocks(5),
 			},
 			mock: func() {
-				patches.ApplyFunc(dummyHash,
-					func() ([]byte, error) {
-						return nil, errors.New("test_get_dummy_hash_err")
-					})
+				failFiller = true
 			},
 			wantErr: true,
 		},
@@ -1078,7 +1053,8 @@ func TestVerify(t *testing.T) {
 					},
 				},
 			},
-			want: false,
+			want:    false,
+			wantErr: true,
 		},
 		{
 			name: "test_proof_nil",
@@ -1214,8 +1190,7 @@ func Test_layeredHashHandler(t *testing.T) {
 					mt:         mt,
 					byteField1: [][]byte{[]byte("test_buf1"), []byte("test_buf1")},
 					byteField2: [][]byte{[]byte("test_buf2")},
-					intField2:  2,
-					intField3:  2,
+					batcher:    &pairBatcher{numPairs: 1, batchSize: 1},
 				},
 			},
 			wantErr: true,
@@ -1245,6 +1220,20 @@ func BenchmarkMerkleTreeNew(b *testing.B) {
 	}
 }
 
+func BenchmarkMerkleTreeNew_small(b *testing.B) {
+	for _, numBlocks := range []int{2, 4, 8, 32} {
+		testCases := dataBlocks(numBlocks)
+		b.Run(fmt.Sprintf("%d_blocks", numBlocks), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := New(nil, testCases); err != nil {
+					b.Errorf("Build() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkMerkleTreeNewParallel(b *testing.B) {
 	config := &Config{
 		RunInParallel: true,
@@ -1259,6 +1248,21 @@ func BenchmarkMerkleTreeNewParallel(b *testing.B) {
 	}
 }
 
+func BenchmarkMerkleTreeNewParallel_excessiveRoutines(b *testing.B) {
+	config := &Config{
+		RunInParallel: true,
+		NumRoutines:   100000,
+	}
+	testCases := dataBlocks(benchSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := New(config, testCases)
+		if err != nil {
+			b.Errorf("Build() error = %v", err)
+		}
+	}
+}
+
 func BenchmarkMerkleTreeBuild(b *testing.B) {
 	testCases := dataBlocks(benchSize)
 	config := &Config{
@@ -1287,3 +1291,913 @@ func BenchmarkMerkleTreeBuildParallel(b *testing.B) {
 		}
 	}
 }
+
+func TestNew_bindLeafIndex(t *testing.T) {
+	blocks := dataBlocks(6)
+	blocks[4] = &mock.DataBlock{Data: blocks[1].(*mock.DataBlock).Data}
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeProofGenAndTreeBuild} {
+		for _, parallel := range []bool{false, true} {
+			config := &Config{BindLeafIndex: true, Mode: mode, RunInParallel: parallel}
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if bytes.Equal(m.Leaves[1], m.Leaves[4]) {
+				t.Errorf("identical data blocks at different indexes have identical leaves")
+			}
+			for i, block := range blocks {
+				ok, err := Verify(block, m.Proofs[i], m.Root, &Config{BindLeafIndex: true})
+				if err != nil || !ok {
+					t.Errorf("Verify() = %v, %v, want true", ok, err)
+				}
+			}
+			// Claiming the identical block at index 4 to be at index 1 must fail.
+			moved := &Proof{Siblings: m.Proofs[4].Siblings, Path: m.Proofs[4].Path, Index: 1}
+			if ok, _ := Verify(blocks[4], moved, m.Root, &Config{BindLeafIndex: true}); ok {
+				t.Errorf("Verify() of a block at a wrong index = true, want false")
+			}
+		}
+	}
+	unbound, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(unbound.Leaves[1], unbound.Leaves[4]) {
+		t.Errorf("identical data blocks have different leaves without BindLeafIndex")
+	}
+	bound, err := New(&Config{BindLeafIndex: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	swapped := make([]DataBlock, len(blocks))
+	copy(swapped, blocks)
+	swapped[0], swapped[2] = swapped[2], swapped[0]
+	swappedTree, err := New(&Config{BindLeafIndex: true}, swapped)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if bytes.Equal(bound.Root, swappedTree.Root) {
+		t.Errorf("swapping two leaves does not change the root")
+	}
+	built, err := New(&Config{BindLeafIndex: true, Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := built.Proof(blocks[0]); err == nil {
+		t.Errorf("Proof() error = nil, want error when BindLeafIndex is set")
+	}
+}
+
+func TestNew_inconsistentHashSize(t *testing.T) {
+	// varyingHashFunc returns outputs of the input size modulo 3 plus one byte.
+	varyingHashFunc := func(data []byte) ([]byte, error) {
+		digest := sha256.Sum256(data)
+		return digest[:len(data)%3+1], nil
+	}
+	tests := []struct {
+		name   string
+		config *Config
+		blocks []DataBlock
+	}{
+		{
+			name:   "test_varying_leaf_size",
+			config: &Config{HashFunc: varyingHashFunc},
+			blocks: []DataBlock{
+				&mock.DataBlock{Data: []byte("a")},
+				&mock.DataBlock{Data: []byte("bb")},
+			},
+		},
+		{
+			name:   "test_varying_leaf_size_parallel",
+			config: &Config{HashFunc: varyingHashFunc, RunInParallel: true, NumRoutines: 2},
+			blocks: []DataBlock{
+				&mock.DataBlock{Data: []byte("a")},
+				&mock.DataBlock{Data: []byte("bb")},
+				&mock.DataBlock{Data: []byte("c")},
+			},
+		},
+		{
+			name: "test_varying_node_size_tree_build",
+			config: &Config{
+				HashFunc: func(data []byte) ([]byte, error) {
+					if len(data) > 32 {
+						return data[:16], nil
+					}
+					return DefaultHashFunc(data)
+				},
+				Mode: ModeTreeBuild,
+			},
+			blocks: dataBlocks(4),
+		},
+		{
+			name: "test_empty_output",
+			config: &Config{
+				HashFunc: func([]byte) ([]byte, error) {
+					return []byte{}, nil
+				},
+			},
+			blocks: dataBlocks(4),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.config, tt.blocks); !errors.Is(err, ErrInconsistentHashSize) {
+				t.Errorf("New() error = %v, want %v", err, ErrInconsistentHashSize)
+			}
+		})
+	}
+	m, err := New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := m.hashSize.Load(); got != defaultHashLen {
+		t.Errorf("hash size = %d, want %d", got, defaultHashLen)
+	}
+}
+
+func TestHashLeaves(t *testing.T) {
+	blocks := dataBlocks(7)
+	configs := []Config{
+		{},
+		{RunInParallel: true, NumRoutines: 3},
+		{DisableLeafHashing: true},
+		{BindLeafIndex: true, RunInParallel: true},
+		{NoDuplicates: true},
+	}
+	for _, config := range configs {
+		treeConfig, leafConfig := config, config
+		m, err := New(&treeConfig, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		leaves, err := HashLeaves(&leafConfig, blocks)
+		if err != nil {
+			t.Fatalf("HashLeaves() error = %v", err)
+		}
+		if len(leaves) != len(blocks) {
+			t.Fatalf("HashLeaves() returned %d leaves, want %d", len(leaves), len(blocks))
+		}
+		for i := range leaves {
+			if !bytes.Equal(leaves[i], m.Leaves[i]) {
+				t.Errorf("HashLeaves()[%d] = %x, want %x", i, leaves[i], m.Leaves[i])
+			}
+		}
+		// The configuration of the caller is not modified, as with New.
+		if !reflect.DeepEqual(leafConfig, config) {
+			t.Errorf("HashLeaves() modified the configuration to %+v, want %+v", leafConfig, config)
+		}
+	}
+	if _, err := HashLeaves(nil, nil); err == nil {
+		t.Errorf("HashLeaves() error = nil, want error for no data blocks")
+	}
+	for _, parallel := range []bool{false, true} {
+		config := &Config{
+			RunInParallel: parallel,
+			HashFunc: func([]byte) ([]byte, error) {
+				return nil, errors.New("test_hash_func_err")
+			},
+		}
+		if _, err := HashLeaves(config, blocks); err == nil {
+			t.Errorf("HashLeaves() error = nil, want hash function error (parallel: %v)", parallel)
+		}
+	}
+}
+
+func TestNew_hashCallCount(t *testing.T) {
+	for _, numLeaves := range []int{2, 3, 5, 8, 13, 100} {
+		blocks := dataBlocks(numLeaves)
+		// Each level is padded to an even length, and every pair is hashed into one parent node.
+		wantNodeHashes := 0
+		for n := numLeaves; n > 1; wantNodeHashes += n {
+			n = (n + 1) >> 1
+		}
+		for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+			for _, parallel := range []bool{false, true} {
+				var leafHashes, nodeHashes atomic.Int64
+				config := &Config{
+					Mode:          mode,
+					RunInParallel: parallel,
+					NumRoutines:   4,
+					HashFunc: func(data []byte) ([]byte, error) {
+						// Data blocks are 100 bytes long, internal nodes hash two 32-byte children.
+						if len(data) == 2*sha256.Size {
+							nodeHashes.Add(1)
+						} else {
+							leafHashes.Add(1)
+						}
+						h := sha256.Sum256(data)
+						return h[:], nil
+					},
+				}
+				if _, err := New(config, blocks); err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				if got := leafHashes.Load(); got != int64(numLeaves) {
+					t.Errorf("leaves: %d, mode: %d, parallel: %v: leaf hash calls = %d, want %d",
+						numLeaves, mode, parallel, got, numLeaves)
+				}
+				if got := nodeHashes.Load(); got != int64(wantNodeHashes) {
+					t.Errorf("leaves: %d, mode: %d, parallel: %v: node hash calls = %d, want %d",
+						numLeaves, mode, parallel, got, wantNodeHashes)
+				}
+			}
+		}
+	}
+}
+
+func TestProof_Equal(t *testing.T) {
+	proof := func() *Proof {
+		return &Proof{Siblings: [][]byte{{1, 2}, {3, 4}}, Path: 2, Index: 1}
+	}
+	differentSibling := proof()
+	differentSibling.Siblings[1] = []byte{3, 5}
+	fewerSiblings := proof()
+	fewerSiblings.Siblings = fewerSiblings.Siblings[:1]
+	differentPath := proof()
+	differentPath.Path = 1
+	differentIndex := proof()
+	differentIndex.Index = 2
+	tests := []struct {
+		name  string
+		proof *Proof
+		other *Proof
+		want  bool
+	}{
+		{"equal", proof(), proof(), true},
+		{"same_proof", differentPath, differentPath, true},
+		{"differing_sibling", proof(), differentSibling, false},
+		{"differing_sibling_count", proof(), fewerSiblings, false},
+		{"differing_direction", proof(), differentPath, false},
+		{"differing_index", proof(), differentIndex, false},
+		{"nil_other", proof(), nil, false},
+		{"nil_proof", nil, proof(), false},
+		{"both_nil", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.proof.Equal(tt.other); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeConfigMode_String(t *testing.T) {
+	tests := []struct {
+		mode TypeConfigMode
+		want string
+	}{
+		{ModeProofGen, "ModeProofGen"},
+		{ModeTreeBuild, "ModeTreeBuild"},
+		{ModeProofGenAndTreeBuild, "ModeProofGenAndTreeBuild"},
+		{TypeConfigMode(7), "TypeConfigMode(7)"},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.String(); got != tt.want {
+			t.Errorf("String() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestMerkleTree_introspection(t *testing.T) {
+	hashFunc := func(data []byte) ([]byte, error) {
+		h := sha256.Sum224(data)
+		return h[:], nil
+	}
+	config := &Config{Mode: ModeTreeBuild, HashFunc: hashFunc, SortSiblingPairs: true}
+	m, err := New(config, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := m.Mode; got != ModeTreeBuild {
+		t.Errorf("Mode = %v, want %v", got, ModeTreeBuild)
+	}
+	if got := m.HashSize(); got != sha256.Size224 {
+		t.Errorf("HashSize() = %d, want %d", got, sha256.Size224)
+	}
+	snapshot := m.ConfigSnapshot()
+	if snapshot.Mode != ModeTreeBuild || !snapshot.SortSiblingPairs || snapshot.HashFunc == nil {
+		t.Errorf("ConfigSnapshot() = %+v, want the configuration of the tree", snapshot)
+	}
+	snapshot.Mode = ModeProofGen
+	if m.Mode != ModeTreeBuild {
+		t.Errorf("modifying the snapshot modified the tree configuration")
+	}
+	m, err = New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, err = m.Proof(dataBlocks(1)[0])
+	if err == nil || !strings.Contains(err.Error(), "ModeProofGen") {
+		t.Errorf("Proof() error = %v, want error naming ModeProofGen", err)
+	}
+}
+
+func TestNew_numWorkers(t *testing.T) {
+	blocks := dataBlocks(33)
+	serial, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	maxProcs := runtime.GOMAXPROCS(0)
+	for _, numRoutines := range []int{1, maxProcs, 100000} {
+		config := &Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, NumRoutines: numRoutines}
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		wantWorkers := numRoutines
+		if wantWorkers > maxProcs {
+			wantWorkers = maxProcs
+		}
+		if m.numWorkers != wantWorkers {
+			t.Errorf("NumRoutines %d: number of workers = %d, want %d", numRoutines, m.numWorkers, wantWorkers)
+		}
+		if config.NumRoutines != numRoutines {
+			t.Errorf("NumRoutines modified to %d, want %d", config.NumRoutines, numRoutines)
+		}
+		if !bytes.Equal(m.Root, serial.Root) {
+			t.Errorf("NumRoutines %d: root differs from the serial build", numRoutines)
+		}
+		for i := range blocks {
+			if !m.Proofs[i].Equal(serial.Proofs[i]) {
+				t.Errorf("NumRoutines %d: proof %d differs from the serial build", numRoutines, i)
+			}
+		}
+	}
+}
+
+func TestVerifyAny(t *testing.T) {
+	var hashCalls atomic.Int64
+	config := &Config{
+		HashFunc: func(data []byte) ([]byte, error) {
+			hashCalls.Add(1)
+			return DefaultHashFunc(data)
+		},
+	}
+	blocks := dataBlocks(9)
+	previous, err := New(config, dataBlocks(9))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	current, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name  string
+		roots [][]byte
+		want  int
+	}{
+		{"current_root", [][]byte{current.Root}, 0},
+		{"rotation", [][]byte{previous.Root, current.Root}, 1},
+		{"duplicate_roots", [][]byte{current.Root, current.Root}, 0},
+		{"no_match", [][]byte{previous.Root, make([]byte, defaultHashLen)}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashCalls.Store(0)
+			got, err := VerifyAny(blocks[3], current.Proofs[3], tt.roots, config)
+			if err != nil {
+				t.Fatalf("VerifyAny() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyAny() = %d, want %d", got, tt.want)
+			}
+			// The root is computed once: one leaf hash and one hash for each sibling.
+			if want := int64(1 + len(current.Proofs[3].Siblings)); hashCalls.Load() != want {
+				t.Errorf("VerifyAny() hash calls = %d, want %d", hashCalls.Load(), want)
+			}
+		})
+	}
+	if _, err := VerifyAny(blocks[3], current.Proofs[3], nil, config); err == nil {
+		t.Errorf("VerifyAny() error = nil, want error for no candidate roots")
+	}
+}
+
+func TestVerify_oddNodeStrategies(t *testing.T) {
+	blocks := dataBlocks(3)
+	leaves := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		data, _ := block.Serialize()
+		leaves[i], _ = DefaultHashFunc(data)
+	}
+	hashPair := func(left, right []byte) []byte {
+		h, _ := DefaultHashFunc(concatHash(left, right))
+		return h
+	}
+	// Promotion: the odd leaf c is promoted to the next level, root = H(H(a || b) || c).
+	ab := hashPair(leaves[0], leaves[1])
+	promotionRoot := hashPair(ab, leaves[2])
+	promotionProofs := []*Proof{
+		{Siblings: [][]byte{leaves[1], leaves[2]}, Path: 0b11, Index: 0},
+		{Siblings: [][]byte{leaves[0], leaves[2]}, Path: 0b10, Index: 1},
+		{Siblings: [][]byte{ab}, Path: 0b0, Index: 2},
+	}
+	for i, proof := range promotionProofs {
+		if ok, err := Verify(blocks[i], proof, promotionRoot, nil); err != nil || !ok {
+			t.Errorf("Verify() of the promotion proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+	// Duplication: the odd leaf c is paired with itself, as built by New.
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if want := hashPair(ab, hashPair(leaves[2], leaves[2])); !bytes.Equal(m.Root, want) {
+		t.Fatalf("New() root = %x, want the duplication root %x", m.Root, want)
+	}
+	for i, block := range blocks {
+		if ok, err := Verify(block, m.Proofs[i], m.Root, nil); err != nil || !ok {
+			t.Errorf("Verify() of the duplication proof %d = %v, %v, want true", i, ok, err)
+		}
+		if ok, _ := Verify(block, m.Proofs[i], promotionRoot, nil); ok {
+			t.Errorf("Verify() of the duplication proof %d against the promotion root = true, want false", i)
+		}
+	}
+}
+
+func TestVerify_processesAllLevels(t *testing.T) {
+	blocks := dataBlocks(64)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var calls atomic.Int64
+	config := &Config{HashFunc: func(data []byte) ([]byte, error) {
+		calls.Add(1)
+		return DefaultHashFunc(data)
+	}}
+	tampered := *m.Proofs[3]
+	tampered.Siblings = append([][]byte{}, tampered.Siblings...)
+	tampered.Siblings[0] = bytes.Repeat([]byte{0xff}, defaultHashLen)
+	for _, proof := range []*Proof{m.Proofs[3], &tampered} {
+		calls.Store(0)
+		ok, err := Verify(blocks[3], proof, m.Root, config)
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if want := proof == m.Proofs[3]; ok != want {
+			t.Errorf("Verify() = %v, want %v", ok, want)
+		}
+		// One leaf hash and one hash for each level, even when the first level diverges.
+		if got, want := calls.Load(), int64(1+len(proof.Siblings)); got != want {
+			t.Errorf("hash calls = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestNew_expectLeafSize(t *testing.T) {
+	oddBlocks := dataBlocks(10)
+	oddBlocks[7] = &mock.DataBlock{Data: make([]byte, 99)}
+	tests := []struct {
+		name    string
+		config  *Config
+		blocks  []DataBlock
+		wantErr bool
+	}{
+		{"uniform", &Config{ExpectLeafSize: 100}, dataBlocks(10), false},
+		{"uniform_parallel", &Config{ExpectLeafSize: 100, RunInParallel: true}, dataBlocks(10), false},
+		{"any_size", &Config{}, oddBlocks, false},
+		{"odd_sized", &Config{ExpectLeafSize: 100}, oddBlocks, true},
+		{"odd_sized_parallel", &Config{ExpectLeafSize: 100, RunInParallel: true, NumRoutines: 3}, oddBlocks, true},
+		{"odd_sized_tree_build", &Config{ExpectLeafSize: 100, Mode: ModeTreeBuild}, oddBlocks, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, tt.blocks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			if !errors.Is(err, ErrUnexpectedLeafSize) {
+				t.Errorf("New() error = %v, want %v", err, ErrUnexpectedLeafSize)
+			}
+			if !strings.Contains(err.Error(), "leaf index 7 ") {
+				t.Errorf("New() error = %v, want the offending leaf index 7", err)
+			}
+		})
+	}
+	m, err := New(&Config{ExpectLeafSize: 100, Mode: ModeTreeBuild}, dataBlocks(10))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = m.UpdateBatch(map[int]DataBlock{3: oddBlocks[7]}); !errors.Is(err, ErrUnexpectedLeafSize) {
+		t.Errorf("UpdateBatch() error = %v, want %v", err, ErrUnexpectedLeafSize)
+	}
+}
+
+func TestConfig_OnNode(t *testing.T) {
+	blocks := dataBlocks(21)
+	type node struct {
+		level, index int
+		hash         []byte
+	}
+	collect := func(t *testing.T, config *Config) (*MerkleTree, []node) {
+		var nodes []node
+		config.OnNode = func(level, index int, hash []byte) {
+			nodes = append(nodes, node{level, index, hash})
+		}
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		return m, nodes
+	}
+	ref, refNodes := collect(t, &Config{Mode: ModeTreeBuild})
+	// 21 leaves: 11 + 6 + 3 + 2 computed nodes over the padded levels of 22, 12, 6 and 4 nodes, and the root.
+	if len(refNodes) != 11+6+3+2+1 {
+		t.Fatalf("OnNode calls = %d, want %d", len(refNodes), 11+6+3+2+1)
+	}
+	for i, n := range refNodes {
+		if i > 0 {
+			prev := refNodes[i-1]
+			if n.level < prev.level || n.level == prev.level && n.index <= prev.index {
+				t.Errorf("node (%d, %d) reported after (%d, %d)", n.level, n.index, prev.level, prev.index)
+			}
+		}
+		want, err := ref.NodeAt(n.level, n.index)
+		if err != nil {
+			t.Fatalf("NodeAt() error = %v", err)
+		}
+		if !bytes.Equal(n.hash, want) {
+			t.Errorf("node (%d, %d) = %x, want NodeAt() = %x", n.level, n.index, n.hash, want)
+		}
+	}
+	if last := refNodes[len(refNodes)-1]; last.level != int(ref.Depth) || !bytes.Equal(last.hash, ref.Root) {
+		t.Errorf("last node = (%d, %d), want the root at level %d", last.level, last.index, ref.Depth)
+	}
+	for _, config := range []*Config{
+		{Mode: ModeTreeBuild, RunInParallel: true, NumRoutines: 4},
+		{Mode: ModeProofGen},
+		{Mode: ModeProofGen, RunInParallel: true, NumRoutines: 3},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+	} {
+		_, nodes := collect(t, config)
+		if len(nodes) != len(refNodes) {
+			t.Fatalf("%v: OnNode calls = %d, want %d", config.Mode, len(nodes), len(refNodes))
+		}
+		for _, n := range nodes {
+			want, _ := ref.NodeAt(n.level, n.index)
+			if !bytes.Equal(n.hash, want) {
+				t.Errorf("%v: node (%d, %d) = %x, want %x", config.Mode, n.level, n.index, n.hash, want)
+			}
+		}
+	}
+}
+
+func TestMerkleTree_NodeAt(t *testing.T) {
+	m, err := New(&Config{Mode: ModeTreeBuild}, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if node, err := m.NodeAt(0, 5); err != nil || !bytes.Equal(node, m.Leaves[4]) {
+		t.Errorf("NodeAt(0, 5) = %x, %v, want the padding node %x", node, err, m.Leaves[4])
+	}
+	if root, err := m.NodeAt(int(m.Depth), 0); err != nil || !bytes.Equal(root, m.Root) {
+		t.Errorf("NodeAt(Depth, 0) = %x, %v, want the root %x", root, err, m.Root)
+	}
+	for _, pos := range [][2]int{{-1, 0}, {int(m.Depth) + 1, 0}, {int(m.Depth), 1}, {0, -1}, {0, 6}, {1, 4}} {
+		if _, err := m.NodeAt(pos[0], pos[1]); err == nil {
+			t.Errorf("NodeAt(%d, %d) error = nil", pos[0], pos[1])
+		}
+	}
+	if m, err = New(nil, dataBlocks(5)); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := m.NodeAt(0, 0); err == nil {
+		t.Error("NodeAt() in ModeProofGen: error = nil")
+	}
+}
+
+func TestConfig_RootBytes(t *testing.T) {
+	blocks := dataBlocks(9)
+	full, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, config := range []*Config{
+		{RootBytes: 20},
+		{RootBytes: 20, RunInParallel: true},
+		{RootBytes: 20, Mode: ModeTreeBuild},
+		{RootBytes: 20, Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if !bytes.Equal(m.Root, full.Root[:20]) {
+			t.Fatalf("%v: Root = %x, want the 20-byte prefix of %x", config.Mode, m.Root, full.Root)
+		}
+		for i, block := range blocks {
+			proof, err := m.Proof(block)
+			if m.Proofs != nil {
+				proof, err = m.Proofs[i], nil
+			}
+			if err != nil {
+				t.Fatalf("Proof() error = %v", err)
+			}
+			if ok, err := Verify(block, proof, m.Root, config); err != nil || !ok {
+				t.Errorf("Verify() with the truncated root = %v, %v, want true", ok, err)
+			}
+			if idx, err := VerifyAny(block, proof, [][]byte{full.Root, m.Root}, config); err != nil || idx != 1 {
+				t.Errorf("VerifyAny() = %d, %v, want 1", idx, err)
+			}
+			if ok, _ := Verify(block, proof, m.Root, nil); ok {
+				t.Error("Verify() of the truncated root without RootBytes = true, want false")
+			}
+			if ok, _ := Verify(block, proof, full.Root, config); ok {
+				t.Error("Verify() of the full root with RootBytes = true, want false")
+			}
+			wrong := append([]byte{}, m.Root...)
+			wrong[19] ^= 1
+			if ok, _ := Verify(block, proof, wrong, config); ok {
+				t.Error("Verify() of a wrong truncated root = true, want false")
+			}
+		}
+	}
+	m, err := New(&Config{RootBytes: 20, Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = m.UpdateBatch(map[int]DataBlock{2: blocks[3]}); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	if len(m.Root) != 20 {
+		t.Errorf("len(Root) after UpdateBatch() = %d, want 20", len(m.Root))
+	}
+	if m, err = New(&Config{RootBytes: 64}, blocks); err != nil || !bytes.Equal(m.Root, full.Root) {
+		t.Errorf("New() with RootBytes larger than the hash size: Root = %x, %v, want %x", m.Root, err, full.Root)
+	}
+	if _, err = New(&Config{RootBytes: -1}, blocks); err == nil {
+		t.Error("New() with negative RootBytes: error = nil")
+	}
+}
+
+func TestConfig_LeafTransform(t *testing.T) {
+	tag := []byte("tag:")
+	prependTag := func(data []byte) ([]byte, error) {
+		return append(append([]byte{}, tag...), data...), nil
+	}
+	blocks := dataBlocks(11)
+	tagged := make([]DataBlock, len(blocks))
+	for i, block := range blocks {
+		data, _ := block.Serialize()
+		tagged[i] = &mock.DataBlock{Data: append(append([]byte{}, tag...), data...)}
+	}
+	for _, base := range []Config{
+		{},
+		{RunInParallel: true},
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+		{BindLeafIndex: true},
+		{DisableLeafHashing: true},
+		{Hasher: sha256.New},
+	} {
+		want, err := New(&base, tagged)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		config := base
+		config.LeafTransform = prependTag
+		m, err := New(&config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if !bytes.Equal(m.Root, want.Root) {
+			t.Fatalf("%+v: Root = %x, want the root %x of the tagged data blocks", base, m.Root, want.Root)
+		}
+		for i, block := range blocks {
+			proof, err := m.Proof(block)
+			if m.Proofs != nil {
+				proof, err = m.Proofs[i], nil
+			}
+			if err != nil {
+				t.Fatalf("Proof() error = %v", err)
+			}
+			if ok, err := Verify(block, proof, m.Root, &config); err != nil || !ok {
+				t.Errorf("Verify() with the transform = %v, %v, want true", ok, err)
+			}
+			if ok, err := Verify(tagged[i], proof, m.Root, &base); err != nil || !ok {
+				t.Errorf("Verify() of the tagged data block without the transform = %v, %v, want true", ok, err)
+			}
+			if ok, _ := Verify(block, proof, m.Root, &base); ok {
+				t.Error("Verify() without the transform = true, want false")
+			}
+		}
+	}
+
+	errTransform := errors.New("transform error")
+	failing := &Config{LeafTransform: func([]byte) ([]byte, error) { return nil, errTransform }}
+	if _, err := New(failing, blocks); !errors.Is(err, errTransform) {
+		t.Errorf("New() with a failing transform: error = %v, want %v", err, errTransform)
+	}
+	m, err := New(&Config{LeafTransform: prependTag, Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want, err := New(&Config{Mode: ModeTreeBuild}, tagged)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = m.UpdateBatch(map[int]DataBlock{4: blocks[7]}); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	if err = want.UpdateBatch(map[int]DataBlock{4: tagged[7]}); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	if !bytes.Equal(m.Root, want.Root) {
+		t.Errorf("Root after UpdateBatch() = %x, want %x", m.Root, want.Root)
+	}
+	if _, err = m.MarshalJSON(); err == nil {
+		t.Error("MarshalJSON() with a transform: error = nil")
+	}
+}
+
+func TestVerify_hashSizeMismatch(t *testing.T) {
+	sha1HashFunc := func(data []byte) ([]byte, error) {
+		sum := sha1.Sum(data)
+		return sum[:], nil
+	}
+	blocks := dataBlocks(5)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, err = Verify(blocks[0], m.Proofs[0], m.Root, &Config{HashFunc: sha1HashFunc})
+	if !errors.Is(err, ErrInconsistentHashSize) {
+		t.Fatalf("Verify() with a 20-byte hash function: error = %v, want %v", err, ErrInconsistentHashSize)
+	}
+	if want := "sibling at level 0 is 32 bytes but hash outputs 20 bytes"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Verify() error = %q, want it to contain %q", err, want)
+	}
+
+	var calls atomic.Int64
+	alternatingHashFunc := func(data []byte) ([]byte, error) {
+		if calls.Add(1)%2 == 0 {
+			return sha1HashFunc(data)
+		}
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}
+	config := &Config{HashFunc: alternatingHashFunc, DisableLeafHashing: true}
+	_, err = Verify(blocks[0], m.Proofs[0], m.Root, config)
+	if !errors.Is(err, ErrInconsistentHashSize) {
+		t.Errorf("Verify() with inconsistent hash outputs: error = %v, want %v", err, ErrInconsistentHashSize)
+	}
+
+	// With NoDuplicates, the padding nodes are of the hash size.
+	config = &Config{HashFunc: sha1HashFunc, NoDuplicates: true}
+	if m, err = New(config, blocks); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i, block := range blocks {
+		if ok, err := Verify(block, m.Proofs[i], m.Root, config); err != nil || !ok {
+			t.Errorf("Verify() of a 20-byte hash tree with NoDuplicates = %v, %v, want true", ok, err)
+		}
+	}
+}
+
+func TestMerkleTree_ProofOf(t *testing.T) {
+	// Proofs[i] is the proof of blocks[i] for random sizes, in every mode and parallelization setting.
+	rng := mrand.New(mrand.NewSource(1))
+	for iter := 0; iter < 20; iter++ {
+		numBlocks := 2 + rng.Intn(300)
+		blocks := dataBlocks(numBlocks)
+		for _, variant := range consistencyVariants() {
+			config := &Config{Mode: variant.mode, RunInParallel: variant.parallel, NumRoutines: variant.numRoutines}
+			name := fmt.Sprintf("%d blocks/%v", numBlocks, variant)
+			m, err := newWithContext(context.Background(), config, blocks, variant.numRoutines)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if variant.mode == ModeTreeBuild && m.Proofs != nil {
+				t.Errorf("%s: Proofs = %v, want nil", name, m.Proofs)
+			}
+			for i, block := range blocks {
+				proof, err := m.ProofOf(i)
+				if err != nil {
+					t.Fatalf("%s: ProofOf(%d) error = %v", name, i, err)
+				}
+				if m.Proofs != nil && proof != m.Proofs[i] {
+					t.Errorf("%s: ProofOf(%d) is not Proofs[%d]", name, i, i)
+				}
+				if proof.Index != i {
+					t.Errorf("%s: ProofOf(%d).Index = %d", name, i, proof.Index)
+				}
+				if ok, err := m.Verify(block, proof); err != nil || !ok {
+					t.Errorf("%s: Verify() of block %d with ProofOf(%d) = %v, %v, want true", name, i, i, ok, err)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTree_ProofOf_invalid(t *testing.T) {
+	m, err := New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, i := range []int{-1, 5} {
+		if _, err := m.ProofOf(i); err == nil {
+			t.Errorf("ProofOf(%d) error = nil, want error", i)
+		}
+	}
+	if _, err := (&MerkleTree{Config: &Config{}, NumLeaves: 2}).ProofOf(0); err == nil {
+		t.Errorf("ProofOf() without proofs and nodes error = nil, want error")
+	}
+}
+
+func TestVerifyReturningLeaf(t *testing.T) {
+	configs := []*Config{
+		nil,
+		{BindLeafIndex: true},
+		{LeafTransform: func(data []byte) ([]byte, error) { return append([]byte("prefix"), data...), nil }},
+		{DisableLeafHashing: true},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+	}
+	blocks := dataBlocks(7)
+	for _, config := range configs {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, proof := range m.Proofs {
+			ok, leaf, err := VerifyReturningLeaf(blocks[i], proof, m.Root, m.Config)
+			if err != nil || !ok {
+				t.Fatalf("VerifyReturningLeaf() of block %d = %v, %v, want true", i, ok, err)
+			}
+			if !bytes.Equal(leaf, m.Leaves[i]) {
+				t.Errorf("VerifyReturningLeaf() leaf %d = %x, want %x", i, leaf, m.Leaves[i])
+			}
+		}
+		ok, leaf, err := VerifyReturningLeaf(blocks[1], m.Proofs[2], m.Root, m.Config)
+		if err != nil || ok || leaf != nil {
+			t.Errorf("VerifyReturningLeaf() with a wrong proof = %v, %x, %v, want false, nil, nil", ok, leaf, err)
+		}
+	}
+	if _, _, err := VerifyReturningLeaf(nil, &Proof{}, nil, nil); err == nil {
+		t.Errorf("VerifyReturningLeaf() of a nil data block error = nil, want error")
+	}
+}
+
+func TestConfig_ParanoidHashInput(t *testing.T) {
+	// scrubHashFunc zeroes its input after hashing it, e.g. to wipe sensitive data, modifying the data blocks.
+	scrubHashFunc := func(data []byte) ([]byte, error) {
+		sum := sha256.Sum256(data)
+		for i := range data {
+			data[i] = 0
+		}
+		return sum[:], nil
+	}
+	newBlocks := func() []DataBlock {
+		blocks := dataBlocks(9)
+		blocks[5] = blocks[2] // The data block is serialized and hashed twice.
+		return blocks
+	}
+	tests := []struct {
+		name     string
+		paranoid bool
+		parallel bool
+	}{
+		{"serial", false, false},
+		{"parallel", false, true},
+		{"serial paranoid", true, false},
+		{"parallel paranoid", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := newBlocks()
+			ref, err := New(nil, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			config := &Config{
+				HashFunc:          scrubHashFunc,
+				ParanoidHashInput: tt.paranoid,
+				RunInParallel:     tt.parallel,
+				NumRoutines:       2,
+			}
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := bytes.Equal(m.Root, ref.Root); got != tt.paranoid {
+				t.Fatalf("Root equal to the reference root = %v, want %v", got, tt.paranoid)
+			}
+			if !tt.paranoid {
+				return
+			}
+			for i, block := range blocks {
+				if ok, err := Verify(block, m.Proofs[i], m.Root, config); err != nil || !ok {
+					t.Errorf("Verify() of block %d = %v, %v, want true", i, ok, err)
+				}
+			}
+			if ok, err := m.Verify(blocks[2], m.Proofs[2]); err != nil || !ok {
+				t.Errorf("Verify() of block 2 again = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"io"
+)
+
+// defaultStreamChunkSize is the number of bytes written to the Hasher at a time when streaming a data block,
+// if ChunkSize is not set.
+const defaultStreamChunkSize = 1 << 20
+
+// MmapBlock is a data block read from a source of known size, e.g. a memory-mapped or a large file,
+// whose serialization is too large to be held in memory.
+// If the Hasher is set, the leaf is computed by streaming the reader into the Hasher in writes of at most
+// ChunkSize bytes, or 1 MiB if ChunkSize is not set, without buffering the whole data block,
+// and Serialize is not called.
+// The size is checked against ExpectLeafSize before reading, and against the number of bytes read.
+// The data block is serialized as usual if the Hasher is not set, or if DisableLeafHashing, LeafTransform
+// or AdditionalHashFuncs are set, as they need the whole serialized data block.
+// OpenReader is called once per leaf computation, e.g. once by the tree building and once by each Verify,
+// and concurrently for different data blocks if RunInParallel is true. The reader is closed after the leaf is computed.
+type MmapBlock interface {
+	DataBlock
+	OpenReader() (io.ReadCloser, int64, error)
+}
+
+// streamedBlock returns the data block as an MmapBlock if its leaf is computed by streaming with the configuration.
+func streamedBlock(block DataBlock, config *Config) (MmapBlock, bool) {
+	if config.Hasher == nil || config.DisableLeafHashing || config.LeafTransform != nil ||
+		len(config.AdditionalHashFuncs) > 0 {
+		return nil, false
+	}
+	streamed, ok := block.(MmapBlock)
+	return streamed, ok
+}
+
+// streamedLeaf computes the leaf of the data block at the leaf index by streaming, observed by OnAnomaly.
+func (m *MerkleTree) streamedLeaf(block MmapBlock, idx int) ([]byte, error) {
+	var (
+		start       = m.anomalyStart()
+		size  int64 = -1
+	)
+	leaf, err := hashStreamedBlock(block, idx, m.Config, func(n int64) {
+		size = n
+		m.checkLarge(idx, n)
+	})
+	m.checkSlow(AnomalySlowHash, idx, start, size)
+	return leaf, err
+}
+
+// hashStreamedBlock computes the leaf of the data block at the leaf index by streaming its reader into the Hasher.
+// If opened is not nil, it is called with the size of the data block before reading.
+func hashStreamedBlock(block MmapBlock, idx int, config *Config, opened func(size int64)) (leaf []byte, err error) {
+	r, size, err := block.OpenReader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := r.Close(); err == nil && closeErr != nil {
+			leaf, err = nil, closeErr
+		}
+	}()
+	if size < 0 {
+		return nil, fmt.Errorf("data block at leaf index %d has invalid size %d", idx, size)
+	}
+	if opened != nil {
+		opened(size)
+	}
+	if config.ExpectLeafSize > 0 && size != int64(config.ExpectLeafSize) {
+		return nil, fmt.Errorf("%w: data block at leaf index %d is %d bytes, want %d bytes",
+			ErrUnexpectedLeafSize, idx, size, config.ExpectLeafSize)
+	}
+	h, err := newLeafHash(config, idx)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := config.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultStreamChunkSize
+	}
+	if size < int64(chunkSize) {
+		chunkSize = int(size) + 1 // one more byte to detect a source longer than its size
+	}
+	// The reader is wrapped so that the chunks are written through the buffer, even if it implements io.WriterTo.
+	n, err := io.CopyBuffer(h, struct{ io.Reader }{r}, make([]byte, chunkSize))
+	if err != nil {
+		return nil, err
+	}
+	if n != size {
+		return nil, fmt.Errorf("data block at leaf index %d read %d bytes, want %d bytes", idx, n, size)
+	}
+	return h.Sum(nil), nil
+}
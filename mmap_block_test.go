@@ -0,0 +1,170 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// fileBlock is a data block streamed from a file.
+type fileBlock struct {
+	path string
+	// serialized counts the calls to Serialize.
+	serialized atomic.Int32
+}
+
+func (b *fileBlock) Serialize() ([]byte, error) {
+	b.serialized.Add(1)
+	return os.ReadFile(b.path)
+}
+
+func (b *fileBlock) OpenReader() (io.ReadCloser, int64, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+func TestMmapBlock(t *testing.T) {
+	dir := t.TempDir()
+	sizes := []int{64 << 20, 0, 1, 3 << 20, 1000}
+	blocks := make([]DataBlock, len(sizes))
+	fileBlocks := make([]*fileBlock, len(sizes))
+	for i, size := range sizes {
+		data := bytes.Repeat([]byte{byte(i + 1)}, size)
+		path := filepath.Join(dir, fmt.Sprintf("block%d", i))
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		fileBlocks[i] = &fileBlock{path: path}
+		blocks[i] = fileBlocks[i]
+	}
+	var maxWrite atomic.Int64
+	hasher := func() hash.Hash { return &chunkRecordingHash{Hash: sha256.New(), maxWrite: &maxWrite} }
+	for _, config := range []*Config{
+		{Hasher: hasher},
+		{Hasher: hasher, ChunkSize: 4096, BindLeafIndex: true},
+		{Hasher: hasher, RunInParallel: true, NumRoutines: 2},
+	} {
+		maxWrite.Store(0)
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, block := range fileBlocks {
+			if n := block.serialized.Load(); n != 0 {
+				t.Errorf("Serialize() of block %d called %d times, want the block streamed", i, n)
+			}
+		}
+		wantMax := int64(config.ChunkSize)
+		if wantMax == 0 {
+			wantMax = defaultStreamChunkSize
+		}
+		if maxWrite.Load() > wantMax {
+			t.Errorf("largest write = %d bytes, want at most %d bytes", maxWrite.Load(), wantMax)
+		}
+		// The leaves are those of the serialized data blocks.
+		want, err := HashLeaves(&Config{HashFunc: DefaultHashFunc, BindLeafIndex: config.BindLeafIndex}, blocks)
+		if err != nil {
+			t.Fatalf("HashLeaves() error = %v", err)
+		}
+		for i := range want {
+			if !bytes.Equal(m.Leaves[i], want[i]) {
+				t.Errorf("leaf %d = %x, want %x", i, m.Leaves[i], want[i])
+			}
+			if ok, err := m.Verify(blocks[i], m.Proofs[i]); err != nil || !ok {
+				t.Errorf("Verify() of block %d = %v, %v, want true", i, ok, err)
+			}
+			fileBlocks[i].serialized.Store(0)
+		}
+	}
+}
+
+func TestMmapBlock_errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "block")
+	if err := os.WriteFile(path, make([]byte, 100), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	blocks := []DataBlock{&fileBlock{path: path}, &fileBlock{path: path}}
+	if _, err := New(&Config{Hasher: sha256.New, ExpectLeafSize: 50}, blocks); !errors.Is(err, ErrUnexpectedLeafSize) {
+		t.Errorf("New() with ExpectLeafSize error = %v, want ErrUnexpectedLeafSize", err)
+	}
+	missing := []DataBlock{&fileBlock{path: path}, &fileBlock{path: filepath.Join(dir, "missing")}}
+	if _, err := New(&Config{Hasher: sha256.New}, missing); err == nil {
+		t.Errorf("New() with a missing file error = nil, want error")
+	}
+	short := []DataBlock{&fileBlock{path: path}, &sizedBlock{data: make([]byte, 10), size: 20}}
+	if _, err := New(&Config{Hasher: sha256.New}, short); err == nil {
+		t.Errorf("New() with a short reader error = nil, want error")
+	}
+	long := []DataBlock{&fileBlock{path: path}, &sizedBlock{data: make([]byte, 30), size: 20}}
+	if _, err := New(&Config{Hasher: sha256.New}, long); err == nil {
+		t.Errorf("New() with a long reader error = nil, want error")
+	}
+}
+
+// sizedBlock is a data block whose reader returns the data while reporting the size.
+type sizedBlock struct {
+	data []byte
+	size int64
+}
+
+func (b *sizedBlock) Serialize() ([]byte, error) {
+	return b.data, nil
+}
+
+func (b *sizedBlock) OpenReader() (io.ReadCloser, int64, error) {
+	return io.NopCloser(bytes.NewReader(b.data)), b.size, nil
+}
+
+// chunkRecordingHash records the size of the largest write to the hash.
+type chunkRecordingHash struct {
+	hash.Hash
+	maxWrite *atomic.Int64
+}
+
+func (h *chunkRecordingHash) Write(p []byte) (int, error) {
+	for {
+		cur := h.maxWrite.Load()
+		if int64(len(p)) <= cur || h.maxWrite.CompareAndSwap(cur, int64(len(p))) {
+			break
+		}
+	}
+	return h.Hash.Write(p)
+}
@@ -0,0 +1,52 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// NestedProof is the proof of a data block in a two-level Merkle Tree scheme,
+// where the leaves of the outer tree are the roots of the inner trees.
+type NestedProof struct {
+	InnerBlock DataBlock // data block in the inner tree.
+	InnerProof *Proof    // proof of the data block in the inner tree.
+	InnerRoot  []byte    // root of the inner tree, which is the data block of the outer tree leaf.
+	OuterProof *Proof    // proof of the inner root in the outer tree.
+}
+
+// VerifyNested verifies the data block in the inner tree against the inner root,
+// and the inner root as the data block of the outer tree leaf against the outer root.
+// The inner and outer configurations may differ, e.g. in their hash functions.
+func VerifyNested(proof *NestedProof, outerRoot []byte, innerConfig, outerConfig *Config) (bool, error) {
+	if proof == nil {
+		return false, errNilInput("nested proof")
+	}
+	if proof.InnerRoot == nil {
+		return false, errNilInput("inner root")
+	}
+	if outerRoot == nil {
+		return false, errNilInput("outer root")
+	}
+	ok, err := Verify(proof.InnerBlock, proof.InnerProof, proof.InnerRoot, innerConfig)
+	if err != nil || !ok {
+		return false, err
+	}
+	return Verify(byteBlock(proof.InnerRoot), proof.OuterProof, outerRoot, outerConfig)
+}
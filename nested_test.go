@@ -0,0 +1,116 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestVerifyNested(t *testing.T) {
+	innerSizes := []int{2, 5, 8, 3}
+	innerBlocks := make([][]DataBlock, len(innerSizes))
+	innerTrees := make([]*MerkleTree, len(innerSizes))
+	outerBlocks := make([]DataBlock, len(innerSizes))
+	for i, size := range innerSizes {
+		innerBlocks[i] = dataBlocks(size)
+		var err error
+		if innerTrees[i], err = New(nil, innerBlocks[i]); err != nil {
+			t.Fatalf("New() inner error = %v", err)
+		}
+		outerBlocks[i] = &mock.DataBlock{Data: innerTrees[i].Root}
+	}
+	outerConfig := &Config{SortSiblingPairs: true}
+	outer, err := New(outerConfig, outerBlocks)
+	if err != nil {
+		t.Fatalf("New() outer error = %v", err)
+	}
+	nestedProof := func(tree, block int) *NestedProof {
+		return &NestedProof{
+			InnerBlock: innerBlocks[tree][block],
+			InnerProof: innerTrees[tree].Proofs[block],
+			InnerRoot:  innerTrees[tree].Root,
+			OuterProof: outer.Proofs[tree],
+		}
+	}
+	tests := []struct {
+		name    string
+		proof   *NestedProof
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:  "test_ok",
+			proof: nestedProof(1, 4),
+			want:  true,
+		},
+		{
+			name:  "test_ok_last_tree",
+			proof: nestedProof(3, 2),
+			want:  true,
+		},
+		{
+			name: "test_inner_root_mismatch",
+			proof: func() *NestedProof {
+				p := nestedProof(1, 4)
+				p.InnerRoot = innerTrees[2].Root
+				return p
+			}(),
+			want: false,
+		},
+		{
+			name: "test_outer_proof_mismatch",
+			proof: func() *NestedProof {
+				p := nestedProof(1, 4)
+				p.OuterProof = outer.Proofs[2]
+				return p
+			}(),
+			want: false,
+		},
+		{
+			name:    "test_nil_proof",
+			wantErr: true,
+		},
+		{
+			name: "test_nil_inner_root",
+			proof: func() *NestedProof {
+				p := nestedProof(1, 4)
+				p.InnerRoot = nil
+				return p
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyNested(tt.proof, outer.Root, nil, outerConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyNested() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyNested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNilProof is returned by the verification functions when the proof, the root or the data block is nil,
+// instead of panicking or failing the verification, e.g. to reject the malformed input of service handlers.
+// The error names the nil input.
+var ErrNilProof = errors.New("nil verification input")
+
+// errNilInput returns ErrNilProof naming the nil input.
+func errNilInput(input string) error {
+	return fmt.Errorf("%w: %s is nil", ErrNilProof, input)
+}
+
+// isNilBlock reports whether the data block is nil, including a nil pointer in the interface,
+// whose Serialize method would panic.
+func isNilBlock(dataBlock DataBlock) bool {
+	if dataBlock == nil {
+		return true
+	}
+	v := reflect.ValueOf(dataBlock)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// checkVerifyInput returns ErrNilProof if the data block, the proof or the root is nil.
+func checkVerifyInput(dataBlock DataBlock, proof *Proof, root []byte) error {
+	switch {
+	case isNilBlock(dataBlock):
+		return errNilInput("data block")
+	case proof == nil:
+		return errNilInput("proof")
+	case root == nil:
+		return errNilInput("root")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestVerify_nilInputs(t *testing.T) {
+	blocks := dataBlocks(8)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The entry points verify the data block with the proof against the root, mapped to their own inputs.
+	entryPoints := []struct {
+		name   string
+		verify func(block DataBlock, proof *Proof, root []byte) (bool, error)
+	}{
+		{"Verify", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			return Verify(block, proof, root, nil)
+		}},
+		{"VerifyReturningLeaf", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			ok, _, err := VerifyReturningLeaf(block, proof, root, nil)
+			return ok, err
+		}},
+		{"VerifyWithLimit", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			return VerifyWithLimit(block, proof, root, nil, 8)
+		}},
+		{"VerifyAny", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			idx, err := VerifyAny(block, proof, [][]byte{root}, nil)
+			return idx == 0, err
+		}},
+		{"VerifyAuto", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			var data []byte
+			if proof != nil {
+				withID := *proof
+				withID.HashID = HashIDSHA256
+				var err error
+				if data, err = withID.MarshalBinary(); err != nil {
+					return false, err
+				}
+			}
+			return VerifyAuto(block, data, root)
+		}},
+		{"VerifyReader", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			var r io.Reader
+			if !isNilBlock(block) {
+				r = bytes.NewReader(block.(*mock.DataBlock).Data)
+			}
+			return VerifyReader(r, proof, root, nil)
+		}},
+		{"ExplainVerification", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			report, err := ExplainVerification(block, proof, root, nil)
+			return report != nil && bytes.Equal(report.Root, report.ExpectedRoot), err
+		}},
+		{"NewVerifier", func(block DataBlock, proof *Proof, root []byte) (bool, error) {
+			v, err := NewVerifier(root, nil)
+			if err != nil {
+				return false, err
+			}
+			return v.Verify(block, proof)
+		}},
+	}
+	var nilBlock *mock.DataBlock
+	inputs := []struct {
+		name  string
+		block DataBlock
+		proof *Proof
+		root  []byte
+	}{
+		{"nil_block", nil, m.Proofs[3], m.Root},
+		{"nil_pointer_block", nilBlock, m.Proofs[3], m.Root},
+		{"nil_proof", blocks[3], nil, m.Root},
+		{"nil_root", blocks[3], m.Proofs[3], nil},
+		{"nil_block_and_proof", nil, nil, m.Root},
+		{"nil_proof_and_root", blocks[3], nil, nil},
+		{"all_nil", nil, nil, nil},
+	}
+	for _, entryPoint := range entryPoints {
+		t.Run(entryPoint.name, func(t *testing.T) {
+			if ok, err := entryPoint.verify(blocks[3], m.Proofs[3], m.Root); err != nil || !ok {
+				t.Fatalf("valid input = %v, %v, want true", ok, err)
+			}
+			for _, in := range inputs {
+				ok, err := entryPoint.verify(in.block, in.proof, in.root)
+				if ok || !errors.Is(err, ErrNilProof) {
+					t.Errorf("%s = %v, %v, want false, ErrNilProof", in.name, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerify_nilInputsOfComposedProofs(t *testing.T) {
+	blocks := dataBlocks(8)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	verifier, err := NewVerifier(m.Root, nil)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	var nilBlock *mock.DataBlock
+	tests := []struct {
+		name   string
+		verify func() (bool, error)
+	}{
+		{"VerifyFull/nil_root", func() (bool, error) { return VerifyFull(blocks, nil, nil) }},
+		{"VerifyFull/nil_block", func() (bool, error) {
+			return VerifyFull([]DataBlock{blocks[0], nilBlock}, m.Root, nil)
+		}},
+		{"VerifyRange/nil_proof", func() (bool, error) { return VerifyRange(blocks[:2], nil, m.Root, nil) }},
+		{"VerifyRange/nil_root", func() (bool, error) {
+			return VerifyRange(blocks[:2], &RangeProof{Start: 0, End: 1}, nil, nil)
+		}},
+		{"VerifyRange/nil_block", func() (bool, error) {
+			return VerifyRange([]DataBlock{blocks[0], nil}, &RangeProof{Start: 0, End: 1}, m.Root, nil)
+		}},
+		{"VerifyFile/nil_proof", func() (bool, error) { return VerifyFile("block", nil, m.Root, nil) }},
+		{"VerifyFile/nil_root", func() (bool, error) { return VerifyFile("block", m.Proofs[0], nil, nil) }},
+		{"VerifyPadding/nil_proof", func() (bool, error) { return VerifyPadding(nil, m.Root, nil) }},
+		{"VerifyPadding/nil_root", func() (bool, error) { return VerifyPadding(m.Proofs[0], nil, nil) }},
+		{"VerifyNested/nil_proof", func() (bool, error) { return VerifyNested(nil, m.Root, nil, nil) }},
+		{"VerifyNested/nil_inner_root", func() (bool, error) {
+			return VerifyNested(&NestedProof{InnerBlock: blocks[0], InnerProof: m.Proofs[0]}, m.Root, nil, nil)
+		}},
+		{"VerifyNested/nil_outer_root", func() (bool, error) {
+			return VerifyNested(&NestedProof{InnerBlock: blocks[0], InnerProof: m.Proofs[0], InnerRoot: m.Root},
+				nil, nil, nil)
+		}},
+		{"VerifyNested/nil_inner_block", func() (bool, error) {
+			return VerifyNested(&NestedProof{InnerProof: m.Proofs[0], InnerRoot: m.Root}, m.Root, nil, nil)
+		}},
+		{"VerifyChain/nil_leaf_hash", func() (bool, error) {
+			return VerifyChain(nil, []ChainLink{{Proof: m.Proofs[0]}}, m.Root, nil)
+		}},
+		{"VerifyChain/nil_link_proof", func() (bool, error) {
+			return VerifyChain(m.Leaves[0], []ChainLink{{}}, m.Root, nil)
+		}},
+		{"VerifyChain/nil_root", func() (bool, error) {
+			return VerifyChain(m.Leaves[0], []ChainLink{{Proof: m.Proofs[0]}}, nil, nil)
+		}},
+		{"RootVerifier.VerifyLeafHash/nil_leaf_hash", func() (bool, error) {
+			return verifier.VerifyLeafHash(nil, m.Proofs[0])
+		}},
+		{"RootVerifier.VerifyLeafHash/nil_proof", func() (bool, error) {
+			return verifier.VerifyLeafHash(m.Leaves[0], nil)
+		}},
+		{"VerifySignedRoot/nil_root", func() (bool, error) {
+			return VerifySignedRoot(nil, []byte("signature"), func(root, signature []byte) (bool, error) {
+				return true, nil
+			})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, err := tt.verify(); ok || !errors.Is(err, ErrNilProof) {
+				t.Errorf("got %v, %v, want false, ErrNilProof", ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// noDuplicatesTag is appended to the last node of an odd level to derive its padding node with NoDuplicates,
+// so that the padding node differs from the last node and from the hash of any pair of nodes.
+const noDuplicatesTag = "merkletree:no-duplicates"
+
+// NoDuplicatesFiller returns the padding node of an odd level ending with the node, with NoDuplicates:
+// HashFunc(node || "merkletree:no-duplicates"). It is the sibling of the last node of the odd level,
+// so a verifier of the proof of the last leaf of an odd level may check that the sibling is not arbitrary.
+// The configuration is not modified.
+func NoDuplicatesFiller(node []byte, config *Config) ([]byte, error) {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	return verifyConfig(&c).noDuplicatesFiller(c.HashFunc, node)
+}
+
+// noDuplicatesFiller derives the padding node of an odd level ending with the node under the hash function.
+func (c *Config) noDuplicatesFiller(hashFunc TypeHashFunc, node []byte) ([]byte, error) {
+	data := make([]byte, 0, len(node)+len(noDuplicatesTag))
+	data = append(append(data, node...), noDuplicatesTag...)
+	return c.hashWith(hashFunc, data)
+}
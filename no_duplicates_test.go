@@ -0,0 +1,104 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNoDuplicates_lastLeaf(t *testing.T) {
+	for _, numBlocks := range []int{3, 5, 7, 9, 13, 101, 1001} {
+		blocks := dataBlocks(numBlocks)
+		var root []byte
+		for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+			for _, parallel := range []bool{false, true} {
+				name := fmt.Sprintf("%d blocks/mode %d/parallel %v", numBlocks, mode, parallel)
+				config := &Config{Mode: mode, NoDuplicates: true, RunInParallel: parallel, NumRoutines: 4}
+				m, err := New(config, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() error = %v", name, err)
+				}
+				// The padding nodes are deterministic, so every build has the same root.
+				if root == nil {
+					root = m.Root
+				} else if !bytes.Equal(m.Root, root) {
+					t.Errorf("%s: root = %x, want %x", name, m.Root, root)
+				}
+				last := numBlocks - 1
+				proof, err := m.GenerateProof(blocks[last])
+				if err != nil {
+					t.Fatalf("%s: GenerateProof() error = %v", name, err)
+				}
+				if ok, err := Verify(blocks[last], proof, m.Root, config); err != nil || !ok {
+					t.Errorf("%s: Verify() of the last leaf = %v, %v, want true", name, ok, err)
+				}
+				// The sibling of the last leaf of an odd level is derived from it.
+				leaf, err := m.LeafHashOf(blocks[last])
+				if err != nil {
+					t.Fatalf("%s: LeafHashOf() error = %v", name, err)
+				}
+				filler, err := NoDuplicatesFiller(leaf, config)
+				if err != nil {
+					t.Fatalf("%s: NoDuplicatesFiller() error = %v", name, err)
+				}
+				if !bytes.Equal(proof.Siblings[0], filler) {
+					t.Errorf("%s: sibling of the last leaf = %x, want %x", name, proof.Siblings[0], filler)
+				}
+				// A duplicate of the last leaf is not accepted as its sibling.
+				forged := *proof
+				forged.Siblings = append([][]byte{leaf}, proof.Siblings[1:]...)
+				if ok, _ := Verify(blocks[last], &forged, m.Root, config); ok {
+					t.Errorf("%s: Verify() with a duplicate sibling = true, want false", name)
+				}
+			}
+		}
+	}
+}
+
+func TestNoDuplicatesFiller(t *testing.T) {
+	node := []byte("node")
+	got, err := NoDuplicatesFiller(node, nil)
+	if err != nil {
+		t.Fatalf("NoDuplicatesFiller() error = %v", err)
+	}
+	want, err := DefaultHashFunc([]byte("nodemerkletree:no-duplicates"))
+	if err != nil {
+		t.Fatalf("DefaultHashFunc() error = %v", err)
+	}
+	if !bytes.Equal(got, want) || string(node) != "node" {
+		t.Errorf("NoDuplicatesFiller() = %x, want %x", got, want)
+	}
+	errHash := errors.New("hash func error")
+	config := &Config{HashFunc: func([]byte) ([]byte, error) {
+		return nil, errHash
+	}}
+	if _, err = NoDuplicatesFiller(node, config); !errors.Is(err, errHash) {
+		t.Errorf("NoDuplicatesFiller() error = %v, want %v", err, errHash)
+	}
+	if config.concatFunc != nil {
+		t.Error("NoDuplicatesFiller() modified the configuration")
+	}
+}
@@ -0,0 +1,131 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestMerkleTree_paddedLeaf(t *testing.T) {
+	// At the sizes padded at several levels, every proof is that of its real leaf, with the padding nodes
+	// as the siblings at the odd levels, and no lookup or proof refers to the padding leaf.
+	for _, numBlocks := range []int{3, 5, 9, 11, 17} {
+		blocks := dataBlocks(numBlocks)
+		for _, config := range []Config{
+			{},
+			{NoDuplicates: true},
+			{PaddingStrategy: PaddingPromote},
+			{SortSiblingPairs: true, ShareSiblings: true},
+			{StoreReversedProofs: true},
+			{Mode: ModeTreeBuild},
+			{Mode: ModeTreeBuild, NoDuplicates: true},
+			{Mode: ModeProofGenAndTreeBuild, PaddingStrategy: PaddingPromote},
+			{RunInParallel: true, NumRoutines: 3},
+			{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, NumRoutines: 2, NoDuplicates: true},
+		} {
+			config := config
+			name := fmt.Sprintf("%d blocks/%+v", numBlocks, config)
+			m, err := newWithContext(context.Background(), &config, blocks, config.NumRoutines)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			wantPadded := numBlocks + 1
+			if config.PaddingStrategy == PaddingPromote {
+				wantPadded = numBlocks
+			}
+			if m.NumLeaves != numBlocks || m.PaddedLeafCount() != wantPadded {
+				t.Errorf("%s: NumLeaves = %d, PaddedLeafCount() = %d, want %d, %d",
+					name, m.NumLeaves, m.PaddedLeafCount(), numBlocks, wantPadded)
+			}
+			leaves := make([][]byte, numBlocks)
+			for i, block := range blocks {
+				if leaves[i], err = leafFromBlock(block, i, m.Config); err != nil {
+					t.Fatalf("%s: leafFromBlock() error = %v", name, err)
+				}
+			}
+			for i, block := range blocks {
+				proof, err := m.ProofOf(i)
+				if err != nil {
+					t.Fatalf("%s: ProofOf(%d) error = %v", name, i, err)
+				}
+				if ok, err := VerifyStrict(block, proof, i, numBlocks, m.Root, &config); err != nil || !ok {
+					t.Errorf("%s: VerifyStrict() of leaf %d = %v, %v, want true", name, i, ok, err)
+				}
+				want, _, err := GenerateProofFromLeaves(&config, leaves, i)
+				if err != nil {
+					t.Fatalf("%s: GenerateProofFromLeaves() error = %v", name, err)
+				}
+				if !proof.Equal(want) {
+					t.Errorf("%s: ProofOf(%d) = %+v, want %+v", name, i, proof, want)
+				}
+				if idx, err := m.IndexOf(block); err != nil || idx != i {
+					t.Errorf("%s: IndexOf() of block %d = %d, %v", name, i, idx, err)
+				}
+				if proof, err := m.GenerateProof(block); err != nil || proof.Index != i {
+					t.Errorf("%s: GenerateProof() of block %d = %+v, %v", name, i, proof, err)
+				}
+			}
+			if _, err := m.ProofOf(numBlocks); err == nil {
+				t.Errorf("%s: ProofOf() of the padding leaf error = nil, want error", name)
+			}
+		}
+	}
+}
+
+func TestMerkleTree_IndexOf_duplicates(t *testing.T) {
+	// The first of identical leaves is returned in every mode, and after updates.
+	blocks := dataBlocks(9)
+	blocks[3], blocks[8] = blocks[1], blocks[1]
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		m, err := New(&Config{Mode: mode}, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if idx, err := m.IndexOf(blocks[8]); err != nil || idx != 1 {
+			t.Errorf("%v: IndexOf() of the duplicate block = %d, %v, want 1", mode, idx, err)
+		}
+		if mode == ModeProofGen {
+			continue
+		}
+		if err := m.UpdateBatch(map[int]DataBlock{1: blocks[0], 5: blocks[1]}); err != nil {
+			t.Fatalf("UpdateBatch() error = %v", err)
+		}
+		if idx, err := m.IndexOf(blocks[8]); err != nil || idx != 3 {
+			t.Errorf("%v: IndexOf() of the duplicate block after UpdateBatch() = %d, %v, want 3", mode, idx, err)
+		}
+		if idx, err := m.IndexOf(blocks[0]); err != nil || idx != 0 {
+			t.Errorf("%v: IndexOf() of the first block after UpdateBatch() = %d, %v, want 0", mode, idx, err)
+		}
+		if err := m.UpdateBatch(map[int]DataBlock{0: blocks[2]}); err != nil {
+			t.Fatalf("UpdateBatch() error = %v", err)
+		}
+		if idx, err := m.IndexOf(blocks[0]); err != nil || idx != 1 {
+			t.Errorf("%v: IndexOf() of the replaced first block = %d, %v, want 1", mode, idx, err)
+		}
+	}
+	if _, err := (&MerkleTree{Config: &Config{BindLeafIndex: true}}).IndexOf(blocks[0]); err == nil {
+		t.Errorf("IndexOf() with BindLeafIndex error = nil, want error")
+	}
+}
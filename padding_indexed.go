@@ -0,0 +1,133 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// indexedPaddingLen returns the number of leaves of a tree of numLeaves leaves padded by PaddingIndexed,
+// i.e. the smallest power of two not less than numLeaves.
+func indexedPaddingLen(numLeaves int) int {
+	padded := 1
+	for padded < numLeaves {
+		padded <<= 1
+	}
+	return padded
+}
+
+// PaddingLeaf returns the padding leaf at the leaf index of the trees built with PaddingIndexed,
+// HashFunc, or the hash function of level 0 of LevelHashFunc, of the big-endian uint64 index.
+// A data block serializing to the same 8 bytes has the same leaf, unless e.g. BindLeafIndex
+// or a LeafTransform separates the data blocks from the padding.
+func PaddingLeaf(idx int, config *Config) ([]byte, error) {
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid leaf index %d", idx)
+	}
+	return paddingLeaf(idx, verifyConfig(config))
+}
+
+// paddingLeaf returns the padding leaf at the leaf index with the initialized configuration.
+func paddingLeaf(idx int, config *Config) ([]byte, error) {
+	var idxBytes [8]byte
+	binary.BigEndian.PutUint64(idxBytes[:], uint64(idx))
+	return config.hashWith(config.levelHash(0), idxBytes[:])
+}
+
+// padIndexed appends the padding leaves of PaddingIndexed to the leaves.
+func (m *MerkleTree) padIndexed(leaves [][]byte) ([][]byte, error) {
+	padded := indexedPaddingLen(len(leaves))
+	m.numPaddingLeaves = padded - len(leaves)
+	for idx := len(leaves); idx < padded; idx++ {
+		leaf, err := paddingLeaf(idx, m.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err = m.checkHashSize(leaf); err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, nil
+}
+
+// splitIndexedPadding splits the padding leaves of PaddingIndexed and their proofs off NumLeaves, Leaves and Proofs
+// once the tree is built. The proofs of the padding leaves are kept in ModeProofGen only, they are generated
+// from the tree nodes otherwise, so that they follow the updates of the tree.
+func (m *MerkleTree) splitIndexedPadding() {
+	n := m.NumLeaves - m.numPaddingLeaves
+	// The leaf map only maps the leaves of the data blocks, a data block may have a padding leaf.
+	if m.leafMap != nil {
+		for _, leaf := range m.Leaves[n:] {
+			if val, ok := m.leafMap.Load(string(leaf)); ok && val.(int) >= n {
+				m.leafMap.Delete(string(leaf))
+			}
+		}
+	}
+	if m.Proofs != nil {
+		if m.nodes == nil {
+			m.paddingProofs = m.Proofs[n:]
+		}
+		m.Proofs = m.Proofs[:n:n]
+	}
+	m.NumLeaves, m.Leaves = n, m.Leaves[:n:n]
+}
+
+// VerifyPadding verifies with the Merkle Tree proof, e.g. ProofOf of a padded position, that the leaf
+// at the index of the proof is the padding leaf of PaddingIndexed, i.e. that no data block is at that position.
+// The proof does not show that the index is past the data blocks, as a data block whose leaf is the padding leaf
+// of its index is verified as padding too.
+func VerifyPadding(proof *Proof, root []byte, config *Config) (bool, error) {
+	if proof == nil {
+		return false, errNilInput("proof")
+	}
+	if root == nil {
+		return false, errNilInput("root")
+	}
+	if proof.Index < 0 {
+		return false, fmt.Errorf("invalid leaf index %d", proof.Index)
+	}
+	config = verifyConfig(config)
+	if config.PaddingStrategy != PaddingIndexed {
+		return false, fmt.Errorf("padding verification requires PaddingIndexed, got %v", config.PaddingStrategy)
+	}
+	if err := checkProofDepth(proof, config); err != nil {
+		return false, err
+	}
+	if err := checkProofPath(proof); err != nil {
+		return false, err
+	}
+	if err := checkFingerprint(proof.Fingerprint, config); err != nil {
+		return false, err
+	}
+	leaf, err := paddingLeaf(proof.Index, config)
+	if err != nil {
+		return false, err
+	}
+	result, err := rootFromLeaf(leaf, proof, config)
+	if err != nil {
+		return false, err
+	}
+	return rootMatches(result, root, config), nil
+}
@@ -0,0 +1,252 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestPaddingIndexed(t *testing.T) {
+	blocks := dataBlocks(5)
+	configs := []Config{
+		{Mode: ModeProofGen},
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, NumRoutines: 2},
+		{Mode: ModeProofGen, RunInParallel: true, NumRoutines: 2},
+		{Mode: ModeProofGen, NoDuplicates: true, EmbedFingerprint: true},
+	}
+	for _, config := range configs {
+		config.PaddingStrategy = PaddingIndexed
+		m, err := New(&config, blocks)
+		if err != nil {
+			t.Fatalf("%+v: New() error = %v", config, err)
+		}
+		// The padding leaves are only counted by PaddedLeafCount.
+		if m.NumLeaves != 5 || len(m.Leaves) != 5 || m.PaddedLeafCount() != 8 || m.Depth != 3 {
+			t.Fatalf("%+v: NumLeaves = %d, %d leaves, PaddedLeafCount() = %d, Depth = %d, want 5, 5, 8, 3",
+				config, m.NumLeaves, len(m.Leaves), m.PaddedLeafCount(), m.Depth)
+		}
+		if m.Proofs != nil && len(m.Proofs) != 5 {
+			t.Errorf("%+v: %d proofs, want 5", config, len(m.Proofs))
+		}
+		// The root is that of the tree of the 5 leaves and the 3 padding leaves.
+		leafBlocks := make([]DataBlock, 8)
+		for i := range leafBlocks {
+			leaf := m.Leaves[min(i, 4)]
+			if i >= 5 {
+				if leaf, err = PaddingLeaf(i, &config); err != nil {
+					t.Fatalf("%+v: PaddingLeaf(%d) error = %v", config, i, err)
+				}
+			}
+			leafBlocks[i] = &mock.DataBlock{Data: leaf}
+		}
+		want, err := New(&Config{DisableLeafHashing: true}, leafBlocks)
+		if err != nil {
+			t.Fatalf("%+v: New() error = %v", config, err)
+		}
+		if !bytes.Equal(m.Root, want.Root) {
+			t.Errorf("%+v: Root = %x, want %x", config, m.Root, want.Root)
+		}
+		for i := 0; i < 8; i++ {
+			proof, err := m.ProofOf(i)
+			if err != nil {
+				t.Fatalf("%+v: ProofOf(%d) error = %v", config, i, err)
+			}
+			if len(proof.Siblings) != 3 {
+				t.Errorf("%+v: proof %d has %d siblings, want 3", config, i, len(proof.Siblings))
+			}
+			padding, err := VerifyPadding(proof, m.Root, &config)
+			if err != nil {
+				t.Fatalf("%+v: VerifyPadding() of position %d error = %v", config, i, err)
+			}
+			if padding != (i >= 5) {
+				t.Errorf("%+v: VerifyPadding() of position %d = %v, want %v", config, i, padding, i >= 5)
+			}
+			if i >= 5 {
+				continue
+			}
+			if ok, err := Verify(blocks[i], proof, m.Root, &config); err != nil || !ok {
+				t.Errorf("%+v: Verify() of block %d = %v, %v, want true", config, i, ok, err)
+			}
+		}
+	}
+
+	m, err := New(&Config{PaddingStrategy: PaddingIndexed}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = m.ProofOf(8); err == nil {
+		t.Errorf("ProofOf() past the padding leaves error = nil, want error")
+	}
+
+	// The padding leaves are distinct from each other.
+	seen := make(map[string]bool)
+	for i := 5; i < 16; i++ {
+		leaf, err := PaddingLeaf(i, nil)
+		if err != nil {
+			t.Fatalf("PaddingLeaf(%d) error = %v", i, err)
+		}
+		if seen[string(leaf)] {
+			t.Fatalf("PaddingLeaf(%d) is not distinct", i)
+		}
+		seen[string(leaf)] = true
+	}
+
+	// A power of two is not padded.
+	m, err = New(&Config{PaddingStrategy: PaddingIndexed}, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.NumLeaves != 4 || m.PaddedLeafCount() != 4 {
+		t.Errorf("NumLeaves, PaddedLeafCount() of 4 data blocks = %d, %d, want 4, 4", m.NumLeaves, m.PaddedLeafCount())
+	}
+
+	plan, err := Plan(&Config{PaddingStrategy: PaddingIndexed}, 5)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if plan.PaddedLeaves != 8 || plan.LeafHashes != 8 || plan.NodeHashes != 7 {
+		t.Errorf("Plan() = %d padded leaves, %d leaf hashes, %d node hashes, want 8, 8, 7",
+			plan.PaddedLeaves, plan.LeafHashes, plan.NodeHashes)
+	}
+}
+
+func TestPaddingIndexed_errors(t *testing.T) {
+	blocks := dataBlocks(5)
+	sha224 := func(data []byte) ([]byte, error) {
+		sum := sha256.Sum224(data)
+		return sum[:], nil
+	}
+	if _, err := New(&Config{PaddingStrategy: PaddingIndexed, AdditionalHashFuncs: []TypeHashFunc{sha224}},
+		blocks); err == nil {
+		t.Errorf("New() with AdditionalHashFuncs error = nil, want error")
+	}
+	if _, err := SubRoot(blocks, 0, 3, &Config{PaddingStrategy: PaddingIndexed}); err == nil {
+		t.Errorf("SubRoot() error = nil, want error")
+	}
+	config := &Config{PaddingStrategy: PaddingIndexed, Mode: ModeTreeBuild}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = m.UpdateLeaf(6, blocks[0]); err == nil {
+		t.Errorf("UpdateLeaf() of a padding leaf error = nil, want error")
+	}
+	if err = m.UpdateLeaf(4, blocks[0]); err != nil {
+		t.Errorf("UpdateLeaf() error = %v", err)
+	}
+	proof, err := m.ProofOf(6)
+	if err != nil {
+		t.Fatalf("ProofOf() error = %v", err)
+	}
+	if ok, err := VerifyPadding(proof, m.Root, config); err != nil || !ok {
+		t.Errorf("VerifyPadding() after the update = %v, %v, want true", ok, err)
+	}
+	if _, err = VerifyPadding(proof, m.Root, nil); err == nil {
+		t.Errorf("VerifyPadding() without PaddingIndexed error = nil, want error")
+	}
+	moved := *proof
+	moved.Index = 7
+	if _, err = VerifyPadding(&moved, m.Root, config); err == nil {
+		t.Errorf("VerifyPadding() of a proof with a mismatching index error = nil, want error")
+	}
+	if _, err = PaddingLeaf(-1, nil); err == nil {
+		t.Errorf("PaddingLeaf(-1) error = nil, want error")
+	}
+}
+
+func TestPaddingIndexed_json(t *testing.T) {
+	blocks := dataBlocks(5)
+	config := &Config{PaddingStrategy: PaddingIndexed}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var restored MerkleTree
+	if err = json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if restored.PaddingStrategy != PaddingIndexed {
+		t.Errorf("PaddingStrategy = %v, want %v", restored.PaddingStrategy, PaddingIndexed)
+	}
+	if restored.NumLeaves != 5 || len(restored.Proofs) != 5 || restored.PaddedLeafCount() != 8 {
+		t.Errorf("restored NumLeaves = %d, %d proofs, PaddedLeafCount() = %d, want 5, 5, 8",
+			restored.NumLeaves, len(restored.Proofs), restored.PaddedLeafCount())
+	}
+	proof, err := restored.ProofOf(7)
+	if err != nil {
+		t.Fatalf("ProofOf() error = %v", err)
+	}
+	if ok, err := VerifyPadding(proof, restored.Root, restored.Config); err != nil || !ok {
+		t.Errorf("VerifyPadding() of the restored proof = %v, %v, want true", ok, err)
+	}
+}
+
+func TestPaddingIndexed_structure(t *testing.T) {
+	blocks := dataBlocks(5)
+	config := &Config{Mode: ModeTreeBuild, PaddingStrategy: PaddingIndexed}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := m.MarshalStructure()
+	if err != nil {
+		t.Fatalf("MarshalStructure() error = %v", err)
+	}
+	var restored MerkleTree
+	if err = restored.UnmarshalStructure(data); err != nil {
+		t.Fatalf("UnmarshalStructure() error = %v", err)
+	}
+	// The padding leaves are restored as padding, so they could not be updated.
+	if err = restored.UpdateLeaf(6, blocks[0]); err == nil {
+		t.Errorf("UpdateLeaf() of a padding leaf of the restored tree error = nil, want error")
+	}
+	if err = restored.UpdateLeaf(4, blocks[0]); err != nil {
+		t.Fatalf("UpdateLeaf() of the restored tree error = %v", err)
+	}
+	if err = m.UpdateLeaf(4, blocks[0]); err != nil {
+		t.Fatalf("UpdateLeaf() error = %v", err)
+	}
+	if !Equal(&restored, m) {
+		t.Errorf("restored tree after the update differs from the updated tree")
+	}
+	for i := 5; i < 8; i++ {
+		proof, err := restored.ProofOf(i)
+		if err != nil {
+			t.Fatalf("ProofOf(%d) error = %v", i, err)
+		}
+		if ok, err := VerifyPadding(proof, restored.Root, restored.Config); err != nil || !ok {
+			t.Errorf("VerifyPadding() of position %d of the restored tree = %v, %v, want true", i, ok, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// rfc6962Root computes the root of the leaves with the tree shape of RFC 6962, without its prefixes:
+// the leaves are split at the largest power of two smaller than their number.
+func rfc6962Root(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := 1
+	for k<<1 < len(leaves) {
+		k <<= 1
+	}
+	root, _ := DefaultHashFunc(concatHash(rfc6962Root(leaves[:k]), rfc6962Root(leaves[k:])))
+	return root
+}
+
+func TestPaddingPromote(t *testing.T) {
+	maxSize := 1000
+	if testing.Short() {
+		maxSize = 100
+	}
+	blocks := dataBlocks(maxSize)
+	for n := 2; n <= maxSize; n++ {
+		m, err := New(&Config{PaddingStrategy: PaddingPromote}, blocks[:n])
+		if err != nil {
+			t.Fatalf("New() with %d blocks error = %v", n, err)
+		}
+		if want := rfc6962Root(m.Leaves); !bytes.Equal(m.Root, want) {
+			t.Fatalf("Root of %d blocks = %x, want %x", n, m.Root, want)
+		}
+		shorter := false
+		for i, proof := range m.Proofs {
+			if len(proof.Siblings) > int(m.Depth) {
+				t.Fatalf("proof %d of %d blocks has %d siblings, more than the depth %d",
+					i, n, len(proof.Siblings), m.Depth)
+			}
+			shorter = shorter || len(proof.Siblings) < int(m.Depth)
+			if ok, err := Verify(blocks[i], proof, m.Root, nil); err != nil || !ok {
+				t.Fatalf("Verify() of the proof %d of %d blocks = %v, %v, want true", i, n, ok, err)
+			}
+		}
+		if isPowerOfTwo := n&(n-1) == 0; shorter == isPowerOfTwo {
+			t.Errorf("proofs of %d blocks shorter than the depth: %v, want %v", n, shorter, !isPowerOfTwo)
+		}
+	}
+	for _, n := range []int{2, 3, 5, 6, 7, 13, 100, 257} {
+		if err := CheckConsistency(&Config{PaddingStrategy: PaddingPromote}, blocks[:n]); err != nil {
+			t.Errorf("CheckConsistency() with %d blocks error = %v", n, err)
+		}
+	}
+}
+
+func TestPaddingPromote_treeBuild(t *testing.T) {
+	blocks := dataBlocks(13)
+	m, err := New(&Config{PaddingStrategy: PaddingPromote, Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// 13 leaves: the leaf 12 is promoted at the levels 0 and 1, and its node is paired at the level 2.
+	proof, err := m.Proof(blocks[12])
+	if err != nil {
+		t.Fatalf("Proof() error = %v", err)
+	}
+	if len(proof.Siblings) != 2 || proof.Path != 0 {
+		t.Errorf("Proof() of the leaf 12 = %d siblings and path %b, want 2 siblings and path 0",
+			len(proof.Siblings), proof.Path)
+	}
+	if _, err = m.NodeAt(0, 13); err == nil {
+		t.Error("NodeAt() of the promoted padding: error = nil")
+	}
+	if node, err := m.NodeAt(2, 3); err != nil || !bytes.Equal(node, m.Leaves[12]) {
+		t.Errorf("NodeAt(2, 3) = %x, %v, want the promoted leaf %x", node, err, m.Leaves[12])
+	}
+	report, err := m.ExplainVerification(blocks[12], proof)
+	if err != nil {
+		t.Fatalf("ExplainVerification() error = %v", err)
+	}
+	if !report.Verified() || report.Mismatch() != "" {
+		t.Errorf("ExplainVerification() = verified %v, mismatch %q, want verified", report.Verified(), report.Mismatch())
+	}
+
+	// The updates are identical to a new build with the updated data blocks.
+	m, err = New(&Config{PaddingStrategy: PaddingPromote, Mode: ModeProofGenAndTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	updated := append([]DataBlock(nil), blocks...)
+	newBlocks := dataBlocks(3)
+	updated[3], updated[11], updated[12] = newBlocks[0], newBlocks[1], newBlocks[2]
+	if err = m.UpdateBatch(map[int]DataBlock{3: newBlocks[0], 11: newBlocks[1], 12: newBlocks[2]}); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	want, err := New(&Config{PaddingStrategy: PaddingPromote}, updated)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(m.Root, want.Root) {
+		t.Errorf("Root after UpdateBatch() = %x, want %x", m.Root, want.Root)
+	}
+	for i := range updated {
+		if !m.Proofs[i].Equal(want.Proofs[i]) {
+			t.Errorf("proof %d after UpdateBatch() differs from the proof of a new build", i)
+		}
+	}
+}
+
+func TestPaddingPromote_features(t *testing.T) {
+	blocks := dataBlocks(11)
+	config := &Config{
+		PaddingStrategy:     PaddingPromote,
+		RunInParallel:       true,
+		AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc},
+	}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want, err := New(&Config{PaddingStrategy: PaddingPromote, HashFunc: sha512HashFunc}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if roots := m.AdditionalRoots(); !bytes.Equal(roots[0], want.Root) {
+		t.Errorf("AdditionalRoots() = %x, want [%x]", roots, want.Root)
+	}
+
+	if m, err = New(&Config{PaddingStrategy: PaddingPromote}, blocks); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	restored := new(MerkleTree)
+	if err = json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if restored.PaddingStrategy != PaddingPromote {
+		t.Errorf("restored PaddingStrategy = %v, want %v", restored.PaddingStrategy, PaddingPromote)
+	}
+	for i, block := range blocks {
+		if ok, err := restored.Verify(block, restored.Proofs[i]); err != nil || !ok {
+			t.Errorf("Verify() of the restored proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+}
+
+func TestPaddingStrategy_errors(t *testing.T) {
+	blocks := dataBlocks(5)
+	for _, config := range []*Config{
+		{PaddingStrategy: PaddingPromote, NoDuplicates: true},
+		{PaddingStrategy: TypePaddingStrategy(3)},
+	} {
+		if _, err := New(config, blocks); err == nil {
+			t.Errorf("New() with %v and NoDuplicates %v: error = nil", config.PaddingStrategy, config.NoDuplicates)
+		}
+	}
+	if got := TypePaddingStrategy(3).String(); got != "TypePaddingStrategy(3)" {
+		t.Errorf("String() = %q, want %q", got, "TypePaddingStrategy(3)")
+	}
+}
@@ -0,0 +1,68 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "bytes"
+
+// TypePairHashFunc is the signature of the functions computing the parent node of a sibling pair, see PairHashFunc.
+type TypePairHashFunc func(left, right []byte) ([]byte, error)
+
+// ReversedPairHashFunc returns the pair hash function computing the parent as the hash of the concatenation
+// of the right node and the left node, H(right || left). If the hash function is nil, DefaultHashFunc is used.
+func ReversedPairHashFunc(hashFunc TypeHashFunc) TypePairHashFunc {
+	if hashFunc == nil {
+		hashFunc = DefaultHashFunc
+	}
+	return func(left, right []byte) ([]byte, error) {
+		return hashFunc(concatHash(right, left))
+	}
+}
+
+// SeparatorPairHashFunc returns the pair hash function computing the parent as the hash of the left node
+// and the right node separated by the separator byte, H(left || separator || right).
+// If the hash function is nil, DefaultHashFunc is used.
+func SeparatorPairHashFunc(hashFunc TypeHashFunc, separator byte) TypePairHashFunc {
+	if hashFunc == nil {
+		hashFunc = DefaultHashFunc
+	}
+	return func(left, right []byte) ([]byte, error) {
+		data := make([]byte, 0, len(left)+1+len(right))
+		data = append(append(append(data, left...), separator), right...)
+		return hashFunc(data)
+	}
+}
+
+// pairHash computes the parent node at the level of the sibling pair with PairHashFunc if set, sorting the pair first
+// if SortSiblingPairs is true, and otherwise with the hash function of the level of the concatenation of the pair.
+func (c *Config) pairHash(level int, left, right []byte) ([]byte, error) {
+	if c.PairHashFunc == nil {
+		return c.hashWith(c.levelHash(level), c.concatFunc(left, right))
+	}
+	if c.SortSiblingPairs && bytes.Compare(left, right) >= 0 {
+		left, right = right, left
+	}
+	if c.ParanoidHashInput {
+		left, right = append([]byte(nil), left...), append([]byte(nil), right...)
+	}
+	return c.PairHashFunc(left, right)
+}
@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func TestConfig_PairHashFunc(t *testing.T) {
+	reversed := func(left, right []byte) ([]byte, error) {
+		sum := sha256.Sum256(append(append([]byte(nil), right...), left...))
+		return sum[:], nil
+	}
+	separated := func(left, right []byte) ([]byte, error) {
+		sum := sha256.Sum256(append(append(append([]byte(nil), left...), 0x01), right...))
+		return sum[:], nil
+	}
+	tests := []struct {
+		name         string
+		pairHashFunc TypePairHashFunc
+		want         TypePairHashFunc
+	}{
+		{"reversed", ReversedPairHashFunc(nil), reversed},
+		{"separator", SeparatorPairHashFunc(DefaultHashFunc, 0x01), separated},
+	}
+	modes := []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild}
+	for _, tt := range tests {
+		for _, numBlocks := range []int{2, 4, 7, 100} {
+			blocks := dataBlocks(numBlocks)
+			for _, mode := range modes {
+				for _, parallel := range []bool{false, true} {
+					name := fmt.Sprintf("%s/%d blocks/mode %d/parallel %v", tt.name, numBlocks, mode, parallel)
+					config := &Config{PairHashFunc: tt.pairHashFunc, Mode: mode, RunInParallel: parallel}
+					m, err := New(config, blocks)
+					if err != nil {
+						t.Fatalf("%s: New() error = %v", name, err)
+					}
+					// The root is that of the levels folded with the expected pair hash function.
+					level := append([][]byte(nil), m.Leaves...)
+					for len(level) > 1 {
+						if len(level)&1 == 1 {
+							level = append(level, level[len(level)-1])
+						}
+						for i := 0; i < len(level); i += 2 {
+							if level[i>>1], err = tt.want(level[i], level[i+1]); err != nil {
+								t.Fatalf("%s: pair hash error = %v", name, err)
+							}
+						}
+						level = level[:len(level)>>1]
+					}
+					if !bytes.Equal(m.Root, level[0]) {
+						t.Fatalf("%s: root = %x, want %x", name, m.Root, level[0])
+					}
+					for i, block := range blocks {
+						proof, err := m.GenerateProof(block)
+						if err != nil {
+							t.Fatalf("%s: GenerateProof() error = %v", name, err)
+						}
+						if ok, err := Verify(block, proof, m.Root, config); err != nil || !ok {
+							t.Fatalf("%s: Verify() of block %d = %v, %v, want true", name, i, ok, err)
+						}
+						// The proofs do not verify with the concatenation of the pairs.
+						if ok, _ := Verify(block, proof, m.Root, nil); ok {
+							t.Fatalf("%s: Verify() of block %d without PairHashFunc = true, want false", name, i)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConfig_PairHashFunc_invalid(t *testing.T) {
+	blocks := dataBlocks(5)
+	inconsistent := func(left, right []byte) ([]byte, error) {
+		return left[:16], nil
+	}
+	if _, err := New(&Config{PairHashFunc: inconsistent}, blocks); err == nil {
+		t.Error("New() with a PairHashFunc of inconsistent output size: error = nil")
+	}
+	m, err := New(&Config{PairHashFunc: ReversedPairHashFunc(nil)}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = Verify(blocks[0], m.Proofs[0], m.Root, &Config{PairHashFunc: inconsistent}); err == nil {
+		t.Error("Verify() with a PairHashFunc of inconsistent output size: error = nil")
+	}
+	config := &Config{PairHashFunc: ReversedPairHashFunc(nil), AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}}
+	if _, err = New(config, blocks); err == nil {
+		t.Error("New() with PairHashFunc and AdditionalHashFuncs: error = nil")
+	}
+}
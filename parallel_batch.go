@@ -0,0 +1,66 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "sync/atomic"
+
+// pairBatcher hands out the sibling pairs of a level to the workers in batches, so that the faster workers
+// grab more batches than the slower ones.
+type pairBatcher struct {
+	next      atomic.Int64 // index of the first pair of the next batch
+	numPairs  int
+	batchSize int
+}
+
+// grab returns the node indexes of the first pair and past the last pair of the next batch,
+// and false when all the pairs are handed out.
+func (b *pairBatcher) grab() (start, end int, ok bool) {
+	first := int(b.next.Add(int64(b.batchSize))) - b.batchSize
+	if first >= b.numPairs {
+		return 0, 0, false
+	}
+	return first << 1, min(first+b.batchSize, b.numPairs) << 1, true
+}
+
+// pairTasks returns the tasks of the workers computing the parents of the sibling pairs of a level,
+// one task for each worker, sharing a pairBatcher as the batcher of the argument.
+// The workers grab ParallelBatchSize pairs at a time, or by default, the pairs divided evenly among the workers.
+// The number of tasks never exceeds the number of workers, as the worker pool only takes that many tasks at once.
+// The handlers derive everything from the node indexes of the batches, never from the worker or the batch boundaries,
+// so the tree does not depend on the number of workers or the batch size.
+func (m *MerkleTree) pairTasks(numPairs int, arg argType) []argType {
+	batchSize := m.ParallelBatchSize
+	if batchSize <= 0 {
+		batchSize = (numPairs + m.numWorkers - 1) / m.numWorkers
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	arg.batcher = &pairBatcher{numPairs: numPairs, batchSize: batchSize}
+	numTasks := min(m.numWorkers, (numPairs+batchSize-1)/batchSize)
+	argList := make([]argType, numTasks)
+	for i := range argList {
+		argList[i] = arg
+	}
+	return argList
+}
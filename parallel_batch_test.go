@@ -0,0 +1,95 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestConfig_ParallelBatchSize(t *testing.T) {
+	configs := []Config{
+		{},
+		{PaddingStrategy: PaddingPromote},
+		{NoDuplicates: true},
+		{AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}},
+	}
+	modes := []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild}
+	for _, numBlocks := range []int{9, 100, 1001} {
+		blocks := dataBlocks(numBlocks)
+		for i, config := range configs {
+			for _, mode := range modes {
+				serialConfig := config
+				serialConfig.Mode = mode
+				want, err := New(&serialConfig, blocks)
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				for _, batchSize := range []int{0, 1, 3, 64, 1 << 20} {
+					name := fmt.Sprintf("%d blocks/config %d/mode %d/batch size %d", numBlocks, i, mode, batchSize)
+					parallelConfig := serialConfig
+					parallelConfig.RunInParallel, parallelConfig.NumRoutines = true, 4
+					parallelConfig.ParallelBatchSize = batchSize
+					m, err := New(&parallelConfig, blocks)
+					if err != nil {
+						t.Fatalf("%s: New() error = %v", name, err)
+					}
+					if !bytes.Equal(m.Root, want.Root) {
+						t.Fatalf("%s: root = %x, want %x", name, m.Root, want.Root)
+					}
+					if len(config.AdditionalHashFuncs) > 0 &&
+						!bytes.Equal(m.AdditionalRoots()[0], want.AdditionalRoots()[0]) {
+						t.Fatalf("%s: additional root differs from the serial build", name)
+					}
+					for j := range want.Proofs {
+						if !m.Proofs[j].Equal(want.Proofs[j]) {
+							t.Fatalf("%s: proof %d = %+v, want %+v", name, j, m.Proofs[j], want.Proofs[j])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConfig_ParallelBatchSize_invalid(t *testing.T) {
+	config := &Config{RunInParallel: true, ParallelBatchSize: -1}
+	if _, err := New(config, dataBlocks(5)); err == nil {
+		t.Error("New() with a negative ParallelBatchSize: error = nil")
+	}
+}
+
+func BenchmarkNew_parallelBatchSize(b *testing.B) {
+	blocks := dataBlocks(1 << 20)
+	for _, batchSize := range []int{0, 16, 256, 4096, 65536} {
+		config := &Config{RunInParallel: true, ParallelBatchSize: batchSize}
+		b.Run(fmt.Sprintf("batch_size_%d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := New(config, blocks); err != nil {
+					b.Fatalf("New() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// partitionConfigs returns the supported configurations checked for independence from the number of workers.
+func partitionConfigs() map[string]Config {
+	return map[string]Config{
+		"default":                      {},
+		"SortSiblingPairs":             {SortSiblingPairs: true},
+		"NoDuplicates":                 {NoDuplicates: true},
+		"PaddingPromote":               {PaddingStrategy: PaddingPromote},
+		"PaddingIndexed":               {PaddingStrategy: PaddingIndexed},
+		"BindLeafIndex":                {BindLeafIndex: true},
+		"DisableLeafHashing":           {DisableLeafHashing: true},
+		"AdditionalHashFuncs":          {AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}},
+		"LevelHashFunc":                {LevelHashFunc: twoLevelHashFunc},
+		"DuplicateCollapse":            {OnDuplicateLeaf: DuplicateLeafCollapse},
+		"ParallelBatchSize":            {ParallelBatchSize: 3},
+		"ProofIndices":                 {ProofIndices: []int{0, 1, 499, 500, 1000}},
+		"ShareSiblings":                {ShareSiblings: true},
+		"EmbedFingerprint":             {EmbedFingerprint: true},
+		"ModeTreeBuild":                {Mode: ModeTreeBuild},
+		"ModeProofGenAndTreeBuild":     {Mode: ModeProofGenAndTreeBuild},
+		"ModeTreeBuild/NoDuplicates":   {Mode: ModeTreeBuild, NoDuplicates: true},
+		"ModeTreeBuild/PaddingPromote": {Mode: ModeTreeBuild, PaddingStrategy: PaddingPromote},
+	}
+}
+
+func TestNew_partitionIndependent(t *testing.T) {
+	// The same blocks, with a few duplicates, give the same tree with any number of workers.
+	blocks := dataBlocks(1001)
+	for i := 99; i < len(blocks); i += 100 {
+		blocks[i] = blocks[i-1]
+	}
+	for name, config := range partitionConfigs() {
+		serialConfig := config
+		want, err := New(&serialConfig, blocks)
+		if err != nil {
+			t.Fatalf("%s: New() error = %v", name, err)
+		}
+		for _, numRoutines := range []int{1, 2, 3, 4, 7, 16, 64} {
+			parallelConfig := config
+			parallelConfig.RunInParallel = true
+			parallelConfig.NumRoutines = numRoutines
+			got, err := newWithContext(context.Background(), &parallelConfig, blocks, numRoutines)
+			if err != nil {
+				t.Fatalf("%s/%d routines: New() error = %v", name, numRoutines, err)
+			}
+			if err := sameTree(got, want); err != nil {
+				t.Errorf("%s/%d routines: %v", name, numRoutines, err)
+			}
+		}
+	}
+}
+
+// sameTree returns an error describing the first difference of the roots, leaves, additional roots
+// and proofs of the trees.
+func sameTree(got, want *MerkleTree) error {
+	if !bytes.Equal(got.Root, want.Root) {
+		return fmt.Errorf("Root = %x, want %x", got.Root, want.Root)
+	}
+	if len(got.Leaves) != len(want.Leaves) {
+		return fmt.Errorf("%d leaves, want %d", len(got.Leaves), len(want.Leaves))
+	}
+	for i := range want.Leaves {
+		if !bytes.Equal(got.Leaves[i], want.Leaves[i]) {
+			return fmt.Errorf("Leaves[%d] = %x, want %x", i, got.Leaves[i], want.Leaves[i])
+		}
+	}
+	gotRoots, wantRoots := got.AdditionalRoots(), want.AdditionalRoots()
+	if len(gotRoots) != len(wantRoots) {
+		return fmt.Errorf("%d additional roots, want %d", len(gotRoots), len(wantRoots))
+	}
+	for k := range wantRoots {
+		if !bytes.Equal(gotRoots[k], wantRoots[k]) {
+			return fmt.Errorf("AdditionalRoots()[%d] = %x, want %x", k, gotRoots[k], wantRoots[k])
+		}
+	}
+	for i := 0; i < want.NumLeaves; i++ {
+		gotProof, gotErr := got.ProofOf(i)
+		wantProof, wantErr := want.ProofOf(i)
+		if (gotErr != nil) != (wantErr != nil) {
+			return fmt.Errorf("ProofOf(%d) error = %v, want %v", i, gotErr, wantErr)
+		}
+		if wantErr == nil && !gotProof.Equal(wantProof) {
+			return fmt.Errorf("ProofOf(%d) = %+v, want %+v", i, gotProof, wantProof)
+		}
+	}
+	return nil
+}
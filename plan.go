@@ -0,0 +1,159 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// sliceHeaderSize is the size in bytes of a slice header on 64-bit platforms.
+	sliceHeaderSize = 24
+	// proofSize is the size in bytes of a Proof and of its pointer in the proofs slice on 64-bit platforms.
+	proofSize = 72
+	// proofPointerSize is the size in bytes of a pointer in the proofs slice on 64-bit platforms.
+	proofPointerSize = 8
+	// leafMapEntrySize is the estimated overhead in bytes of an entry of the leaf map, excluding the key bytes.
+	leafMapEntrySize = 64
+)
+
+// BuildPlan describes the Merkle Tree New would build for a number of data blocks, as returned by Plan.
+type BuildPlan struct {
+	// NumLeaves is the number of leaves, and PaddedLeaves the number of leaves after padding the leaf level
+	// to an even number, unless the padding strategy is PaddingPromote, or to a power of two with PaddingIndexed.
+	NumLeaves    int
+	PaddedLeaves int
+	// Depth is the Merkle Tree depth.
+	Depth uint32
+	// HashSize is the size of the hash outputs, probed by hashing an empty input.
+	HashSize int
+	// LeafHashes is the number of leaf hashes, and NodeHashes the number of internal node hashes,
+	// including the root and the padding nodes of NoDuplicates, under HashFunc and every additional hash function.
+	LeafHashes int
+	NodeHashes int
+	// EstimatedMemory is the estimated size in bytes of the leaves, proofs and tree nodes retained by the
+	// Merkle Tree on 64-bit platforms, excluding the data blocks and the transient buffers of the build.
+	// With DisableLeafHashing, the leaves are assumed to be of ExpectLeafSize bytes if set,
+	// otherwise of the hash size.
+	EstimatedMemory int64
+	// Parallel reports whether the build runs in parallel, and NumWorkers is the number of workers it uses.
+	Parallel   bool
+	NumWorkers int
+}
+
+// Plan returns the plan of the Merkle Tree New would build with the configuration for the number of data blocks,
+// without any data block, e.g. to decide on the capacity before a build.
+// The configuration is checked as by New, and is not modified.
+func Plan(config *Config, numBlocks int) (*BuildPlan, error) {
+	if numBlocks <= 1 {
+		return nil, errors.New("the number of data blocks must be greater than 1")
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if c.AutoParallel {
+		c.RunInParallel = numBlocks >= AutoParallelMinBlocks
+	}
+	m := &MerkleTree{Config: &c}
+	m.initConfig()
+	if err := m.checkPaddingStrategy(); err != nil {
+		return nil, err
+	}
+	if m.RootBytes < 0 {
+		return nil, fmt.Errorf("invalid root length %d", m.RootBytes)
+	}
+	if m.Config.Mode == 0 {
+		m.Config.Mode = ModeProofGen
+	}
+	probe, err := m.HashFunc([]byte{})
+	if err != nil {
+		return nil, err
+	}
+	plan := &BuildPlan{
+		NumLeaves:    numBlocks,
+		PaddedLeaves: numBlocks,
+		Depth:        calTreeDepth(numBlocks),
+		HashSize:     len(probe),
+		Parallel:     m.RunInParallel,
+		NumWorkers:   1,
+	}
+	if m.RunInParallel {
+		plan.NumWorkers = m.numWorkers
+	}
+	// treeLeaves is the number of leaves of the tree, including the padding leaves of PaddingIndexed.
+	treeLeaves := numBlocks
+	if m.PaddingStrategy == PaddingIndexed {
+		treeLeaves = indexedPaddingLen(numBlocks)
+		plan.PaddedLeaves = treeLeaves
+	} else if numBlocks&1 == 1 && m.PaddingStrategy != PaddingPromote {
+		plan.PaddedLeaves++
+	}
+	numFuncs := 1 + len(m.AdditionalHashFuncs)
+	if !m.DisableLeafHashing {
+		plan.LeafHashes = numBlocks * numFuncs
+	}
+	plan.LeafHashes += treeLeaves - numBlocks
+	leafSize := plan.HashSize
+	if m.DisableLeafHashing && m.ExpectLeafSize > 0 {
+		leafSize = m.ExpectLeafSize
+		if m.BindLeafIndex {
+			leafSize += 8
+		}
+	}
+	var (
+		numLeaves = int64(treeLeaves)
+		hashSize  = int64(plan.HashSize)
+		memory    = numLeaves * int64(sliceHeaderSize+leafSize)
+	)
+	plan.NodeHashes = estimatePairHashes(treeLeaves, m.PaddingStrategy) * numFuncs
+	for level := 0; level < int(plan.Depth); level++ {
+		levelLen := (treeLeaves + 1<<level - 1) >> level
+		if levelLen&1 == 1 && m.NoDuplicates {
+			// The padding node is hashed from the last node.
+			plan.NodeHashes += numFuncs
+		}
+		if m.Config.Mode != ModeProofGen {
+			paddedLen := int64(paddedLevelCap(levelLen))
+			memory += paddedLen * sliceHeaderSize
+			if level > 0 {
+				memory += int64(levelLen) * hashSize
+			}
+		}
+	}
+	if m.Config.Mode != ModeProofGen {
+		memory += numLeaves * (leafMapEntrySize + int64(leafSize))
+	}
+	if m.Config.Mode != ModeTreeBuild {
+		// The proofs slice has a pointer for every leaf, but only the proofs selected by ProofIndices if set.
+		numProofs := numLeaves
+		if m.ProofIndices != nil {
+			numProofs = int64(numSelected(m.ProofIndices, treeLeaves))
+		}
+		memory += numLeaves*proofPointerSize +
+			numProofs*(proofSize-proofPointerSize+int64(plan.Depth)*(sliceHeaderSize+hashSize))
+	}
+	plan.EstimatedMemory = memory
+	return plan, nil
+}
@@ -0,0 +1,122 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	var numHashes atomic.Int64
+	countingHashFunc := func(data []byte) ([]byte, error) {
+		numHashes.Add(1)
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}
+	configs := []Config{
+		{},
+		{PaddingStrategy: PaddingPromote},
+		{NoDuplicates: true},
+		{Mode: ModeTreeBuild, SortSiblingPairs: true},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, NumRoutines: 3},
+		{DisableLeafHashing: true},
+		{AdditionalHashFuncs: []TypeHashFunc{countingHashFunc}},
+		{AdditionalHashFuncs: []TypeHashFunc{countingHashFunc}, PaddingStrategy: PaddingPromote},
+	}
+	for _, numBlocks := range []int{2, 3, 5, 8, 13, 100, 1000} {
+		for _, config := range configs {
+			config := config
+			config.HashFunc = countingHashFunc
+			name := fmt.Sprintf("%d blocks/%v/padding %v/no duplicates %v/additional %d", numBlocks,
+				config.Mode, config.PaddingStrategy, config.NoDuplicates, len(config.AdditionalHashFuncs))
+			plan, err := Plan(&config, numBlocks)
+			if err != nil {
+				t.Fatalf("%s: Plan() error = %v", name, err)
+			}
+			numHashes.Store(0)
+			m, err := New(&config, dataBlocks(numBlocks))
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if plan.Depth != m.Depth {
+				t.Errorf("%s: planned Depth = %d, want %d", name, plan.Depth, m.Depth)
+			}
+			if got := int64(plan.LeafHashes + plan.NodeHashes); got != numHashes.Load() {
+				t.Errorf("%s: planned hashes = %d, want %d", name, got, numHashes.Load())
+			}
+			if plan.HashSize != m.HashSize() {
+				t.Errorf("%s: planned HashSize = %d, want %d", name, plan.HashSize, m.HashSize())
+			}
+			numWorkers := 1
+			if config.RunInParallel {
+				numWorkers = m.numWorkers
+			}
+			if plan.Parallel != config.RunInParallel || plan.NumWorkers != numWorkers {
+				t.Errorf("%s: planned Parallel, NumWorkers = %v, %d, want %v, %d",
+					name, plan.Parallel, plan.NumWorkers, config.RunInParallel, numWorkers)
+			}
+			if plan.EstimatedMemory <= 0 {
+				t.Errorf("%s: planned EstimatedMemory = %d, want positive", name, plan.EstimatedMemory)
+			}
+		}
+	}
+}
+
+func TestPlan_paddedLeaves(t *testing.T) {
+	tests := []struct {
+		config    *Config
+		numBlocks int
+		want      int
+	}{
+		{nil, 4, 4},
+		{nil, 5, 6},
+		{&Config{NoDuplicates: true}, 7, 8},
+		{&Config{PaddingStrategy: PaddingPromote}, 5, 5},
+	}
+	for _, tt := range tests {
+		plan, err := Plan(tt.config, tt.numBlocks)
+		if err != nil {
+			t.Fatalf("Plan() error = %v", err)
+		}
+		if plan.PaddedLeaves != tt.want {
+			t.Errorf("Plan(%d).PaddedLeaves = %d, want %d", tt.numBlocks, plan.PaddedLeaves, tt.want)
+		}
+	}
+	small, _ := Plan(nil, 100)
+	large, _ := Plan(nil, 10000)
+	if large.EstimatedMemory <= small.EstimatedMemory {
+		t.Errorf("EstimatedMemory of 10000 blocks = %d, want more than %d for 100 blocks",
+			large.EstimatedMemory, small.EstimatedMemory)
+	}
+	for _, config := range []*Config{{PaddingStrategy: 7}, {RootBytes: -1}} {
+		if _, err := Plan(config, 10); err == nil {
+			t.Errorf("Plan(%+v) error = nil", config)
+		}
+	}
+	if _, err := Plan(nil, 1); err == nil {
+		t.Error("Plan() of 1 data block: error = nil")
+	}
+}
@@ -0,0 +1,266 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+const (
+	// proofBinaryVersion is the version byte of the binary proof encoding.
+	proofBinaryVersion = 1
+	// proofBinaryVersionHashID is the version byte of the binary proof encoding carrying a hash id.
+	proofBinaryVersionHashID = 2
+)
+
+// Size returns the exact size in bytes of the binary encoding of the proof by MarshalBinary.
+//
+// The encoding is the version byte, the path as a big-endian uint32, the leaf index as a uvarint,
+// the number of siblings as a uvarint followed by each sibling prefixed with its length as a uvarint,
+// and the fingerprint prefixed with its length as a uvarint.
+// If the proof has a hash id, the version byte is 2 and is followed by the hash id, otherwise it is 1.
+func (p *Proof) Size() int {
+	size := 1 + 4 + uvarintLen(uint64(p.Index)) + uvarintLen(uint64(len(p.Siblings)))
+	for _, sibling := range p.Siblings {
+		size += uvarintLen(uint64(len(sibling))) + len(sibling)
+	}
+	if p.HashID != 0 {
+		size++
+	}
+	return size + uvarintLen(uint64(len(p.Fingerprint))) + len(p.Fingerprint)
+}
+
+// MarshalBinary encodes the proof in the binary encoding described in Size.
+// The encoding has no orientation, so root-to-leaf proofs must be reversed before being encoded.
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	if p.Index < 0 {
+		return nil, fmt.Errorf("invalid leaf index %d", p.Index)
+	}
+	if p.RootToLeaf {
+		return nil, errors.New("proof is root-to-leaf, reverse it before encoding")
+	}
+	data := make([]byte, 0, p.Size())
+	if p.HashID != 0 {
+		data = append(data, proofBinaryVersionHashID, p.HashID)
+	} else {
+		data = append(data, proofBinaryVersion)
+	}
+	data = binary.BigEndian.AppendUint32(data, p.Path)
+	data = binary.AppendUvarint(data, uint64(p.Index))
+	data = binary.AppendUvarint(data, uint64(len(p.Siblings)))
+	for _, sibling := range p.Siblings {
+		data = binary.AppendUvarint(data, uint64(len(sibling)))
+		data = append(data, sibling...)
+	}
+	data = binary.AppendUvarint(data, uint64(len(p.Fingerprint)))
+	return append(data, p.Fingerprint...), nil
+}
+
+// UnmarshalBinary decodes the proof from the binary encoding described in Size.
+// The siblings are copied into one buffer, so the proof does not reference the data.
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 {
+		return errors.New("proof data is too short")
+	}
+	var hashID byte
+	switch data[0] {
+	case proofBinaryVersion:
+		data = data[1:]
+	case proofBinaryVersionHashID:
+		if len(data) < 6 {
+			return errors.New("proof data is too short")
+		}
+		if hashID = data[1]; hashID == 0 {
+			return errors.New("invalid hash id 0 in proof data")
+		}
+		data = data[2:]
+	default:
+		return fmt.Errorf("unsupported proof encoding version %d", data[0])
+	}
+	path := binary.BigEndian.Uint32(data[:4])
+	d := proofDecoder{data: data[4:]}
+	index := d.uvarint()
+	numSiblings := d.uvarint()
+	if d.err == nil && (index > uint64(maxInt) || numSiblings > uint64(len(d.data))) {
+		return errors.New("invalid proof data")
+	}
+	siblings := make([][]byte, 0, int(numSiblings))
+	for i := uint64(0); i < numSiblings && d.err == nil; i++ {
+		siblings = append(siblings, d.bytes())
+	}
+	fingerprint := d.bytes()
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) != 0 {
+		return errors.New("trailing bytes after the proof data")
+	}
+	p.Path, p.Index, p.Siblings, p.Fingerprint, p.HashID = path, int(index), compactSiblings(siblings), nil, hashID
+	p.RootToLeaf = false
+	if fingerprint != nil {
+		p.Fingerprint = append([]byte(nil), fingerprint...)
+	}
+	return nil
+}
+
+// DecodeProof decodes the proof from the binary encoding described in Size, which carries the length
+// of each sibling, so no hash size has to be known in advance, e.g. to decode the proofs of producers
+// using hash functions of different output sizes with one decode path.
+// Unlike UnmarshalBinary, it returns an error wrapping ErrInconsistentHashSize if the siblings have different
+// sizes, so the proof is verified by Verify with any hash function whose outputs are of the sibling size,
+// and Verify reports a hash function of another output size with ErrInconsistentHashSize.
+func DecodeProof(data []byte) (*Proof, error) {
+	proof := new(Proof)
+	if err := proof.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	for i, sibling := range proof.Siblings {
+		if len(sibling) == 0 {
+			return nil, fmt.Errorf("empty sibling at position %d in proof data", i)
+		}
+		if len(sibling) != len(proof.Siblings[0]) {
+			return nil, fmt.Errorf("%w: sibling at position %d is %d bytes but sibling at position 0 is %d bytes",
+				ErrInconsistentHashSize, i, len(sibling), len(proof.Siblings[0]))
+		}
+	}
+	return proof, nil
+}
+
+// VerifyAuto verifies the data block with the binary encoded proof and the Merkle root, with the hash function
+// registered under the hash id of the proof, e.g. to verify proofs of remote systems using various hash functions.
+// The other configuration fields are the defaults, so the proofs of trees built with e.g. SortSiblingPairs
+// must be verified with Verify. It returns an error if the proof has no hash id or the id is not registered.
+func VerifyAuto(dataBlock DataBlock, serializedProof []byte, root []byte) (bool, error) {
+	if serializedProof == nil {
+		return false, errNilInput("proof")
+	}
+	if err := checkVerifyInput(dataBlock, &Proof{}, root); err != nil {
+		return false, err
+	}
+	var proof Proof
+	if err := proof.UnmarshalBinary(serializedProof); err != nil {
+		return false, err
+	}
+	if proof.HashID == 0 {
+		return false, errors.New("proof has no hash id, could not select the hash function")
+	}
+	hashFunc, ok := HashFuncByID(proof.HashID)
+	if !ok {
+		return false, fmt.Errorf("hash function id %d is not registered", proof.HashID)
+	}
+	return Verify(dataBlock, &proof, root, &Config{HashFunc: hashFunc})
+}
+
+// maxInt is the maximum value of int.
+const maxInt = int(^uint(0) >> 1)
+
+// proofDecoder reads the length-prefixed fields of the binary proof encoding, keeping the first error.
+type proofDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *proofDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errors.New("invalid uvarint in proof data")
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *proofDecoder) bytes() []byte {
+	n := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.data)) {
+		d.err = errors.New("proof data is too short")
+		return nil
+	}
+	if n == 0 {
+		return nil
+	}
+	b := d.data[:n:n]
+	d.data = d.data[n:]
+	return b
+}
+
+// compactSiblings copies the siblings into one contiguous buffer.
+// Each sibling is capped to its length, so appending to a sibling never overwrites the next one.
+func compactSiblings(siblings [][]byte) [][]byte {
+	total := 0
+	for _, sibling := range siblings {
+		total += len(sibling)
+	}
+	buf := make([]byte, total)
+	offset := 0
+	for i, sibling := range siblings {
+		end := offset + copy(buf[offset:], sibling)
+		siblings[i] = buf[offset:end:end]
+		offset = end
+	}
+	return siblings
+}
+
+// uvarintLen returns the length of the uvarint encoding of v.
+func uvarintLen(v uint64) int {
+	n := 1
+	for ; v >= 0x80; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+// MemoryFootprint estimates the heap memory in bytes used by the proof: the Proof struct,
+// the slice headers of the siblings, and the bytes referenced by the siblings and the fingerprint.
+// Bytes shared with other proofs or with the tree nodes are counted in full.
+func (p *Proof) MemoryFootprint() int {
+	size := int(unsafe.Sizeof(*p)) + cap(p.Siblings)*int(unsafe.Sizeof([]byte(nil))) + cap(p.Fingerprint)
+	for _, sibling := range p.Siblings {
+		size += cap(sibling)
+	}
+	return size
+}
+
+// ProofsMemoryFootprint estimates the heap memory in bytes used by the proofs of the Merkle Tree,
+// including the slice of proof pointers.
+// In ModeProofGen, the siblings and the fingerprint of each proof are stored in one buffer owned by the proof,
+// so the estimate is tight, unless ShareSiblings is true. In ModeProofGenAndTreeBuild, the siblings are shared
+// with the tree nodes.
+func (m *MerkleTree) ProofsMemoryFootprint() int {
+	size := cap(m.Proofs) * int(unsafe.Sizeof((*Proof)(nil)))
+	for _, proof := range m.Proofs {
+		if proof != nil {
+			size += proof.MemoryFootprint()
+		}
+	}
+	return size
+}
@@ -0,0 +1,295 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func TestProof_MarshalBinary(t *testing.T) {
+	blocks := dataBlocks(13)
+	for _, config := range []*Config{
+		nil,
+		{EmbedFingerprint: true, RunInParallel: true},
+		{DisableLeafHashing: true, Mode: ModeProofGenAndTreeBuild},
+		{BindLeafIndex: true, NoDuplicates: true},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, proof := range m.Proofs {
+			data, err := proof.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			if proof.Size() != len(data) {
+				t.Errorf("Size() = %d, want the encoding length %d", proof.Size(), len(data))
+			}
+			decoded := new(Proof)
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			if !decoded.Equal(proof) {
+				t.Errorf("UnmarshalBinary() of proof %d = %+v, want %+v", i, decoded, proof)
+			}
+			if ok, err := Verify(blocks[i], decoded, m.Root, m.Config); err != nil || !ok {
+				t.Errorf("Verify() of the decoded proof %d = %v, %v, want true", i, ok, err)
+			}
+		}
+	}
+}
+
+func TestProof_UnmarshalBinary_invalid(t *testing.T) {
+	m, err := New(&Config{EmbedFingerprint: true}, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := m.Proofs[3].MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"wrong_version", append([]byte{proofBinaryVersionHashID + 1}, data[1:]...)},
+		{"zero_hash_id", append([]byte{proofBinaryVersionHashID, 0}, data[1:]...)},
+		{"truncated", data[:len(data)-1]},
+		{"truncated_sibling", data[:20]},
+		{"trailing_bytes", append(append([]byte(nil), data...), 0)},
+		{"too_many_siblings", []byte{proofBinaryVersion, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0x0f}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := new(Proof).UnmarshalBinary(tt.data); err == nil {
+				t.Errorf("UnmarshalBinary() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDecodeProof(t *testing.T) {
+	blocks := dataBlocks(11)
+	for _, hashFunc := range []TypeHashFunc{DefaultHashFunc, sha512HashFunc} {
+		m, err := New(&Config{HashFunc: hashFunc}, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, proof := range m.Proofs {
+			data, err := proof.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			decoded, err := DecodeProof(data)
+			if err != nil {
+				t.Fatalf("DecodeProof() error = %v", err)
+			}
+			if len(decoded.Siblings[0]) != m.HashSize() {
+				t.Errorf("DecodeProof() sibling size = %d, want %d", len(decoded.Siblings[0]), m.HashSize())
+			}
+			if ok, err := Verify(blocks[i], decoded, m.Root, &Config{HashFunc: hashFunc}); err != nil || !ok {
+				t.Errorf("Verify() of the decoded proof %d = %v, %v, want true", i, ok, err)
+			}
+		}
+	}
+
+	// A hash function of another output size is reported by Verify.
+	m, err := New(&Config{HashFunc: sha512HashFunc}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := m.Proofs[4].MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	decoded, err := DecodeProof(data)
+	if err != nil {
+		t.Fatalf("DecodeProof() error = %v", err)
+	}
+	if _, err := Verify(blocks[4], decoded, m.Root, nil); !errors.Is(err, ErrInconsistentHashSize) {
+		t.Errorf("Verify() with a 32-byte hash function error = %v, want ErrInconsistentHashSize", err)
+	}
+}
+
+func TestDecodeProof_invalid(t *testing.T) {
+	mixed := &Proof{Siblings: [][]byte{make([]byte, 32), make([]byte, 64)}, Path: 1}
+	data, err := mixed.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	if _, err := DecodeProof(data); !errors.Is(err, ErrInconsistentHashSize) {
+		t.Errorf("DecodeProof() of mixed sibling sizes error = %v, want ErrInconsistentHashSize", err)
+	}
+	empty := &Proof{Siblings: [][]byte{make([]byte, 32), nil}}
+	if data, err = empty.MarshalBinary(); err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	if _, err := DecodeProof(data); err == nil {
+		t.Errorf("DecodeProof() of an empty sibling error = nil, want error")
+	}
+	if _, err := DecodeProof([]byte{proofBinaryVersion}); err == nil {
+		t.Errorf("DecodeProof() of truncated data error = nil, want error")
+	}
+}
+
+func TestVerifyAuto(t *testing.T) {
+	const (
+		sha512ID     byte = 200
+		sha512_256ID byte = 201
+	)
+	for id, hashFunc := range map[byte]TypeHashFunc{sha512ID: sha512HashFunc, sha512_256ID: sha512_256HashFunc} {
+		if _, ok := HashFuncByID(id); !ok {
+			if err := RegisterHashFunc(id, hashFunc); err != nil {
+				t.Fatalf("RegisterHashFunc() error = %v", err)
+			}
+		}
+	}
+	blocks := dataBlocks(6)
+	for _, config := range []*Config{
+		{EmbedFingerprint: true},
+		{HashFunc: sha512HashFunc, HashID: sha512ID, EmbedFingerprint: true},
+		{HashFunc: sha512_256HashFunc, HashID: sha512_256ID, EmbedFingerprint: true},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		id := m.HashID
+		for i, proof := range m.Proofs {
+			proof.HashID = id
+			data, err := proof.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			if proof.Size() != len(data) {
+				t.Errorf("Size() with a hash id = %d, want the encoding length %d", proof.Size(), len(data))
+			}
+			if ok, err := VerifyAuto(blocks[i], data, m.Root); err != nil || !ok {
+				t.Errorf("VerifyAuto() of proof %d with hash id %d = %v, %v, want true", i, id, ok, err)
+			}
+			if ok, _ := VerifyAuto(blocks[(i+1)%len(blocks)], data, m.Root); ok {
+				t.Errorf("VerifyAuto() of another data block with proof %d = true, want false", i)
+			}
+		}
+	}
+
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	proof := *m.Proofs[0]
+	for _, id := range []byte{0, 255} {
+		proof.HashID = id
+		data, err := proof.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary() error = %v", err)
+		}
+		if _, err = VerifyAuto(blocks[0], data, m.Root); err == nil {
+			t.Errorf("VerifyAuto() of a proof with hash id %d: error = nil", id)
+		}
+	}
+	if err = RegisterHashFunc(HashIDSHA256, mockHashFunc); err == nil {
+		t.Error("RegisterHashFunc() of a registered id: error = nil")
+	}
+	if err = RegisterHashFunc(0, mockHashFunc); err == nil {
+		t.Error("RegisterHashFunc() of the id 0: error = nil")
+	}
+}
+
+func TestVerifyAuto_closures(t *testing.T) {
+	// The closures of the same function literal are told apart by their ids.
+	prefixed := func(prefix byte) TypeHashFunc {
+		return func(data []byte) ([]byte, error) {
+			return DefaultHashFunc(append([]byte{prefix}, data...))
+		}
+	}
+	ids := []byte{210, 211}
+	for i, id := range ids {
+		if _, ok := HashFuncByID(id); !ok {
+			if err := RegisterHashFunc(id, prefixed(byte(i+1))); err != nil {
+				t.Fatalf("RegisterHashFunc() error = %v", err)
+			}
+		}
+	}
+	blocks := dataBlocks(5)
+	for i, id := range ids {
+		m, err := New(&Config{HashFunc: prefixed(byte(i + 1)), HashID: id}, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		proof := *m.Proofs[3]
+		proof.HashID = m.HashID
+		data, err := proof.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary() error = %v", err)
+		}
+		if ok, err := VerifyAuto(blocks[3], data, m.Root); err != nil || !ok {
+			t.Errorf("VerifyAuto() with hash id %d = %v, %v, want true", id, ok, err)
+		}
+	}
+}
+
+func TestProof_MemoryFootprint(t *testing.T) {
+	const numLeaves = 100
+	for _, parallel := range []bool{false, true} {
+		m, err := New(&Config{RunInParallel: parallel, EmbedFingerprint: true}, dataBlocks(numLeaves))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		want := numLeaves * int(unsafe.Sizeof((*Proof)(nil)))
+		for _, proof := range m.Proofs {
+			// The siblings and the fingerprint of a proof are stored in one contiguous buffer.
+			buf := unsafe.Pointer(&proof.Siblings[0][0])
+			for level, sibling := range proof.Siblings {
+				if unsafe.Pointer(&sibling[0]) != unsafe.Add(buf, level*defaultHashLen) {
+					t.Fatalf("sibling at level %d of proof %d is not in the proof buffer", level, proof.Index)
+				}
+			}
+			if unsafe.Pointer(&proof.Fingerprint[0]) != unsafe.Add(buf, int(m.Depth)*defaultHashLen) {
+				t.Fatalf("fingerprint of proof %d is not in the proof buffer", proof.Index)
+			}
+			footprint := int(unsafe.Sizeof(Proof{})) + int(m.Depth)*(int(unsafe.Sizeof([]byte(nil)))+defaultHashLen) +
+				fingerprintLen
+			if got := proof.MemoryFootprint(); got != footprint {
+				t.Errorf("MemoryFootprint() = %d, want %d", got, footprint)
+			}
+			want += footprint
+		}
+		if got := m.ProofsMemoryFootprint(); got != want {
+			t.Errorf("ProofsMemoryFootprint() = %d, want %d", got, want)
+		}
+	}
+	// Each proof allocates the Proof struct, the sibling slice headers and one buffer for the sibling bytes.
+	m := &MerkleTree{Config: &Config{}, NumLeaves: numLeaves, Depth: calTreeDepth(numLeaves)}
+	allocs := testing.AllocsPerRun(10, func() {
+		m.initProofs()
+		m.initProofBuffers(defaultHashLen)
+	})
+	if want := float64(3*numLeaves + 2); allocs != want {
+		t.Errorf("proof allocations = %v, want %v", allocs, want)
+	}
+}
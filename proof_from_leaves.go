@@ -0,0 +1,166 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GenerateProofFromLeaves computes the proof of the leaf at the target index and the Merkle root
+// from the leaves, e.g. as returned by HashLeaves, without building a Merkle Tree.
+// The proof and the root are identical to those of the tree built by New with the same configuration
+// for the data blocks of the leaves.
+// The leaves are folded in a single pass from left to right, keeping at most one pending node per level
+// and the siblings of the proof, so the memory beyond the leaves is O(log n).
+// The computation is serial and OnNode is not called. The configuration is not modified.
+func GenerateProofFromLeaves(config *Config, leafHashes [][]byte, targetIndex int) (*Proof, []byte, error) {
+	if len(leafHashes) <= 1 {
+		return nil, nil, errors.New("the number of leaves must be greater than 1")
+	}
+	if targetIndex < 0 || targetIndex >= len(leafHashes) {
+		return nil, nil, fmt.Errorf("leaf index %d is out of range [0, %d)", targetIndex, len(leafHashes))
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	m := &MerkleTree{Config: &c, NumLeaves: len(leafHashes), Depth: calTreeDepth(len(leafHashes))}
+	m.initConfig()
+	if err := m.checkPaddingStrategy(); err != nil {
+		return nil, nil, err
+	}
+	if m.PaddingStrategy == PaddingIndexed {
+		return nil, nil, errors.New("proofs from leaves are not supported with PaddingIndexed")
+	}
+	if m.RootBytes < 0 {
+		return nil, nil, fmt.Errorf("invalid root length %d", m.RootBytes)
+	}
+	f := &proofFolder{
+		m:        m,
+		target:   targetIndex,
+		pending:  make([][]byte, m.Depth+1),
+		counts:   make([]int, m.Depth+1),
+		siblings: make([][]byte, m.Depth),
+		onRight:  make([]bool, m.Depth),
+	}
+	for i, leaf := range leafHashes {
+		if len(leaf) == 0 {
+			return nil, nil, fmt.Errorf("leaf %d is empty", i)
+		}
+		if !m.DisableLeafHashing {
+			if err := m.checkHashSize(leaf); err != nil {
+				return nil, nil, fmt.Errorf("leaf %d: %w", i, err)
+			}
+		}
+		if err := f.push(0, leaf); err != nil {
+			return nil, nil, err
+		}
+	}
+	root, err := f.finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := &Proof{Index: targetIndex, Siblings: make([][]byte, 0, m.Depth)}
+	for level, sibling := range f.siblings {
+		// The nil sibling of a promoted node is not part of the proof.
+		if sibling == nil {
+			continue
+		}
+		if f.onRight[level] {
+			proof.Path |= 1 << len(proof.Siblings)
+		}
+		proof.Siblings = append(proof.Siblings, sibling)
+	}
+	if m.EmbedFingerprint {
+		if proof.Fingerprint, err = configFingerprint(m.Config); err != nil {
+			return nil, nil, err
+		}
+	}
+	return proof, truncateRoot(root, m.Config), nil
+}
+
+// proofFolder folds the nodes of a Merkle Tree level by level as they are pushed from left to right,
+// recording the siblings on the path of the target leaf, if the target is not negative.
+type proofFolder struct {
+	m      *MerkleTree
+	target int
+	// pending is the left node waiting for its right sibling at each level, nil if there is none,
+	// and counts is the number of nodes pushed at each level. They grow with the levels pushed to.
+	pending [][]byte
+	counts  []int
+	// siblings are the siblings of the target path at each level, and onRight reports whether they are on the right.
+	siblings [][]byte
+	onRight  []bool
+}
+
+// push appends the node to the level, hashing it with its left sibling into the parent level if it is a right node.
+func (f *proofFolder) push(level int, node []byte) error {
+	if level == len(f.counts) {
+		f.pending, f.counts = append(f.pending, nil), append(f.counts, 0)
+	}
+	idx := f.counts[level]
+	f.counts[level]++
+	if f.target >= 0 {
+		f.record(level, idx, node)
+	}
+	if idx&1 == 0 {
+		f.pending[level] = node
+		return nil
+	}
+	parent, err := f.m.hashPair(level+1, f.pending[level], node)
+	if err != nil {
+		return err
+	}
+	f.pending[level] = nil
+	return f.push(level+1, parent)
+}
+
+// record stores the node as a sibling if it is the sibling of the node of the target path at the level.
+func (f *proofFolder) record(level, idx int, node []byte) {
+	if pathIdx := f.target >> level; idx == pathIdx^1 {
+		f.siblings[level], f.onRight[level] = node, idx > pathIdx
+	}
+}
+
+// finish pads the odd levels below Depth as fixOdd does, from the leaf level up, and returns the root,
+// i.e. the only node at level Depth.
+func (f *proofFolder) finish() ([]byte, error) {
+	for level := 0; level < int(f.m.Depth); level++ {
+		if f.counts[level]&1 == 0 {
+			continue
+		}
+		buf, _, err := f.m.fixOdd([][]byte{f.pending[level]}, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err = f.push(level, buf[1]); err != nil {
+			return nil, err
+		}
+	}
+	depth := int(f.m.Depth)
+	if depth >= len(f.counts) || f.counts[depth] != 1 {
+		return nil, errors.New("leaves did not fold into a root")
+	}
+	return f.pending[depth], nil
+}
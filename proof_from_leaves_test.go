@@ -0,0 +1,108 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestGenerateProofFromLeaves(t *testing.T) {
+	configs := []*Config{
+		nil,
+		{PaddingStrategy: PaddingPromote},
+		{SortSiblingPairs: true, EmbedFingerprint: true},
+		{BindLeafIndex: true, RootBytes: 16},
+		{DisableLeafHashing: true},
+		{DisableLeafHashing: true, PaddingStrategy: PaddingPromote},
+	}
+	for _, numBlocks := range []int{2, 3, 5, 8, 13, 33} {
+		blocks := dataBlocks(numBlocks)
+		for _, config := range configs {
+			name := fmt.Sprintf("%d blocks/%+v", numBlocks, config)
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			for i := range blocks {
+				proof, root, err := GenerateProofFromLeaves(config, m.Leaves, i)
+				if err != nil {
+					t.Fatalf("%s: GenerateProofFromLeaves() error = %v", name, err)
+				}
+				if !bytes.Equal(root, m.Root) {
+					t.Errorf("%s: GenerateProofFromLeaves() root = %x, want %x", name, root, m.Root)
+				}
+				if !proof.Equal(m.Proofs[i]) {
+					t.Errorf("%s: GenerateProofFromLeaves() proof %d = %+v, want %+v", name, i, proof, m.Proofs[i])
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateProofFromLeaves_noDuplicates(t *testing.T) {
+	config := &Config{NoDuplicates: true}
+	blocks := dataBlocks(11)
+	leaves, err := HashLeaves(config, blocks)
+	if err != nil {
+		t.Fatalf("HashLeaves() error = %v", err)
+	}
+	for i := range blocks {
+		proof, root, err := GenerateProofFromLeaves(config, leaves, i)
+		if err != nil {
+			t.Fatalf("GenerateProofFromLeaves() error = %v", err)
+		}
+		if ok, err := Verify(blocks[i], proof, root, config); err != nil || !ok {
+			t.Errorf("Verify() of proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+}
+
+func TestGenerateProofFromLeaves_invalid(t *testing.T) {
+	leaves, err := HashLeaves(nil, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("HashLeaves() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		config *Config
+		leaves [][]byte
+		target int
+	}{
+		{"single_leaf", nil, leaves[:1], 0},
+		{"negative_index", nil, leaves, -1},
+		{"index_out_of_range", nil, leaves, 4},
+		{"empty_leaf", nil, [][]byte{leaves[0], nil, leaves[2]}, 0},
+		{"inconsistent_leaf_size", nil, [][]byte{leaves[0], leaves[1][:16], leaves[2]}, 0},
+		{"invalid_root_length", &Config{RootBytes: -1}, leaves, 0},
+		{"invalid_padding_strategy", &Config{PaddingStrategy: PaddingPromote, NoDuplicates: true}, leaves, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := GenerateProofFromLeaves(tt.config, tt.leaves, tt.target); err == nil {
+				t.Errorf("GenerateProofFromLeaves() error = nil, want error")
+			}
+		})
+	}
+}
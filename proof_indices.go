@@ -0,0 +1,62 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "fmt"
+
+// checkProofIndices checks that the leaf indexes of ProofIndices are in range.
+func (m *MerkleTree) checkProofIndices() error {
+	for _, idx := range m.ProofIndices {
+		if idx < 0 || idx >= m.NumLeaves {
+			return fmt.Errorf("proof index %d is out of range [0, %d)", idx, m.NumLeaves)
+		}
+	}
+	return nil
+}
+
+// dropUnselectedProofs sets the proofs of the leaves not selected by ProofIndices to nil, if set.
+func (m *MerkleTree) dropUnselectedProofs() {
+	if m.ProofIndices == nil || m.Proofs == nil {
+		return
+	}
+	selected := make([]bool, m.NumLeaves)
+	for _, idx := range m.ProofIndices {
+		selected[idx] = true
+	}
+	for i := range m.Proofs {
+		if !selected[i] {
+			m.Proofs[i] = nil
+		}
+	}
+}
+
+// numSelected returns the number of distinct leaf indexes in range [0, numLeaves) of the proof indexes.
+func numSelected(indices []int, numLeaves int) int {
+	selected := make(map[int]struct{}, len(indices))
+	for _, idx := range indices {
+		if idx >= 0 && idx < numLeaves {
+			selected[idx] = struct{}{}
+		}
+	}
+	return len(selected)
+}
@@ -0,0 +1,135 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConfig_ProofIndices(t *testing.T) {
+	for _, numBlocks := range []int{4, 13, 100, 1001} {
+		blocks := dataBlocks(numBlocks)
+		selected := []int{numBlocks - 1, 0, 3, 3}
+		for _, mode := range []TypeConfigMode{ModeProofGen, ModeProofGenAndTreeBuild} {
+			for _, parallel := range []bool{false, true} {
+				name := fmt.Sprintf("%d blocks/mode %d/parallel %v", numBlocks, mode, parallel)
+				config := &Config{Mode: mode, RunInParallel: parallel, NumRoutines: 4, StoreReversedProofs: true}
+				want, err := New(config, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() error = %v", name, err)
+				}
+				config = &Config{Mode: mode, RunInParallel: parallel, NumRoutines: 4, StoreReversedProofs: true,
+					ProofIndices: selected}
+				m, err := New(config, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() with ProofIndices error = %v", name, err)
+				}
+				for i := range blocks {
+					isSelected := i == 0 || i == 3 || i == numBlocks-1
+					if (m.Proofs[i] != nil) != isSelected || (m.ReversedProofs[i] != nil) != isSelected {
+						t.Fatalf("%s: proof %d = %v, want selected %v", name, i, m.Proofs[i], isSelected)
+					}
+					if isSelected && !m.Proofs[i].Equal(want.Proofs[i]) {
+						t.Errorf("%s: proof %d = %+v, want %+v", name, i, m.Proofs[i], want.Proofs[i])
+					}
+					// The unselected proofs are generated from the tree nodes if the tree is built.
+					proof, err := m.GenerateProof(blocks[i])
+					if wantErr := !isSelected && mode == ModeProofGen; (err != nil) != wantErr {
+						t.Fatalf("%s: GenerateProof() of block %d error = %v, wantErr %v", name, i, err, wantErr)
+					}
+					if err == nil && !proof.Equal(want.Proofs[i]) {
+						t.Errorf("%s: GenerateProof() of block %d = %+v, want %+v", name, i, proof, want.Proofs[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConfig_ProofIndices_update(t *testing.T) {
+	blocks := dataBlocks(9)
+	config := &Config{Mode: ModeProofGenAndTreeBuild, ProofIndices: []int{2, 8}}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	blocks[3] = dataBlocks(1)[0]
+	if err = m.UpdateBatch(map[int]DataBlock{3: blocks[3]}); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	if m.Proofs[3] != nil {
+		t.Errorf("UpdateBatch() generated the unselected proof 3")
+	}
+	for _, i := range config.ProofIndices {
+		if ok, err := m.Verify(blocks[i], m.Proofs[i]); err != nil || !ok {
+			t.Errorf("Verify() of proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+}
+
+func TestConfig_ProofIndices_invalid(t *testing.T) {
+	for _, indices := range [][]int{{-1}, {1, 5}} {
+		if _, err := New(&Config{ProofIndices: indices}, dataBlocks(5)); err == nil {
+			t.Errorf("New() with ProofIndices %v: error = nil", indices)
+		}
+	}
+}
+
+func TestPlan_proofIndices(t *testing.T) {
+	all, err := Plan(nil, 1000)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	selected, err := Plan(&Config{ProofIndices: []int{1, 2, 3}}, 1000)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if selected.EstimatedMemory >= all.EstimatedMemory/10 {
+		t.Errorf("Plan() with ProofIndices EstimatedMemory = %d, want much less than %d",
+			selected.EstimatedMemory, all.EstimatedMemory)
+	}
+}
+
+func BenchmarkNew_proofIndices(b *testing.B) {
+	const numBlocks, numSelected = 1 << 20, 1000
+	blocks := dataBlocks(numBlocks)
+	selected := make([]int, numSelected)
+	for i := range selected {
+		selected[i] = i * (numBlocks / numSelected)
+	}
+	for _, indices := range [][]int{nil, selected} {
+		name := "all_proofs"
+		if indices != nil {
+			name = fmt.Sprintf("%d_proofs", len(indices))
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := New(&Config{ProofIndices: indices}, blocks); err != nil {
+					b.Fatalf("New() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxProofDepth is the maximum number of siblings of the proofs accepted by the verification
+// if MaxProofDepth is 0. It is above the depth of any tree that fits in memory.
+const DefaultMaxProofDepth = 64
+
+// ErrProofTooDeep is returned when a proof has more siblings than the maximum proof depth of the verification.
+var ErrProofTooDeep = errors.New("proof has too many siblings")
+
+// checkProofDepth checks that the proof has at most MaxProofDepth siblings, DefaultMaxProofDepth if 0.
+// It is called before the leaf is hashed, so rootFromLeaf does not check it again.
+func checkProofDepth(proof *Proof, config *Config) error {
+	return checkDepth(len(proof.Siblings), config)
+}
+
+// checkDepth checks that a proof of depth levels has at most MaxProofDepth levels, DefaultMaxProofDepth if 0.
+func checkDepth(depth int, config *Config) error {
+	maxDepth := DefaultMaxProofDepth
+	if config != nil && config.MaxProofDepth > 0 {
+		maxDepth = config.MaxProofDepth
+	}
+	if depth > maxDepth {
+		return fmt.Errorf("%w: got %d siblings, want at most %d", ErrProofTooDeep, depth, maxDepth)
+	}
+	return nil
+}
+
+// VerifyWithLimit verifies the data block with the Merkle Tree proof and Merkle root hash like Verify,
+// rejecting the proof with ErrProofTooDeep before any hashing if it has more than maxDepth siblings,
+// e.g. the Depth of the expected tree, so that verifying the proofs of untrusted peers has a bounded cost.
+// maxDepth overrides MaxProofDepth of the configuration and must be positive.
+func VerifyWithLimit(dataBlock DataBlock, proof *Proof, root []byte, config *Config, maxDepth int) (bool, error) {
+	if maxDepth <= 0 {
+		return false, fmt.Errorf("invalid maximum proof depth %d", maxDepth)
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	c.MaxProofDepth = maxDepth
+	return Verify(dataBlock, proof, root, &c)
+}
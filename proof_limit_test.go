@@ -0,0 +1,93 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestVerifyWithLimit(t *testing.T) {
+	var calls int
+	countingHashFunc := func(data []byte) ([]byte, error) {
+		calls++
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	}
+	blocks := dataBlocks(100)
+	config := &Config{HashFunc: countingHashFunc}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	proof := m.Proofs[42]
+	if ok, err := VerifyWithLimit(blocks[42], proof, m.Root, config, int(m.Depth)); err != nil || !ok {
+		t.Errorf("VerifyWithLimit() = %v, %v, want true", ok, err)
+	}
+	if _, err = VerifyWithLimit(blocks[42], proof, m.Root, config, int(m.Depth)-1); !errors.Is(err, ErrProofTooDeep) {
+		t.Errorf("VerifyWithLimit() below the depth error = %v, want %v", err, ErrProofTooDeep)
+	}
+	if _, err = VerifyWithLimit(blocks[42], proof, m.Root, config, 0); err == nil {
+		t.Errorf("VerifyWithLimit() with limit 0 error = nil, want error")
+	}
+
+	// An oversized proof is rejected before any hashing, whatever its size.
+	huge := &Proof{Index: 42, Path: proof.Path, Siblings: make([][]byte, 1<<20)}
+	for i := range huge.Siblings {
+		huge.Siblings[i] = proof.Siblings[0]
+	}
+	verifiers := []struct {
+		name   string
+		verify func() (bool, error)
+	}{
+		{"VerifyWithLimit", func() (bool, error) {
+			return VerifyWithLimit(blocks[42], huge, m.Root, config, int(m.Depth))
+		}},
+		{"Verify", func() (bool, error) { return Verify(blocks[42], huge, m.Root, config) }},
+		{"MaxProofDepth", func() (bool, error) {
+			return Verify(blocks[42], huge, m.Root, &Config{HashFunc: countingHashFunc, MaxProofDepth: 10})
+		}},
+		{"MerkleTree.Verify", func() (bool, error) { return m.Verify(blocks[42], huge) }},
+		{"VerifyRange", func() (bool, error) {
+			hugeRange := &RangeProof{Start: 42, End: 43, Left: huge.Siblings, Right: huge.Siblings}
+			return VerifyRange(blocks[42:44], hugeRange, m.Root, config)
+		}},
+		{"VerifyChain", func() (bool, error) {
+			return VerifyChain(m.Leaves[42], []ChainLink{{Proof: proof}, {Proof: huge}}, m.Root, countingHashFunc)
+		}},
+	}
+	for _, v := range verifiers {
+		calls = 0
+		if ok, err := v.verify(); ok || !errors.Is(err, ErrProofTooDeep) {
+			t.Errorf("%s() of an oversized proof = %v, %v, want %v", v.name, ok, err, ErrProofTooDeep)
+		}
+		if calls != 0 {
+			t.Errorf("%s() of an oversized proof hashed %d times, want 0", v.name, calls)
+		}
+	}
+	deep := &Proof{Index: 42, Path: proof.Path, Siblings: huge.Siblings[:DefaultMaxProofDepth]}
+	if _, err = Verify(blocks[42], deep, m.Root, config); errors.Is(err, ErrProofTooDeep) {
+		t.Errorf("Verify() of a proof of DefaultMaxProofDepth siblings error = %v, want nil", err)
+	}
+}
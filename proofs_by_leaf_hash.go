@@ -0,0 +1,67 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// LeafHashOf computes the leaf of the data block as the Merkle Tree does, e.g. with its leaf transform,
+// so that the leaf is the key of the proof of the data block in ProofsByLeafHash once hex encoded.
+// It returns an error if BindLeafIndex is set, as the leaf depends on the leaf index of the data block.
+func (m *MerkleTree) LeafHashOf(dataBlock DataBlock) ([]byte, error) {
+	if dataBlock == nil {
+		return nil, errors.New("data block is nil")
+	}
+	if m.BindLeafIndex {
+		return nil, errors.New("data block lookup is not supported when BindLeafIndex is set")
+	}
+	return leafFromBlock(dataBlock, 0, m.Config)
+}
+
+// ProofsByLeafHash returns the proofs of the Merkle Tree keyed by hex encoded leaf hash, e.g. for storage layers
+// addressing the data blocks by content hash. The map is built once and cached until the leaves change,
+// e.g. by UpdateBatch, so it must not be modified. In ModeTreeBuild, the proofs are generated from the tree nodes.
+// If several leaves are identical, the proof of the first leaf wins, which is valid for every data block of the leaf.
+func (m *MerkleTree) ProofsByLeafHash() (map[string]*Proof, error) {
+	m.proofsByLeafHashMu.Lock()
+	defer m.proofsByLeafHashMu.Unlock()
+	if m.proofsByLeafHash != nil {
+		return m.proofsByLeafHash, nil
+	}
+	proofs := make(map[string]*Proof, len(m.Leaves))
+	for i, leaf := range m.Leaves {
+		key := hex.EncodeToString(leaf)
+		if _, ok := proofs[key]; ok {
+			continue
+		}
+		proof, err := m.ProofOf(i)
+		if err != nil {
+			return nil, err
+		}
+		proofs[key] = proof
+	}
+	m.proofsByLeafHash = proofs
+	return proofs, nil
+}
@@ -0,0 +1,106 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestMerkleTree_ProofsByLeafHash(t *testing.T) {
+	tagLeaf := func(data []byte) ([]byte, error) {
+		return append([]byte("leaf:"), data...), nil
+	}
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		blocks := dataBlocks(7)
+		blocks[5] = blocks[1]
+		config := &Config{Mode: mode, LeafTransform: tagLeaf}
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("%v: New() error = %v", mode, err)
+		}
+		proofs, err := m.ProofsByLeafHash()
+		if err != nil {
+			t.Fatalf("%v: ProofsByLeafHash() error = %v", mode, err)
+		}
+		if len(proofs) != 6 {
+			t.Fatalf("%v: ProofsByLeafHash() has %d proofs, want 6", mode, len(proofs))
+		}
+		for i, block := range blocks {
+			leaf, err := m.LeafHashOf(block)
+			if err != nil {
+				t.Fatalf("%v: LeafHashOf() error = %v", mode, err)
+			}
+			proof := proofs[hex.EncodeToString(leaf)]
+			if proof == nil {
+				t.Fatalf("%v: no proof of block %d", mode, i)
+			}
+			want := i
+			if i == 5 {
+				want = 1 // The proof of the first identical leaf wins.
+			}
+			if proof.Index != want {
+				t.Errorf("%v: proof of block %d has index %d", mode, i, proof.Index)
+			}
+			if ok, err := Verify(block, proof, m.Root, config); err != nil || !ok {
+				t.Errorf("%v: Verify() of block %d = %v, %v, want true", mode, i, ok, err)
+			}
+		}
+		cached, err := m.ProofsByLeafHash()
+		if err != nil {
+			t.Fatalf("%v: ProofsByLeafHash() error = %v", mode, err)
+		}
+		if key := hex.EncodeToString(m.Leaves[0]); cached[key] != proofs[key] {
+			t.Errorf("%v: ProofsByLeafHash() is not cached", mode)
+		}
+		if mode == ModeProofGen {
+			continue
+		}
+		// The cache is reset by the updates.
+		update := dataBlocks(1)[0]
+		if err = m.UpdateLeaf(0, update); err != nil {
+			t.Fatalf("%v: UpdateLeaf() error = %v", mode, err)
+		}
+		if proofs, err = m.ProofsByLeafHash(); err != nil {
+			t.Fatalf("%v: ProofsByLeafHash() error = %v", mode, err)
+		}
+		leaf, err := m.LeafHashOf(update)
+		if err != nil {
+			t.Fatalf("%v: LeafHashOf() error = %v", mode, err)
+		}
+		if ok, err := Verify(update, proofs[hex.EncodeToString(leaf)], m.Root, config); err != nil || !ok {
+			t.Errorf("%v: Verify() of the updated block = %v, %v, want true", mode, ok, err)
+		}
+	}
+
+	m, err := New(&Config{BindLeafIndex: true}, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = m.LeafHashOf(dataBlocks(1)[0]); err == nil {
+		t.Errorf("LeafHashOf() with BindLeafIndex error = nil, want error")
+	}
+	if _, err = m.LeafHashOf(nil); err == nil {
+		t.Errorf("LeafHashOf(nil) error = nil, want error")
+	}
+}
@@ -0,0 +1,120 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EncodeProofsJSON streams the proofs of the Merkle Tree to the writer as a JSON array, in leaf order,
+// one proof at a time, so that the memory does not grow with the number of proofs, unlike MarshalJSON.
+// The proofs are encoded as in MarshalJSON, with the byte values hex encoded and the hash id if set.
+// In ModeTreeBuild, the proofs are generated from the tree nodes.
+func (m *MerkleTree) EncodeProofsJSON(w io.Writer) error {
+	if m.Proofs == nil && m.nodes == nil {
+		return errors.New("merkle Tree has neither proofs nor nodes, could not encode the proofs")
+	}
+	bw := bufio.NewWriter(w)
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+	for i := 0; i < m.NumLeaves; i++ {
+		proof, err := m.ProofOf(i)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(newProofJSON(proof))
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if err = bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if _, err = bw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := bw.WriteByte(']'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodeProofsJSON returns an iterator over the proofs of the JSON array read from the reader,
+// e.g. encoded by EncodeProofsJSON, decoding one proof at a time.
+// Each call returns the next proof and true, or false at the end of the array.
+// After an error, every call returns the error.
+func DecodeProofsJSON(r io.Reader) func() (*Proof, bool, error) {
+	var (
+		dec     = json.NewDecoder(r)
+		started bool
+		done    bool
+		err     error
+	)
+	expectDelim := func(want json.Delim) error {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != want {
+			return fmt.Errorf("invalid proofs JSON: got %v, want %v", token, want)
+		}
+		return nil
+	}
+	return func() (*Proof, bool, error) {
+		if err != nil {
+			return nil, false, err
+		}
+		if done {
+			return nil, false, nil
+		}
+		if !started {
+			if err = expectDelim('['); err != nil {
+				return nil, false, err
+			}
+			started = true
+		}
+		if !dec.More() {
+			if err = expectDelim(']'); err != nil {
+				return nil, false, err
+			}
+			done = true
+			return nil, false, nil
+		}
+		var doc proofJSON
+		if err = dec.Decode(&doc); err != nil {
+			return nil, false, err
+		}
+		if doc.Index < 0 || len(doc.Siblings) == 0 {
+			err = fmt.Errorf("invalid proof of leaf %d in proofs JSON", doc.Index)
+			return nil, false, err
+		}
+		return doc.proof(), true, nil
+	}
+}
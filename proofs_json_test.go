@@ -0,0 +1,127 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMerkleTree_EncodeProofsJSON(t *testing.T) {
+	blocks := dataBlocks(10000)
+	config := &Config{EmbedFingerprint: true}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err = m.EncodeProofsJSON(&buf); err != nil {
+		t.Fatalf("EncodeProofsJSON() error = %v", err)
+	}
+	next := DecodeProofsJSON(&buf)
+	for i := 0; ; i++ {
+		proof, ok, err := next()
+		if err != nil {
+			t.Fatalf("DecodeProofsJSON() error = %v", err)
+		}
+		if !ok {
+			if i != len(blocks) {
+				t.Fatalf("DecodeProofsJSON() decoded %d proofs, want %d", i, len(blocks))
+			}
+			break
+		}
+		if !proof.Equal(m.Proofs[i]) {
+			t.Fatalf("decoded proof %d differs from the encoded proof", i)
+		}
+		if i == 4321 {
+			if ok, err := Verify(blocks[i], proof, m.Root, config); err != nil || !ok {
+				t.Errorf("Verify() of decoded proof %d = %v, %v, want true", i, ok, err)
+			}
+		}
+	}
+	if _, ok, err := next(); ok || err != nil {
+		t.Errorf("DecodeProofsJSON() after the end = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestMerkleTree_EncodeProofsJSON_treeBuild(t *testing.T) {
+	blocks := dataBlocks(5)
+	m, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err = m.EncodeProofsJSON(&buf); err != nil {
+		t.Fatalf("EncodeProofsJSON() error = %v", err)
+	}
+	next := DecodeProofsJSON(&buf)
+	for i := range blocks {
+		proof, ok, err := next()
+		if err != nil || !ok {
+			t.Fatalf("DecodeProofsJSON() = %v, %v, want proof %d", ok, err, i)
+		}
+		if ok, err := m.Verify(blocks[i], proof); err != nil || !ok {
+			t.Errorf("Verify() of decoded proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+	if err = (&MerkleTree{}).EncodeProofsJSON(&buf); err == nil {
+		t.Errorf("EncodeProofsJSON() without proofs nor nodes error = nil, want error")
+	}
+}
+
+func TestDecodeProofsJSON_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not an array", `{"index": 0}`},
+		{"truncated", `[{"index":0,"path":1,"siblings":["00"]}`},
+		{"no siblings", `[{"index":0,"path":1,"siblings":[]}]`},
+		{"negative index", `[{"index":-1,"path":1,"siblings":["00"]}]`},
+		{"invalid hex", `[{"index":0,"path":1,"siblings":["zz"]}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := DecodeProofsJSON(strings.NewReader(tt.data))
+			var err error
+			for i := 0; i < 3 && err == nil; i++ {
+				var ok bool
+				if _, ok, err = next(); !ok && err == nil {
+					t.Fatalf("DecodeProofsJSON() ended without error")
+				}
+			}
+			if err == nil {
+				t.Fatalf("DecodeProofsJSON() error = nil, want error")
+			}
+			if _, _, again := next(); again != err {
+				t.Errorf("DecodeProofsJSON() error after an error = %v, want %v", again, err)
+			}
+		})
+	}
+	next := DecodeProofsJSON(strings.NewReader("[]"))
+	if _, ok, err := next(); ok || err != nil {
+		t.Errorf("DecodeProofsJSON() of an empty array = %v, %v, want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,129 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Prune frees the internal levels of the tree nodes, keeping the leaves and the nodes at keepLevel,
+// e.g. to keep the memory of a long-lived Merkle Tree low after the proofs are generated.
+// The proofs generated from the tree nodes afterwards by ProofOf and GenerateProof are identical,
+// recomputing the pruned nodes of their path from the leaves of the subtree at keepLevel and from the nodes
+// at keepLevel, i.e. with O(2^keepLevel + n/2^keepLevel) hashes for n leaves: O(n) with keepLevel 0,
+// where only the leaves are kept, and O(sqrt(n)) with keepLevel Depth/2.
+// The other methods reading the tree nodes, e.g. NodeAt, ExportCap and UpdateBatch, recompute the pruned levels.
+// The root and the Proofs are kept. Prune may be called again with another level.
+// The tree nodes are required, so the Merkle Tree must be built in ModeTreeBuild or ModeProofGenAndTreeBuild.
+func (m *MerkleTree) Prune(keepLevel int) error {
+	if m.nodes == nil {
+		return errors.New("merkle Tree nodes are not available, could not prune the tree")
+	}
+	if keepLevel < 0 || keepLevel >= int(m.Depth) {
+		return fmt.Errorf("level %d is out of range [0, %d)", keepLevel, m.Depth)
+	}
+	if m.pruned && m.nodes[keepLevel] == nil {
+		nodes, err := m.treeNodes()
+		if err != nil {
+			return err
+		}
+		m.nodes = nodes
+	}
+	m.pruned, m.keptLevel = true, keepLevel
+	m.dropPrunedLevels()
+	return nil
+}
+
+// dropPrunedLevels frees the levels of the tree nodes other than the leaves and the kept level.
+func (m *MerkleTree) dropPrunedLevels() {
+	for level := 1; level < len(m.nodes); level++ {
+		if level != m.keptLevel {
+			m.nodes[level] = nil
+		}
+	}
+}
+
+// treeNodes returns the tree nodes, recomputing the pruned levels if the Merkle Tree is pruned.
+// The tree nodes of the Merkle Tree are not modified.
+func (m *MerkleTree) treeNodes() ([][][]byte, error) {
+	if !m.pruned {
+		return m.nodes, nil
+	}
+	nodes := make([][][]byte, len(m.nodes))
+	copy(nodes, m.nodes)
+	for level := 1; level < len(nodes); level++ {
+		if nodes[level] != nil {
+			continue
+		}
+		var err error
+		if nodes[level], err = m.parentNodes(level, nodes[level-1]); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// parentNodes computes the nodes at the level from the even number of nodes below it, padded as fixOdd does.
+// OnNode is not called, as the nodes were already reported when the tree was built.
+func (m *MerkleTree) parentNodes(level int, children [][]byte) ([][]byte, error) {
+	parents := make([][]byte, len(children)>>1, len(children)>>1+1)
+	for i := 0; i < len(children); i += 2 {
+		var err error
+		if parents[i>>1], err = m.hashPair(level, children[i], children[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	parents, _, err := m.fixOdd(parents, len(parents))
+	return parents, err
+}
+
+// prunedProof generates the proof for the leaf at the index from the tree nodes of the pruned Merkle Tree.
+// The levels below the kept level are recomputed from the leaves of the subtree of the leaf at the kept level,
+// which spans the tail of each level if it is the last subtree, so that the padding nodes are recomputed too.
+// The levels above are recomputed from the nodes at the kept level.
+func (m *MerkleTree) prunedProof(idx int) (*Proof, error) {
+	var (
+		siblings = make([][]byte, m.Depth)
+		start    = idx >> m.keptLevel << m.keptLevel
+		nodes    = m.nodes[0][start:min(start+1<<m.keptLevel, len(m.nodes[0]))]
+		offset   = start // index of the first node of nodes at the level
+	)
+	for level := 0; level < int(m.Depth); level++ {
+		if level == m.keptLevel {
+			nodes, offset = m.nodes[level], 0
+		}
+		siblings[level] = nodes[(idx>>level-offset)^1]
+		if level+1 == m.keptLevel || level+1 == int(m.Depth) {
+			continue
+		}
+		var err error
+		if nodes, err = m.parentNodes(level+1, nodes); err != nil {
+			return nil, err
+		}
+		offset >>= 1
+	}
+	return m.proofFromLevels(idx, func(level int) []byte {
+		return siblings[level]
+	}), nil
+}
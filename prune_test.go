@@ -0,0 +1,164 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMerkleTree_Prune(t *testing.T) {
+	for _, numBlocks := range []int{2, 3, 5, 9, 100, 257} {
+		blocks := dataBlocks(numBlocks)
+		for _, config := range []Config{
+			{Mode: ModeTreeBuild},
+			{Mode: ModeTreeBuild, SortSiblingPairs: true, EmbedFingerprint: true},
+			{Mode: ModeTreeBuild, NoDuplicates: true},
+			{Mode: ModeTreeBuild, PaddingStrategy: PaddingPromote},
+			{Mode: ModeTreeBuild, PaddingStrategy: PaddingIndexed},
+			{Mode: ModeTreeBuild, LevelHashFunc: twoLevelHashFunc},
+			{Mode: ModeTreeBuild, RunInParallel: true, RootBytes: 8},
+		} {
+			for keepLevel := 0; keepLevel < int(calTreeDepth(numBlocks)); keepLevel++ {
+				config := config
+				name := fmt.Sprintf("%d blocks/%+v/level %d", numBlocks, config, keepLevel)
+				m, err := New(&config, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() error = %v", name, err)
+				}
+				root := m.Root
+				want := make([]*Proof, m.NumLeaves)
+				for i := range want {
+					want[i] = m.proofFromNodes(i)
+				}
+				nodes := append([][][]byte(nil), m.nodes...)
+				if err := m.Prune(keepLevel); err != nil {
+					t.Fatalf("%s: Prune() error = %v", name, err)
+				}
+				for level := 1; level < int(m.Depth); level++ {
+					if pruned := m.nodes[level] == nil; pruned != (level != keepLevel) {
+						t.Errorf("%s: level %d pruned = %v", name, level, pruned)
+					}
+				}
+				if !bytes.Equal(m.Root, root) {
+					t.Errorf("%s: Root after Prune() = %x, want %x", name, m.Root, root)
+				}
+				for i := range want {
+					proof, err := m.ProofOf(i)
+					if err != nil {
+						t.Fatalf("%s: ProofOf(%d) error = %v", name, i, err)
+					}
+					if !proof.Equal(want[i]) {
+						t.Errorf("%s: ProofOf(%d) after Prune() = %+v, want %+v", name, i, proof, want[i])
+					}
+				}
+				for i, block := range blocks {
+					proof, err := m.GenerateProof(block)
+					if err != nil {
+						t.Fatalf("%s: GenerateProof() error = %v", name, err)
+					}
+					if !proof.Equal(want[i]) {
+						t.Errorf("%s: GenerateProof() of block %d after Prune() differs", name, i)
+					}
+				}
+				recomputed, err := m.treeNodes()
+				if err != nil {
+					t.Fatalf("%s: treeNodes() error = %v", name, err)
+				}
+				for level := range nodes {
+					if len(recomputed[level]) != len(nodes[level]) {
+						t.Fatalf("%s: %d recomputed nodes at level %d, want %d",
+							name, len(recomputed[level]), level, len(nodes[level]))
+					}
+					for i := range nodes[level] {
+						if !bytes.Equal(recomputed[level][i], nodes[level][i]) {
+							t.Errorf("%s: recomputed node %d at level %d differs", name, i, level)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTree_Prune_update(t *testing.T) {
+	// The pruned tree is updated as the full tree, and stays pruned.
+	blocks := dataBlocks(37)
+	m, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := m.Prune(3); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	updates := map[int]DataBlock{0: dataBlocks(38)[37], 36: dataBlocks(39)[38]}
+	if err := m.UpdateBatch(updates); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	for idx, block := range updates {
+		blocks[idx] = block
+	}
+	want, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !Equal(m, want) {
+		t.Errorf("pruned tree after UpdateBatch() differs from a full rebuild")
+	}
+	if m.nodes[1] != nil || m.nodes[3] == nil {
+		t.Errorf("tree is not pruned at level 3 after UpdateBatch()")
+	}
+	for i := range blocks {
+		if proof, err := m.ProofOf(i); err != nil || !proof.Equal(want.proofFromNodes(i)) {
+			t.Errorf("ProofOf(%d) of the pruned tree after UpdateBatch() = %+v, %v, want %+v",
+				i, proof, err, want.proofFromNodes(i))
+		}
+	}
+
+	// Pruning again at another level recomputes the newly kept level.
+	if err := m.Prune(1); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if m.nodes[3] != nil || m.nodes[1] == nil || !Equal(m, want) {
+		t.Errorf("tree pruned again at level 1 differs from a full rebuild")
+	}
+}
+
+func TestMerkleTree_Prune_errors(t *testing.T) {
+	m, err := New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := m.Prune(0); err == nil {
+		t.Errorf("Prune() in ModeProofGen error = nil, want error")
+	}
+	if m, err = New(&Config{Mode: ModeTreeBuild}, dataBlocks(5)); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, level := range []int{-1, 3} {
+		if err := m.Prune(level); err == nil {
+			t.Errorf("Prune(%d) error = nil, want error", level)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// RangeProof is the proof of the data blocks of a range of consecutive leaves.
+// At each tree level, only the siblings outside the range are needed, the nodes inside the range
+// are computed from the data blocks.
+type RangeProof struct {
+	Start int      // index of the first leaf of the range.
+	End   int      // index of the last leaf of the range, inclusive.
+	Left  [][]byte // sibling on the left of the range at each level, nil if the range starts with a left node.
+	Right [][]byte // sibling on the right of the range at each level, nil if the range ends with a right node.
+	// Fingerprint of the configuration of the Merkle Tree, set if EmbedFingerprint is true in the configuration.
+	Fingerprint []byte
+}
+
+// errPromotedProofs completes the errors of MergeAdjacentProofs for the proofs skipping levels.
+const errPromotedProofs = "or a proof skips the level of a promoted node with PaddingPromote, which is not supported"
+
+// MergeAdjacentProofs merges the proofs of two adjacent leaves into a range proof of the two leaves,
+// without the Merkle Tree. The proofs may be passed in any order.
+// It returns an error if the leaves are not adjacent, or if the proofs do not belong to the same tree,
+// i.e. their paths do not match their leaf indexes or their common upper-level siblings differ.
+// With PaddingPromote, the proofs skipping the levels of promoted nodes, i.e. of the last leaves of the trees
+// with odd levels, have fewer siblings than levels and paths indexed by sibling, so they are not supported.
+func MergeAdjacentProofs(p1, p2 *Proof) (*RangeProof, error) {
+	if p1 == nil || p2 == nil {
+		return nil, errors.New("proof is nil")
+	}
+	if p1.Index > p2.Index {
+		p1, p2 = p2, p1
+	}
+	if p2.Index != p1.Index+1 {
+		return nil, fmt.Errorf("leaf indexes %d and %d are not adjacent", p1.Index, p2.Index)
+	}
+	if len(p1.Siblings) != len(p2.Siblings) {
+		return nil, errors.New("proofs have different numbers of siblings, " + errPromotedProofs)
+	}
+	if !bytes.Equal(p1.Fingerprint, p2.Fingerprint) {
+		return nil, errors.New("proofs have different configuration fingerprints")
+	}
+	for _, p := range []*Proof{p1, p2} {
+		if err := checkProofPath(p); err != nil {
+			return nil, fmt.Errorf("%w, %s", err, errPromotedProofs)
+		}
+	}
+	var (
+		depth = len(p1.Siblings)
+		rp    = &RangeProof{
+			Start:       p1.Index,
+			End:         p2.Index,
+			Left:        make([][]byte, depth),
+			Right:       make([][]byte, depth),
+			Fingerprint: p1.Fingerprint,
+		}
+	)
+	for level := 0; level < depth; level++ {
+		lo, hi := p1.Index>>level, p2.Index>>level
+		if lo == hi && !bytes.Equal(p1.Siblings[level], p2.Siblings[level]) {
+			return nil, fmt.Errorf("proofs have different siblings at level %d", level)
+		}
+		if lo&1 == 1 {
+			rp.Left[level] = p1.Siblings[level]
+		}
+		if hi&1 == 0 {
+			rp.Right[level] = p2.Siblings[level]
+		}
+	}
+	return rp, nil
+}
+
+// checkProofPath checks that the path of the proof matches its leaf index.
+// Root-to-leaf proofs are rejected, as their paths are ordered from the root.
+func checkProofPath(proof *Proof) error {
+	if proof.RootToLeaf {
+		return errors.New("proof is root-to-leaf, reverse it first")
+	}
+	if proof.Index < 0 || proof.Index>>len(proof.Siblings) != 0 {
+		return fmt.Errorf("leaf index %d is out of range of the proof", proof.Index)
+	}
+	for level := range proof.Siblings {
+		isLeft := (proof.Index>>level)&1 == 0
+		pathLeft := (proof.Path>>level)&1 == 1
+		if pathLeft != isLeft {
+			return fmt.Errorf("path of the proof does not match leaf index %d", proof.Index)
+		}
+	}
+	return nil
+}
+
+// VerifyRange verifies the data blocks of the range against the Merkle root with the range proof.
+// The data blocks must be in leaf order, from the leaf at index proof.Start to the leaf at index proof.End.
+// As with Verify, a range proof of more levels than MaxProofDepth is rejected with ErrProofTooDeep before any hashing.
+func VerifyRange(blocks []DataBlock, proof *RangeProof, root []byte, config *Config) (bool, error) {
+	if proof == nil {
+		return false, errNilInput("range proof")
+	}
+	if root == nil {
+		return false, errNilInput("root")
+	}
+	if proof.Start < 0 || proof.End < proof.Start {
+		return false, fmt.Errorf("invalid leaf range [%d, %d]", proof.Start, proof.End)
+	}
+	if len(blocks) != proof.End-proof.Start+1 {
+		return false, fmt.Errorf("got %d data blocks for the leaf range [%d, %d]", len(blocks), proof.Start, proof.End)
+	}
+	if len(proof.Left) != len(proof.Right) {
+		return false, errors.New("range proof has different numbers of left and right siblings")
+	}
+	// The oversized range proofs are rejected before the data blocks are hashed.
+	if err := checkDepth(len(proof.Left), config); err != nil {
+		return false, err
+	}
+	config = verifyConfig(config)
+	if err := checkFingerprint(proof.Fingerprint, config); err != nil {
+		return false, err
+	}
+	nodes := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		if isNilBlock(block) {
+			return false, errNilInput(fmt.Sprintf("data block %d", proof.Start+i))
+		}
+		leaf, err := leafFromBlock(block, proof.Start+i, config)
+		if err != nil {
+			return false, err
+		}
+		nodes[i] = leaf
+	}
+	lo, hi := proof.Start, proof.End
+	for level := range proof.Left {
+		if lo&1 == 1 {
+			if proof.Left[level] == nil {
+				return false, fmt.Errorf("missing left sibling at level %d", level)
+			}
+			nodes = append([][]byte{proof.Left[level]}, nodes...)
+			lo--
+		}
+		if hi&1 == 0 {
+			if proof.Right[level] == nil {
+				return false, fmt.Errorf("missing right sibling at level %d", level)
+			}
+			nodes = append(nodes, proof.Right[level])
+			hi++
+		}
+		parents := make([][]byte, len(nodes)>>1)
+		for i := range parents {
+			parent, err := config.pairHash(level+1, nodes[i<<1], nodes[i<<1+1])
+			if err != nil {
+				return false, err
+			}
+			parents[i] = parent
+		}
+		nodes, lo, hi = parents, lo>>1, hi>>1
+	}
+	if len(nodes) != 1 {
+		return false, errors.New("range proof does not cover the leaf range")
+	}
+	return rootMatches(nodes[0], root, config), nil
+}
@@ -0,0 +1,148 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestMergeAdjacentProofs(t *testing.T) {
+	tests := []struct {
+		name      string
+		numLeaves int
+		first     int
+		config    *Config
+	}{
+		{"leaves_4_5_of_16", 16, 4, nil},
+		{"leaves_5_6_of_16", 16, 5, nil},
+		{"leaves_7_8_of_16", 16, 7, nil},
+		{"last_leaves_of_13", 13, 11, nil},
+		{"leaves_of_2", 2, 0, nil},
+		{"sorted_pairs", 16, 9, &Config{SortSiblingPairs: true}},
+		{"bind_leaf_index", 11, 3, &Config{BindLeafIndex: true, EmbedFingerprint: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := dataBlocks(tt.numLeaves)
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			// The proofs may be passed in any order.
+			rp, err := MergeAdjacentProofs(m.Proofs[tt.first+1], m.Proofs[tt.first])
+			if err != nil {
+				t.Fatalf("MergeAdjacentProofs() error = %v", err)
+			}
+			if rp.Start != tt.first || rp.End != tt.first+1 {
+				t.Errorf("MergeAdjacentProofs() range = [%d, %d], want [%d, %d]", rp.Start, rp.End, tt.first, tt.first+1)
+			}
+			numSiblings := 0
+			for level := range rp.Left {
+				if rp.Left[level] != nil {
+					numSiblings++
+				}
+				if rp.Right[level] != nil {
+					numSiblings++
+				}
+			}
+			if numSiblings >= 2*int(m.Depth) {
+				t.Errorf("range proof has %d siblings, want fewer than the %d of the two proofs", numSiblings, 2*m.Depth)
+			}
+			rangeBlocks := blocks[tt.first : tt.first+2]
+			ok, err := VerifyRange(rangeBlocks, rp, m.Root, m.Config)
+			if err != nil || !ok {
+				t.Errorf("VerifyRange() = %v, %v, want true", ok, err)
+			}
+			swapped := []DataBlock{rangeBlocks[1], rangeBlocks[0]}
+			if ok, _ := VerifyRange(swapped, rp, m.Root, m.Config); ok {
+				t.Errorf("VerifyRange() of swapped data blocks = true, want false")
+			}
+			tampered := []DataBlock{rangeBlocks[0], &mock.DataBlock{Data: []byte("tampered")}}
+			if ok, _ := VerifyRange(tampered, rp, m.Root, m.Config); ok {
+				t.Errorf("VerifyRange() of a tampered data block = true, want false")
+			}
+			if _, err := VerifyRange(rangeBlocks[:1], rp, m.Root, m.Config); err == nil {
+				t.Errorf("VerifyRange() error = nil, want error for a wrong number of data blocks")
+			}
+		})
+	}
+}
+
+func TestMergeAdjacentProofs_invalid(t *testing.T) {
+	blocks := dataBlocks(16)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	other, err := New(nil, dataBlocks(16))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	wrongPath := *m.Proofs[5]
+	wrongPath.Path ^= 1
+	tests := []struct {
+		name   string
+		p1, p2 *Proof
+	}{
+		{"nil_proof", m.Proofs[4], nil},
+		{"not_adjacent", m.Proofs[4], m.Proofs[6]},
+		{"same_leaf", m.Proofs[4], m.Proofs[4]},
+		{"wrong_path", m.Proofs[4], &wrongPath},
+		{"different_trees", m.Proofs[5], other.Proofs[6]},
+		{"different_depths", m.Proofs[4], &Proof{Siblings: m.Proofs[5].Siblings[:3], Path: m.Proofs[5].Path, Index: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MergeAdjacentProofs(tt.p1, tt.p2); err == nil {
+				t.Errorf("MergeAdjacentProofs() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestMergeAdjacentProofs_paddingPromote(t *testing.T) {
+	blocks := dataBlocks(6)
+	config := &Config{PaddingStrategy: PaddingPromote}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The proofs of the leaves 0 to 3 have a sibling at every level, and are merged.
+	rp, err := MergeAdjacentProofs(m.Proofs[1], m.Proofs[2])
+	if err != nil {
+		t.Fatalf("MergeAdjacentProofs() error = %v", err)
+	}
+	if ok, err := VerifyRange(blocks[1:3], rp, m.Root, config); err != nil || !ok {
+		t.Errorf("VerifyRange() of the merged proofs = %v, %v, want true", ok, err)
+	}
+	// The node of the leaves 4 and 5 is promoted at level 1, so their proofs skip it.
+	for _, pair := range [][2]int{{3, 4}, {4, 5}} {
+		_, err := MergeAdjacentProofs(m.Proofs[pair[0]], m.Proofs[pair[1]])
+		if err == nil || !strings.Contains(err.Error(), "PaddingPromote") {
+			t.Errorf("MergeAdjacentProofs() of leaves %v error = %v, want the PaddingPromote error", pair, err)
+		}
+	}
+}
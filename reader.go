@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// NewFromReader generates a new Merkle Tree with specified configuration from the content of the reader,
+// split into chunks of chunkSize bytes, e.g. to content-address a large file by 1 MiB chunks.
+// Each chunk is hashed as the data block of a leaf while the reader is streamed, and is not retained,
+// unless DisableLeafHashing is set. The last chunk may be shorter and is hashed as it is, without padding.
+// It returns the Merkle Tree and the number of bytes read, also on error.
+func NewFromReader(config *Config, r io.Reader, chunkSize int) (*MerkleTree, int64, error) {
+	if chunkSize <= 0 {
+		return nil, 0, errors.New("chunk size must be greater than 0")
+	}
+	var total int64
+	m, err := build(context.Background(), config, 0, 0, func(m *MerkleTree) ([][]byte, error) {
+		return m.readLeaves(r, chunkSize, &total)
+	})
+	return m, total, err
+}
+
+// readLeaves reads the reader by chunks of the chunk size and computes the leaf of each chunk,
+// adding the number of bytes read to total.
+func (m *MerkleTree) readLeaves(r io.Reader, chunkSize int, total *int64) ([][]byte, error) {
+	var (
+		buf    = make([]byte, chunkSize)
+		leaves [][]byte
+	)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		*total += int64(n)
+		if n > 0 {
+			start := m.anomalyStart()
+			leaf, additional, err := m.leavesFromBytes(buf[:n], len(leaves))
+			m.checkSlow(AnomalySlowHash, len(leaves), start, int64(n))
+			if err != nil {
+				return nil, err
+			}
+			if additional != nil {
+				m.additionalLeaves = append(m.additionalLeaves, additional)
+			}
+			if !m.DisableLeafHashing {
+				if err = m.checkHashSize(leaf); err != nil {
+					return nil, err
+				}
+			}
+			leaves = append(leaves, leaf)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			return leaves, nil
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("test_read_err")
+}
+
+func TestNewFromReader(t *testing.T) {
+	const (
+		streamSize = 10_000_000
+		chunkSize  = 1 << 20
+	)
+	data := make([]byte, streamSize)
+	rand.New(rand.NewSource(1)).Read(data)
+	for _, config := range []*Config{nil, {RunInParallel: true, BindLeafIndex: true}} {
+		m, n, err := NewFromReader(config, bytes.NewReader(data), chunkSize)
+		if err != nil {
+			t.Fatalf("NewFromReader() error = %v", err)
+		}
+		if n != streamSize {
+			t.Errorf("NewFromReader() read %d bytes, want %d", n, streamSize)
+		}
+		var chunks []DataBlock
+		for start := 0; start < streamSize; start += chunkSize {
+			end := start + chunkSize
+			if end > streamSize {
+				end = streamSize
+			}
+			chunks = append(chunks, &mock.DataBlock{Data: data[start:end]})
+		}
+		if m.NumLeaves != len(chunks) {
+			t.Fatalf("NewFromReader() built %d leaves, want %d", m.NumLeaves, len(chunks))
+		}
+		// The last chunk is short and hashed without padding.
+		want, err := New(&Config{BindLeafIndex: m.BindLeafIndex}, chunks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if !bytes.Equal(m.Root, want.Root) {
+			t.Errorf("NewFromReader() root differs from New() with the chunks as data blocks")
+		}
+		// Verify a middle chunk, and a range of two chunks for a partial download.
+		if ok, err := m.Verify(chunks[5], m.Proofs[5]); err != nil || !ok {
+			t.Errorf("Verify() of the middle chunk = %v, %v, want true", ok, err)
+		}
+		rp, err := MergeAdjacentProofs(m.Proofs[4], m.Proofs[5])
+		if err != nil {
+			t.Fatalf("MergeAdjacentProofs() error = %v", err)
+		}
+		if ok, err := VerifyRange(chunks[4:6], rp, m.Root, m.Config); err != nil || !ok {
+			t.Errorf("VerifyRange() of the middle chunks = %v, %v, want true", ok, err)
+		}
+	}
+}
+
+func TestNewFromReader_errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		r         io.Reader
+		chunkSize int
+	}{
+		{"read_error", errReader{}, 16},
+		{"single_chunk", bytes.NewReader(make([]byte, 16)), 16},
+		{"empty", bytes.NewReader(nil), 16},
+		{"invalid_chunk_size", bytes.NewReader(make([]byte, 16)), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := NewFromReader(nil, tt.r, tt.chunkSize); err == nil {
+				t.Errorf("NewFromReader() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/txaty/go-merkletree/internal/reference"
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// referenceCombine hashes the concatenation of the sibling nodes with SHA256, sorted first if sorted is true.
+func referenceCombine(sorted bool) func(left, right []byte) []byte {
+	return func(left, right []byte) []byte {
+		if sorted && bytes.Compare(left, right) > 0 {
+			left, right = right, left
+		}
+		sum := sha256.Sum256(append(append([]byte(nil), left...), right...))
+		return sum[:]
+	}
+}
+
+// checkReferenceRoot builds the random data blocks of the seed with New under every mode and parallelization
+// setting, and compares the root with that of the reference building of their leaves.
+func checkReferenceRoot(t *testing.T, numBlocks int, seed int64) {
+	t.Helper()
+	rng := mrand.New(mrand.NewSource(seed))
+	blocks := make([]DataBlock, numBlocks)
+	for i := range blocks {
+		data := make([]byte, 1+rng.Intn(64))
+		rng.Read(data)
+		blocks[i] = &mock.DataBlock{Data: data}
+	}
+	for _, base := range []Config{{}, {SortSiblingPairs: true}, {DisableLeafHashing: true}} {
+		leaves := make([][]byte, numBlocks)
+		for i, block := range blocks {
+			data, _ := block.Serialize()
+			leaves[i] = data
+			if !base.DisableLeafHashing {
+				sum := sha256.Sum256(data)
+				leaves[i] = sum[:]
+			}
+		}
+		want := reference.BuildRoot(leaves, referenceCombine(base.SortSiblingPairs))
+		for _, variant := range consistencyVariants() {
+			config := base
+			config.Mode, config.RunInParallel, config.NumRoutines = variant.mode, variant.parallel, variant.numRoutines
+			name := fmt.Sprintf("%d blocks/seed %d/%+v", numBlocks, seed, config)
+			m, err := newWithContext(context.Background(), &config, blocks, variant.numRoutines)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if !bytes.Equal(m.Root, want) {
+				t.Errorf("%s: Root = %x, want the reference root %x", name, m.Root, want)
+			}
+		}
+	}
+}
+
+func TestNew_reference(t *testing.T) {
+	rng := mrand.New(mrand.NewSource(1))
+	for iter := 0; iter < 50; iter++ {
+		checkReferenceRoot(t, 2+rng.Intn(600), rng.Int63())
+	}
+}
+
+func FuzzNew_reference(f *testing.F) {
+	for _, numBlocks := range []uint16{0, 1, 3, 7, 63, 64, 65, 1000} {
+		f.Add(numBlocks, int64(numBlocks))
+	}
+	f.Fuzz(func(t *testing.T, numBlocks uint16, seed int64) {
+		checkReferenceRoot(t, 2+int(numBlocks%2048), seed)
+	})
+}
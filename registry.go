@@ -0,0 +1,121 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// HashSHA256 is the registered name of the default SHA256 hash function.
+const HashSHA256 = "sha256"
+
+// Hash ids of the well-known hash functions, carried by the proofs to let VerifyAuto select the hash function.
+// Only HashIDSHA256 is registered by default, the other hash functions must be registered with RegisterHashFunc.
+const (
+	HashIDSHA256    byte = 1
+	HashIDKeccak256 byte = 2
+)
+
+// hashRegistry maps the names of the registered hash functions to the functions.
+// The functions are not identified by their values, as closures of the same function literal cannot
+// be told apart: the name is set explicitly by HashName in the configuration.
+var hashRegistry = struct {
+	sync.RWMutex
+	byName map[string]TypeHashFunc
+}{
+	byName: map[string]TypeHashFunc{HashSHA256: DefaultHashFunc},
+}
+
+// hashIDRegistry maps the ids of the registered hash functions to the functions.
+// As with hashRegistry, the id is set explicitly by HashID in the configuration.
+var hashIDRegistry = struct {
+	sync.RWMutex
+	byID map[byte]TypeHashFunc
+}{
+	byID: map[byte]TypeHashFunc{HashIDSHA256: DefaultHashFunc},
+}
+
+// RegisterHashFuncName registers the hash function under the name, so that trees built with it
+// and with the name as HashName in their configuration can be serialized with the name,
+// and deserialized with the function.
+// It returns an error if the name is already registered.
+func RegisterHashFuncName(name string, hashFunc TypeHashFunc) error {
+	if name == "" || hashFunc == nil {
+		return errors.New("hash function name and function must be set")
+	}
+	hashRegistry.Lock()
+	defer hashRegistry.Unlock()
+	if _, ok := hashRegistry.byName[name]; ok {
+		return errors.New("hash function name is already registered: " + name)
+	}
+	hashRegistry.byName[name] = hashFunc
+	return nil
+}
+
+// HashFuncByName returns the hash function registered under the name.
+func HashFuncByName(name string) (TypeHashFunc, bool) {
+	hashRegistry.RLock()
+	defer hashRegistry.RUnlock()
+	hashFunc, ok := hashRegistry.byName[name]
+	return hashFunc, ok
+}
+
+// registeredHashName returns the HashName of the configuration, checking that it is registered
+// by RegisterHashFuncName, so that the Merkle Tree can be deserialized.
+func registeredHashName(config *Config) (string, error) {
+	if config.HashName == "" {
+		return "", errors.New("hash function name is not set by HashName, could not marshal the Merkle Tree")
+	}
+	if _, ok := HashFuncByName(config.HashName); !ok {
+		return "", errors.New("hash function name is not registered: " + config.HashName +
+			", could not marshal the Merkle Tree")
+	}
+	return config.HashName, nil
+}
+
+// RegisterHashFunc registers the hash function under the id, so that VerifyAuto verifies the proofs
+// carrying the id with the function. The id 0 is reserved for proofs without hash id.
+// The producer of the proofs sets their HashID, e.g. from HashID in the configuration.
+// It returns an error if the id is already registered.
+func RegisterHashFunc(id byte, hashFunc TypeHashFunc) error {
+	if id == 0 || hashFunc == nil {
+		return errors.New("hash function id must be non-zero and the function must be set")
+	}
+	hashIDRegistry.Lock()
+	defer hashIDRegistry.Unlock()
+	if _, ok := hashIDRegistry.byID[id]; ok {
+		return fmt.Errorf("hash function id %d is already registered", id)
+	}
+	hashIDRegistry.byID[id] = hashFunc
+	return nil
+}
+
+// HashFuncByID returns the hash function registered under the id.
+func HashFuncByID(id byte) (TypeHashFunc, bool) {
+	hashIDRegistry.RLock()
+	defer hashIDRegistry.RUnlock()
+	hashFunc, ok := hashIDRegistry.byID[id]
+	return hashFunc, ok
+}
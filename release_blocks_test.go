@@ -0,0 +1,99 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// finalizedBlocks returns the data blocks with finalizers counting the collected payloads into collected.
+func finalizedBlocks(num int, collected *atomic.Int64) []DataBlock {
+	blocks := make([]DataBlock, num)
+	for i := range blocks {
+		block := &mock.DataBlock{Data: []byte(fmt.Sprintf("payload %d", i))}
+		runtime.SetFinalizer(block, func(*mock.DataBlock) { collected.Add(1) })
+		blocks[i] = block
+	}
+	return blocks
+}
+
+// awaitCollected forces garbage collections until the number of collected payloads reaches want or a timeout.
+func awaitCollected(collected *atomic.Int64, want int64) int64 {
+	deadline := time.Now().Add(2 * time.Second)
+	for collected.Load() < want && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	return collected.Load()
+}
+
+func TestNew_releasesDataBlocksAfterLeafHashing(t *testing.T) {
+	const numBlocks = 64
+	configs := []Config{
+		{},
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, PaddingStrategy: PaddingIndexed},
+		{RunInParallel: true, NumRoutines: 4},
+		{RunInParallel: true, NumRoutines: 4, Mode: ModeProofGenAndTreeBuild},
+		{AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}},
+	}
+	for i := range configs {
+		var collected, midBuild atomic.Int64
+		config := configs[i]
+		config.OnNode = func(level, index int, hash []byte) {
+			// The leaves are hashed when the first node is computed: collect the data blocks once.
+			if level == 1 && index == 0 {
+				midBuild.Store(awaitCollected(&collected, numBlocks))
+			}
+		}
+		if _, err := New(&config, finalizedBlocks(numBlocks, &collected)); err != nil {
+			t.Fatalf("config %d: New() error = %v", i, err)
+		}
+		if got := midBuild.Load(); got != numBlocks {
+			t.Errorf("config %d: %d of %d data blocks collected during the build, want all", i, got, numBlocks)
+		}
+	}
+}
+
+func TestBuildAsync_releasesDataBlocksAfterLeafHashing(t *testing.T) {
+	const numBlocks = 64
+	var collected, midBuild atomic.Int64
+	config := &Config{OnNode: func(level, index int, hash []byte) {
+		if level == 1 && index == 0 {
+			midBuild.Store(awaitCollected(&collected, numBlocks))
+		}
+	}}
+	if _, err := BuildAsync(context.Background(), config, finalizedBlocks(numBlocks, &collected)).Result(); err != nil {
+		t.Fatalf("BuildAsync() error = %v", err)
+	}
+	if got := midBuild.Load(); got != numBlocks {
+		t.Errorf("%d of %d data blocks collected during the build, want all", got, numBlocks)
+	}
+}
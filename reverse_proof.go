@@ -0,0 +1,51 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+// Reverse returns the proof in the opposite orientation: the siblings and the bits of the path are reversed,
+// and RootToLeaf is flipped, converting between the leaf-to-root and the root-to-leaf conventions.
+// Reversing twice yields the original proof, and the proofs verify in both orientations.
+// The siblings and the fingerprint are shared with the proof, not copied.
+func (p *Proof) Reverse() *Proof {
+	reversed := *p
+	n := len(p.Siblings)
+	reversed.Siblings = make([][]byte, n)
+	reversed.Path = 0
+	for i, sibling := range p.Siblings {
+		reversed.Siblings[n-1-i] = sibling
+		reversed.Path |= (p.Path >> i & 1) << (n - 1 - i)
+	}
+	reversed.RootToLeaf = !p.RootToLeaf
+	return &reversed
+}
+
+// reverseProofs returns the proofs reversed by Reverse.
+func reverseProofs(proofs []*Proof) []*Proof {
+	reversed := make([]*Proof, len(proofs))
+	for i, proof := range proofs {
+		if proof != nil {
+			reversed[i] = proof.Reverse()
+		}
+	}
+	return reversed
+}
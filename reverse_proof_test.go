@@ -0,0 +1,137 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProof_Reverse(t *testing.T) {
+	configs := []*Config{
+		nil,
+		{PaddingStrategy: PaddingPromote},
+		{SortSiblingPairs: true, EmbedFingerprint: true},
+		{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+	}
+	for _, numBlocks := range []int{2, 5, 8, 13} {
+		blocks := dataBlocks(numBlocks)
+		for _, config := range configs {
+			name := fmt.Sprintf("%d blocks/%+v", numBlocks, config)
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			for i, proof := range m.Proofs {
+				reversed := proof.Reverse()
+				if !reversed.RootToLeaf {
+					t.Errorf("%s: Reverse() of proof %d is not root-to-leaf", name, i)
+				}
+				n := len(proof.Siblings)
+				for k := range proof.Siblings {
+					if &reversed.Siblings[n-1-k][0] != &proof.Siblings[k][0] ||
+						reversed.Path>>(n-1-k)&1 != proof.Path>>k&1 {
+						t.Errorf("%s: Reverse() of proof %d does not flip sibling %d", name, i, k)
+					}
+				}
+				if !reversed.Reverse().Equal(proof) {
+					t.Errorf("%s: Reverse().Reverse() of proof %d = %+v, want %+v", name, i, reversed.Reverse(), proof)
+				}
+				if reversed.Equal(proof) && n > 1 {
+					t.Errorf("%s: Reverse() of proof %d equals the proof", name, i)
+				}
+				for _, p := range []*Proof{proof, reversed} {
+					if ok, err := m.Verify(blocks[i], p); err != nil || !ok {
+						t.Errorf("%s: Verify() of proof %d, root-to-leaf %v = %v, %v, want true",
+							name, i, p.RootToLeaf, ok, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConfig_StoreReversedProofs(t *testing.T) {
+	blocks := dataBlocks(7)
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeProofGenAndTreeBuild} {
+		m, err := New(&Config{Mode: mode, StoreReversedProofs: true}, blocks)
+		if err != nil {
+			t.Fatalf("%v: New() error = %v", mode, err)
+		}
+		if len(m.ReversedProofs) != len(m.Proofs) {
+			t.Fatalf("%v: len(ReversedProofs) = %d, want %d", mode, len(m.ReversedProofs), len(m.Proofs))
+		}
+		for i, proof := range m.Proofs {
+			if !m.ReversedProofs[i].Equal(proof.Reverse()) {
+				t.Errorf("%v: ReversedProofs[%d] = %+v, want %+v", mode, i, m.ReversedProofs[i], proof.Reverse())
+			}
+		}
+	}
+
+	m, err := New(&Config{Mode: ModeTreeBuild, StoreReversedProofs: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.ReversedProofs != nil {
+		t.Errorf("ReversedProofs in %v = %v, want nil", ModeTreeBuild, m.ReversedProofs)
+	}
+	m, err = New(&Config{StoreReversedProofs: false}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if m.ReversedProofs != nil {
+		t.Errorf("ReversedProofs = %v, want nil", m.ReversedProofs)
+	}
+
+	// The reversed proofs follow the updates.
+	m, err = New(&Config{Mode: ModeProofGenAndTreeBuild, StoreReversedProofs: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	blocks[2] = dataBlocks(8)[7]
+	if err = m.UpdateLeaf(2, blocks[2]); err != nil {
+		t.Fatalf("UpdateLeaf() error = %v", err)
+	}
+	for i, proof := range m.ReversedProofs {
+		if ok, err := m.Verify(blocks[i], proof); err != nil || !ok {
+			t.Errorf("Verify() of the updated reversed proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+}
+
+func TestProof_Reverse_encoding(t *testing.T) {
+	m, err := New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	reversed := m.Proofs[1].Reverse()
+	if _, err := reversed.MarshalBinary(); err == nil {
+		t.Errorf("MarshalBinary() of a root-to-leaf proof error = nil, want error")
+	}
+	if _, err := reversed.CompactEncode(); err == nil {
+		t.Errorf("CompactEncode() of a root-to-leaf proof error = nil, want error")
+	}
+	if _, err := MergeAdjacentProofs(reversed, m.Proofs[2].Reverse()); err == nil {
+		t.Errorf("MergeAdjacentProofs() of root-to-leaf proofs error = nil, want error")
+	}
+}
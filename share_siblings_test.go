@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// allocatedBytes returns the bytes allocated on the heap by the function.
+func allocatedBytes(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestConfig_ShareSiblings(t *testing.T) {
+	const numBlocks = 1 << 14
+	blocks := dataBlocks(numBlocks)
+	for _, parallel := range []bool{false, true} {
+		name := fmt.Sprintf("parallel %v", parallel)
+		var (
+			owned, shared *MerkleTree
+			err           error
+		)
+		ownedBytes := allocatedBytes(func() {
+			owned, err = New(&Config{RunInParallel: parallel}, blocks)
+		})
+		if err != nil {
+			t.Fatalf("%s: New() error = %v", name, err)
+		}
+		config := &Config{RunInParallel: parallel, ShareSiblings: true}
+		sharedBytes := allocatedBytes(func() {
+			shared, err = New(config, blocks)
+		})
+		if err != nil {
+			t.Fatalf("%s: New() with ShareSiblings error = %v", name, err)
+		}
+		// The copies of the siblings into the proof buffers are saved.
+		if saved := int64(ownedBytes) - int64(sharedBytes); saved < numBlocks*int64(owned.Depth)*defaultHashLen/2 {
+			t.Errorf("%s: ShareSiblings allocated %d bytes, want much less than %d bytes", name, sharedBytes, ownedBytes)
+		}
+		// The upper-level siblings are the same slices across the proofs.
+		last := len(shared.Proofs[0].Siblings) - 1
+		if &shared.Proofs[0].Siblings[last][0] != &shared.Proofs[1].Siblings[last][0] {
+			t.Errorf("%s: the top sibling of the proofs 0 and 1 is not shared", name)
+		}
+		for i, block := range blocks {
+			if !shared.Proofs[i].Equal(owned.Proofs[i]) {
+				t.Fatalf("%s: proof %d = %+v, want %+v", name, i, shared.Proofs[i], owned.Proofs[i])
+			}
+			if ok, err := Verify(block, shared.Proofs[i], shared.Root, config); err != nil || !ok {
+				t.Fatalf("%s: Verify() of proof %d = %v, %v, want true", name, i, ok, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SignRoot signs the Merkle root with the signer, e.g. an Ed25519 private key, and returns the signature,
+// so that the package does not depend on a signature scheme. The signature covers Root only:
+// any other data, e.g. a timestamp, must be bound by the signer.
+// The signer is called with a copy of the root.
+func (m *MerkleTree) SignRoot(signer func(root []byte) ([]byte, error)) ([]byte, error) {
+	if signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+	if len(m.Root) == 0 {
+		return nil, errors.New("merkle tree has no root")
+	}
+	signature, err := signer(append([]byte(nil), m.Root...))
+	if err != nil {
+		return nil, fmt.Errorf("sign root: %w", err)
+	}
+	return signature, nil
+}
+
+// VerifySignedRoot verifies the signature of the Merkle root, e.g. returned by SignRoot, with the verify function,
+// e.g. an Ed25519 public key. It returns false if the signature is invalid.
+func VerifySignedRoot(root, signature []byte, verify func(root, signature []byte) (bool, error)) (bool, error) {
+	if verify == nil {
+		return false, errors.New("verify function is nil")
+	}
+	if root == nil {
+		return false, errNilInput("root")
+	}
+	if len(root) == 0 {
+		return false, errors.New("root is empty")
+	}
+	ok, err := verify(root, signature)
+	if err != nil {
+		return false, fmt.Errorf("verify root signature: %w", err)
+	}
+	return ok, nil
+}
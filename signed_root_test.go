@@ -0,0 +1,95 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+func TestMerkleTree_SignRoot(t *testing.T) {
+	m, err := New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	signer := func(root []byte) ([]byte, error) {
+		return ed25519.Sign(privateKey, root), nil
+	}
+	verify := func(root, signature []byte) (bool, error) {
+		return ed25519.Verify(publicKey, root, signature), nil
+	}
+	signature, err := m.SignRoot(signer)
+	if err != nil {
+		t.Fatalf("SignRoot() error = %v", err)
+	}
+	if ok, err := VerifySignedRoot(m.Root, signature, verify); err != nil || !ok {
+		t.Errorf("VerifySignedRoot() = %v, %v, want true", ok, err)
+	}
+	other, err := New(nil, dataBlocks(5))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if ok, err := VerifySignedRoot(other.Root, signature, verify); err != nil || ok {
+		t.Errorf("VerifySignedRoot() of another root = %v, %v, want false", ok, err)
+	}
+
+	// The signer cannot modify the root of the tree.
+	root := append([]byte(nil), m.Root...)
+	if _, err = m.SignRoot(func(root []byte) ([]byte, error) {
+		root[0] ^= 0xff
+		return []byte("stub"), nil
+	}); err != nil {
+		t.Fatalf("SignRoot() error = %v", err)
+	}
+	if !bytes.Equal(m.Root, root) {
+		t.Errorf("SignRoot() modified the root")
+	}
+
+	errStub := errors.New("stub error")
+	if _, err = m.SignRoot(func([]byte) ([]byte, error) { return nil, errStub }); !errors.Is(err, errStub) {
+		t.Errorf("SignRoot() error = %v, want %v", err, errStub)
+	}
+	if _, err = VerifySignedRoot(m.Root, signature, func([]byte, []byte) (bool, error) {
+		return true, errStub
+	}); !errors.Is(err, errStub) {
+		t.Errorf("VerifySignedRoot() error = %v, want %v", err, errStub)
+	}
+	if _, err = m.SignRoot(nil); err == nil {
+		t.Errorf("SignRoot(nil) error = nil, want error")
+	}
+	if _, err = (&MerkleTree{}).SignRoot(signer); err == nil {
+		t.Errorf("SignRoot() without root error = nil, want error")
+	}
+	if _, err = VerifySignedRoot(m.Root, signature, nil); err == nil {
+		t.Errorf("VerifySignedRoot(nil) error = nil, want error")
+	}
+	if _, err = VerifySignedRoot(nil, signature, verify); err == nil {
+		t.Errorf("VerifySignedRoot() of an empty root error = nil, want error")
+	}
+}
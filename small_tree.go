@@ -0,0 +1,210 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+)
+
+// smallTreeMaxLeaves is the maximum number of leaves of the trees whose proofs are generated
+// by the small-tree fast path.
+const smallTreeMaxLeaves = 64
+
+// isSmallTree reports whether the proofs are generated by the small-tree fast path,
+// i.e. the serial proof generation of at most smallTreeMaxLeaves leaves.
+func (m *MerkleTree) isSmallTree() bool {
+	return m.NumLeaves <= smallTreeMaxLeaves && m.Config.Mode == ModeProofGen && !m.RunInParallel
+}
+
+// isSmallTreeLeaves reports whether the leaves are generated by the small-tree fast path,
+// i.e. the serial generation of at most smallTreeMaxLeaves leaves hashed with the default hash function.
+func (m *MerkleTree) isSmallTreeLeaves() bool {
+	return m.NumLeaves <= smallTreeMaxLeaves && !m.RunInParallel && !m.DisableLeafHashing && m.Hasher == nil &&
+		m.LevelHashFunc == nil && len(m.AdditionalHashFuncs) == 0 && m.OnAnomaly == nil && isDefaultHashFunc(m.HashFunc)
+}
+
+// leafGenSmall generates the leaves of a small tree, identical to those of leafGen,
+// storing the leaves in a single arena.
+func (m *MerkleTree) leafGenSmall(blocks []DataBlock) ([][]byte, error) {
+	var (
+		leaves = make([][]byte, m.NumLeaves)
+		arena  = make([]byte, m.NumLeaves*sha256.Size)
+	)
+	for i := range leaves {
+		if err := m.checkCanceled(); err != nil {
+			return nil, err
+		}
+		blockBytes, err := blocks[i].Serialize()
+		if err != nil {
+			return nil, err
+		}
+		if err = m.checkLeafSize(blockBytes, i); err != nil {
+			return nil, err
+		}
+		if blockBytes, err = transformLeaf(blockBytes, m.Config); err != nil {
+			return nil, err
+		}
+		if m.BindLeafIndex {
+			blockBytes = bindLeafIndex(blockBytes, i)
+		}
+		digest := sha256.Sum256(blockBytes)
+		leaves[i] = arena[i*sha256.Size : (i+1)*sha256.Size : (i+1)*sha256.Size]
+		copy(leaves[i], digest[:])
+		if err = m.checkHashSize(leaves[i]); err != nil {
+			return nil, err
+		}
+	}
+	return leaves, nil
+}
+
+// initSmallProofs allocates the proofs of a small tree, their sibling slices and, if the hash size is set,
+// their buffers at once, instead of once for each proof.
+func (m *MerkleTree) initSmallProofs(hashSize int) {
+	var (
+		depth    = int(m.Depth)
+		proofs   = make([]Proof, m.NumLeaves)
+		siblings = make([][]byte, m.NumLeaves*depth)
+	)
+	m.Proofs = make([]*Proof, m.NumLeaves)
+	for i := range proofs {
+		proofs[i] = Proof{
+			Index:       i,
+			Siblings:    siblings[i*depth : i*depth : (i+1)*depth],
+			Fingerprint: m.fingerprint,
+		}
+		m.Proofs[i] = &proofs[i]
+	}
+	if hashSize == 0 {
+		return
+	}
+	var (
+		siblingsLen = depth * hashSize
+		bufLen      = siblingsLen + len(m.fingerprint)
+		buf         = make([]byte, m.NumLeaves*bufLen)
+	)
+	m.proofBufs, m.proofSiblingSize = make([][]byte, m.NumLeaves), hashSize
+	for i, proof := range m.Proofs {
+		proofBuf := buf[i*bufLen : (i+1)*bufLen : (i+1)*bufLen]
+		if m.fingerprint != nil {
+			proof.Fingerprint = proofBuf[siblingsLen:]
+			copy(proof.Fingerprint, m.fingerprint)
+		}
+		m.proofBufs[i] = proofBuf[:siblingsLen:siblingsLen]
+	}
+}
+
+// proofGenSmall generates the proofs and the root of a small tree, identical to those of proofGen.
+// The levels are computed in place in a stack-allocated scratch buffer, and if the nodes are computed
+// with the default hash function, the children are concatenated in a scratch array
+// and the nodes are stored in a single arena referenced by the proofs.
+func (m *MerkleTree) proofGenSmall() (err error) {
+	var (
+		scratch  [smallTreeMaxLeaves + 1][]byte
+		buf      = scratch[:m.NumLeaves]
+		prevLen  = m.NumLeaves
+		depth    = int(m.Depth)
+		hashSize = m.HashSize()
+		nodes    *smallTreeNodes
+	)
+	copy(buf, m.Leaves)
+	if m.DisableLeafHashing {
+		hashSize = 0
+	}
+	if ok, sorted := m.isDefaultNodeHashing(hashSize); ok {
+		// The number of nodes is at most the number of leaves plus one padding node at each level.
+		nodes = &smallTreeNodes{arena: make([]byte, 0, (m.NumLeaves+depth)*sha256.Size), sort: sorted}
+		// The nodes in the arena are only referenced by the tree and its proofs,
+		// so the proofs reference them instead of copying them into buffers.
+		hashSize = 0
+	}
+	m.initSmallProofs(hashSize)
+	defer func() {
+		m.proofBufs, m.proofSiblingSize = nil, 0
+	}()
+	for level := 0; ; level++ {
+		if buf, prevLen, err = m.fixOdd(buf, prevLen); err != nil {
+			return
+		}
+		m.updateProofs(buf, prevLen, level)
+		if level == depth-1 {
+			break
+		}
+		if err = m.checkCanceled(); err != nil {
+			return
+		}
+		for idx := 0; idx < prevLen; idx += 2 {
+			if nodes != nil {
+				buf[idx>>1] = nodes.hashPair(buf[idx], buf[idx+1])
+				continue
+			}
+			if buf[idx>>1], err = m.hashNode(level+1, idx>>1, buf[idx], buf[idx+1]); err != nil {
+				return
+			}
+		}
+		prevLen >>= 1
+	}
+	if nodes != nil {
+		m.Root = nodes.hashPair(buf[0], buf[1])
+	} else if m.Root, err = m.hashNode(depth, 0, buf[0], buf[1]); err != nil {
+		return
+	}
+	m.Root = truncateRoot(m.Root, m.Config)
+	return
+}
+
+// isDefaultNodeHashing reports whether the nodes of hashSize bytes are computed with the default hash function
+// from the concatenation, sorted or not, of their children, and are not reported to OnNode,
+// so that they are computed without calling the hash function and the concatenation function of the tree.
+func (m *MerkleTree) isDefaultNodeHashing(hashSize int) (ok, sorted bool) {
+	concatPtr := reflect.ValueOf(m.concatFunc).Pointer()
+	sorted = concatPtr == reflect.ValueOf(concatSortHash).Pointer()
+	ok = hashSize == sha256.Size && m.OnNode == nil && m.PairHashFunc == nil && m.LevelHashFunc == nil &&
+		isDefaultHashFunc(m.HashFunc) &&
+		(sorted || concatPtr == reflect.ValueOf(concatHash).Pointer())
+	return ok, sorted
+}
+
+// smallTreeNodes computes the nodes of a small tree with the default hash function.
+type smallTreeNodes struct {
+	concat [2 * sha256.Size]byte
+	arena  []byte
+	sort   bool
+}
+
+// hashPair computes the parent of the nodes, as MerkleTree.hashPair with the default hash function.
+func (s *smallTreeNodes) hashPair(left, right []byte) []byte {
+	if right == nil {
+		return left // The node is promoted.
+	}
+	if s.sort && bytes.Compare(left, right) >= 0 {
+		left, right = right, left
+	}
+	copy(s.concat[:sha256.Size], left)
+	copy(s.concat[sha256.Size:], right)
+	digest := sha256.Sum256(s.concat[:])
+	start := len(s.arena)
+	s.arena = append(s.arena, digest[:]...)
+	return s.arena[start:len(s.arena):len(s.arena)]
+}
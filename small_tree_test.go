@@ -0,0 +1,149 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func TestNew_smallTree(t *testing.T) {
+	tagLeaf := func(data []byte) ([]byte, error) {
+		return append([]byte("leaf:"), data...), nil
+	}
+	customHashFunc := func(data []byte) ([]byte, error) {
+		sum := sha256.Sum224(data)
+		return sum[:], nil
+	}
+	configs := []Config{
+		{},
+		{SortSiblingPairs: true},
+		{PaddingStrategy: PaddingPromote},
+		{BindLeafIndex: true, EmbedFingerprint: true},
+		{LeafTransform: tagLeaf},
+		{DisableLeafHashing: true},
+		{HashFunc: customHashFunc},
+		{Hasher: sha256.New},
+		{OnNode: func(int, int, []byte) {}},
+		{RootBytes: 20},
+	}
+	for numBlocks := 2; numBlocks <= smallTreeMaxLeaves+1; numBlocks++ {
+		blocks := dataBlocks(numBlocks)
+		for i, config := range configs {
+			name := fmt.Sprintf("%d blocks/config %d", numBlocks, i)
+			small := config
+			m, err := New(&small, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			// The parallel proof generation never takes the small-tree fast path.
+			general := config
+			general.RunInParallel, general.NumRoutines = true, 2
+			want, err := New(&general, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatalf("%s: Root = %x, want %x", name, m.Root, want.Root)
+			}
+			for j := range blocks {
+				if !bytes.Equal(m.Leaves[j], want.Leaves[j]) {
+					t.Fatalf("%s: leaf %d differs from the general path", name, j)
+				}
+				if !m.Proofs[j].Equal(want.Proofs[j]) {
+					t.Fatalf("%s: proof %d differs from the general path", name, j)
+				}
+				if ok, err := Verify(blocks[j], m.Proofs[j], m.Root, &small); err != nil || !ok {
+					t.Fatalf("%s: Verify() of proof %d = %v, %v, want true", name, j, ok, err)
+				}
+			}
+		}
+	}
+	// With NoDuplicates, the padding nodes are derived from the last nodes.
+	blocks := dataBlocks(13)
+	config := &Config{NoDuplicates: true}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i, block := range blocks {
+		if ok, err := Verify(block, m.Proofs[i], m.Root, config); err != nil || !ok {
+			t.Errorf("Verify() of proof %d with NoDuplicates = %v, %v, want true", i, ok, err)
+		}
+	}
+}
+
+func TestNew_unrolledTree(t *testing.T) {
+	configs := []Config{
+		{},
+		{SortSiblingPairs: true},
+		{BindLeafIndex: true, EmbedFingerprint: true},
+		{RootBytes: 20},
+	}
+	for _, numBlocks := range []int{2, 4, 8} {
+		blocks := dataBlocks(numBlocks)
+		for i, config := range configs {
+			name := fmt.Sprintf("%d blocks/config %d", numBlocks, i)
+			unrolled := config
+			m, err := New(&unrolled, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if !m.isUnrolledTree() {
+				t.Fatalf("%s: isUnrolledTree() = false, want true", name)
+			}
+			// The small-tree fast path computes the same tree with the generic level loop.
+			small := config
+			want, err := New(&small, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			want.Proofs, want.Root = nil, nil
+			if err = want.proofGenSmall(); err != nil {
+				t.Fatalf("%s: proofGenSmall() error = %v", name, err)
+			}
+			if !bytes.Equal(m.Root, want.Root) {
+				t.Fatalf("%s: Root = %x, want %x", name, m.Root, want.Root)
+			}
+			for j := range blocks {
+				if !m.Proofs[j].Equal(want.Proofs[j]) {
+					t.Fatalf("%s: proof %d differs from the small-tree fast path", name, j)
+				}
+				if ok, err := Verify(blocks[j], m.Proofs[j], m.Root, &unrolled); err != nil || !ok {
+					t.Fatalf("%s: Verify() of proof %d = %v, %v, want true", name, j, ok, err)
+				}
+			}
+		}
+	}
+	for _, config := range []Config{{OnNode: func(int, int, []byte) {}}, {DisableLeafHashing: true}, {RunInParallel: true}} {
+		m, err := New(&config, dataBlocks(4))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if m.isUnrolledTree() {
+			t.Errorf("isUnrolledTree() = true with config %+v, want false", config)
+		}
+	}
+}
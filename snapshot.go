@@ -0,0 +1,73 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "sync"
+
+// Snapshot returns a snapshot of the Merkle Tree, e.g. to publish a consistent version of the tree to readers
+// while a writer keeps updating the tree with UpdateBatch. The snapshot shares the leaves, the tree nodes
+// and the proofs with the Merkle Tree, and only the slice of the tree levels is copied, so it takes O(Depth):
+// UpdateBatch replaces the levels, the leaves and the proofs it changes instead of modifying them,
+// and the leaf map is copied by the first update of either tree. The updates of the Merkle Tree thus never
+// affect the snapshot, whose proofs verify against its root, and the updates of the snapshot never affect
+// the Merkle Tree. The snapshot may be read concurrently with the updates of the Merkle Tree,
+// but Snapshot itself must not be called concurrently with UpdateBatch or Prune.
+func (m *MerkleTree) Snapshot() *MerkleTree {
+	s := &MerkleTree{
+		Config:           m.Config,
+		leafMap:          m.leafMap,
+		leafMapShared:    m.leafMap != nil,
+		nodes:            append([][][]byte(nil), m.nodes...),
+		pruned:           m.pruned,
+		keptLevel:        m.keptLevel,
+		keyMap:           m.keyMap,
+		Root:             m.Root,
+		Leaves:           m.Leaves,
+		Proofs:           m.Proofs,
+		LeafIndexes:      m.LeafIndexes,
+		ReversedProofs:   m.ReversedProofs,
+		Depth:            m.Depth,
+		NumLeaves:        m.NumLeaves,
+		fingerprint:      m.fingerprint,
+		numWorkers:       m.numWorkers,
+		additionalRoots:  m.additionalRoots,
+		numPaddingLeaves: m.numPaddingLeaves,
+		paddingProofs:    m.paddingProofs,
+	}
+	s.hashSize.Store(m.hashSize.Load())
+	m.leafMapShared = s.leafMapShared
+	return s
+}
+
+// ownLeafMap copies the leaf map if it is shared with a snapshot, so that it can be modified.
+func (m *MerkleTree) ownLeafMap() {
+	if !m.leafMapShared {
+		return
+	}
+	leafMap := &sync.Map{}
+	m.leafMap.Range(func(key, val any) bool {
+		leafMap.Store(key, val)
+		return true
+	})
+	m.leafMap, m.leafMapShared = leafMap, false
+}
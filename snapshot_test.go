@@ -0,0 +1,190 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMerkleTree_Snapshot(t *testing.T) {
+	for _, config := range []Config{
+		{Mode: ModeTreeBuild},
+		{Mode: ModeProofGenAndTreeBuild, StoreReversedProofs: true},
+		{Mode: ModeTreeBuild, PaddingStrategy: PaddingIndexed},
+		{Mode: ModeTreeBuild, NoDuplicates: true, RunInParallel: true, NumRoutines: 2},
+	} {
+		config := config
+		blocks := dataBlocks(13)
+		m, err := New(&config, blocks)
+		if err != nil {
+			t.Fatalf("%+v: New() error = %v", config, err)
+		}
+		snapshot := m.Snapshot()
+		updated := append([]DataBlock(nil), blocks...)
+		for round := 0; round < 3; round++ {
+			updates := map[int]DataBlock{round: dataBlocks(1)[0], 12 - round: dataBlocks(1)[0]}
+			if err = m.UpdateBatch(updates); err != nil {
+				t.Fatalf("%+v: UpdateBatch() error = %v", config, err)
+			}
+			for idx, block := range updates {
+				updated[idx] = block
+			}
+		}
+		// The snapshot is the tree of the original data blocks, and the tree that of the updated data blocks.
+		for _, tt := range []struct {
+			tree   *MerkleTree
+			blocks []DataBlock
+		}{{snapshot, blocks}, {m, updated}} {
+			wantConfig := config
+			want, err := New(&wantConfig, tt.blocks)
+			if err != nil {
+				t.Fatalf("%+v: New() error = %v", config, err)
+			}
+			if !Equal(tt.tree, want) {
+				t.Fatalf("%+v: tree differs from the tree built from its data blocks", config)
+			}
+			for i, block := range tt.blocks {
+				proof, err := tt.tree.GenerateProof(block)
+				if err != nil {
+					t.Fatalf("%+v: GenerateProof() of block %d error = %v", config, i, err)
+				}
+				if ok, err := Verify(block, proof, tt.tree.Root, &config); err != nil || !ok {
+					t.Errorf("%+v: Verify() of block %d = %v, %v, want true", config, i, ok, err)
+				}
+			}
+			if tt.tree.ReversedProofs != nil && !tt.tree.ReversedProofs[0].Reverse().Equal(tt.tree.Proofs[0]) {
+				t.Errorf("%+v: reversed proof of leaf 0 differs from the proof", config)
+			}
+		}
+		if _, err = snapshot.IndexOf(updated[0]); err == nil {
+			t.Errorf("%+v: IndexOf() of an updated data block in the snapshot error = nil, want error", config)
+		}
+
+		// Updating the snapshot does not affect the tree.
+		root := m.Root
+		if err = snapshot.UpdateLeaf(5, dataBlocks(1)[0]); err != nil {
+			t.Fatalf("%+v: UpdateLeaf() of the snapshot error = %v", config, err)
+		}
+		if !bytes.Equal(m.Root, root) {
+			t.Errorf("%+v: root changed by the update of the snapshot", config)
+		}
+		if idx, err := m.IndexOf(updated[5]); err != nil || idx != 5 {
+			t.Errorf("%+v: IndexOf() after the update of the snapshot = %d, %v, want 5", config, idx, err)
+		}
+	}
+}
+
+func TestMerkleTree_Snapshot_pruned(t *testing.T) {
+	blocks := dataBlocks(16)
+	m, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	snapshot := m.Snapshot()
+	// Pruning the tree frees its levels, not those of the snapshot.
+	if err = m.Prune(0); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if node, err := snapshot.NodeAt(2, 1); err != nil || node == nil {
+		t.Fatalf("NodeAt() of the snapshot = %x, %v", node, err)
+	}
+	if snapshot.pruned || snapshot.nodes[2] == nil {
+		t.Errorf("snapshot is pruned with the tree")
+	}
+}
+
+func TestMerkleTree_Snapshot_concurrent(t *testing.T) {
+	// A writer updates the tree and publishes a snapshot after each update,
+	// while readers verify the proofs of the latest published snapshot against its root.
+	const (
+		numLeaves  = 256
+		numRounds  = 200
+		numUpdates = 16
+		numReaders = 4
+	)
+	type version struct {
+		tree   *MerkleTree
+		blocks []DataBlock
+	}
+	blocks := dataBlocks(numLeaves)
+	config := &Config{Mode: ModeProofGenAndTreeBuild}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var (
+		published atomic.Pointer[version]
+		done      = make(chan struct{})
+		wg        sync.WaitGroup
+		failures  atomic.Int64
+	)
+	published.Store(&version{tree: m.Snapshot(), blocks: append([]DataBlock(nil), blocks...)})
+	for reader := 0; reader < numReaders; reader++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				v := published.Load()
+				i := r.Intn(numLeaves)
+				proof, err := v.tree.ProofOf(i)
+				if err != nil {
+					failures.Add(1)
+					continue
+				}
+				if ok, err := v.tree.Verify(v.blocks[i], proof); err != nil || !ok {
+					failures.Add(1)
+				}
+				if idx, err := v.tree.IndexOf(v.blocks[i]); err != nil || v.blocks[idx] != v.blocks[i] {
+					failures.Add(1)
+				}
+			}
+		}(int64(reader))
+	}
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < numRounds; round++ {
+		updates := make(map[int]DataBlock, numUpdates)
+		for _, idx := range r.Perm(numLeaves)[:numUpdates] {
+			updates[idx] = dataBlocks(1)[0]
+			blocks[idx] = updates[idx]
+		}
+		if err := m.UpdateBatch(updates); err != nil {
+			t.Fatalf("UpdateBatch() error = %v", err)
+		}
+		published.Store(&version{tree: m.Snapshot(), blocks: append([]DataBlock(nil), blocks...)})
+	}
+	close(done)
+	wg.Wait()
+	if n := failures.Load(); n > 0 {
+		t.Errorf("%d proofs of the published snapshots failed", n)
+	}
+}
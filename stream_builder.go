@@ -0,0 +1,165 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StreamBuilder computes the Merkle root of data blocks appended one at a time, e.g. to checksum a huge dataset.
+// Each data block is hashed and its leaf folded as soon as it is appended, keeping only one pending node per level,
+// so neither the data blocks nor the leaves are retained and the memory is O(log n).
+// The root is identical to that of the tree built by New with the same configuration for the data blocks.
+// The computation is serial, the mode is ignored and OnNode is not called. AdditionalHashFuncs and the handling
+// of duplicate leaves other than DuplicateLeafAllow are not supported.
+type StreamBuilder struct {
+	m        *MerkleTree
+	folder   *proofFolder
+	finished bool
+}
+
+// NewStreamBuilder returns a StreamBuilder with the configuration, which is not modified.
+func NewStreamBuilder(config *Config) (*StreamBuilder, error) {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	m := &MerkleTree{Config: &c}
+	m.initConfig()
+	if err := m.checkPaddingStrategy(); err != nil {
+		return nil, err
+	}
+	if m.RootBytes < 0 {
+		return nil, fmt.Errorf("invalid root length %d", m.RootBytes)
+	}
+	if err := checkLevelHashFunc(m.Config); err != nil {
+		return nil, err
+	}
+	if len(m.AdditionalHashFuncs) > 0 {
+		return nil, errors.New("AdditionalHashFuncs is not supported when computing the root only")
+	}
+	if m.OnDuplicateLeaf != DuplicateLeafAllow {
+		return nil, fmt.Errorf("%v is not supported when computing the root only", m.OnDuplicateLeaf)
+	}
+	return &StreamBuilder{m: m, folder: &proofFolder{m: m, target: -1}}, nil
+}
+
+// Append hashes the data block as the next leaf and folds it.
+func (b *StreamBuilder) Append(block DataBlock) error {
+	if b.finished {
+		return errors.New("stream builder is finished")
+	}
+	if block == nil {
+		return fmt.Errorf("data block at leaf index %d is nil", b.m.NumLeaves)
+	}
+	leaf, _, err := b.m.dataBlockLeaves(block, b.m.NumLeaves)
+	if err != nil {
+		return err
+	}
+	if !b.m.DisableLeafHashing {
+		if err = b.m.checkHashSize(leaf); err != nil {
+			return err
+		}
+	}
+	if err = b.folder.push(0, leaf); err != nil {
+		return err
+	}
+	b.m.NumLeaves++
+	return nil
+}
+
+// NumLeaves returns the number of data blocks appended.
+func (b *StreamBuilder) NumLeaves() int {
+	return b.m.NumLeaves
+}
+
+// Peaks returns the peaks of the data blocks appended, from left to right: the roots of the perfect subtrees
+// of the leaves folded so far, one per set bit of the number of leaves, the leftmost being the largest subtree.
+// They are the frontier of the stream, e.g. to compute an interim commitment without finishing the stream.
+// After Root, the only peak is the root before its truncation to RootBytes.
+func (b *StreamBuilder) Peaks() [][]byte {
+	var peaks [][]byte
+	for level := len(b.folder.pending) - 1; level >= 0; level-- {
+		if node := b.folder.pending[level]; node != nil {
+			peaks = append(peaks, append([]byte(nil), node...))
+		}
+	}
+	return peaks
+}
+
+// Root finishes the stream, padding the odd levels, and returns the Merkle root.
+// No data block can be appended after.
+func (b *StreamBuilder) Root() ([]byte, error) {
+	if b.finished {
+		return nil, errors.New("stream builder is finished")
+	}
+	if b.m.NumLeaves <= 1 {
+		return nil, errors.New("the number of data blocks must be greater than 1")
+	}
+	b.finished = true
+	numLeaves := b.m.NumLeaves
+	if b.m.PaddingStrategy == PaddingIndexed {
+		numLeaves = indexedPaddingLen(b.m.NumLeaves)
+		for idx := b.m.NumLeaves; idx < numLeaves; idx++ {
+			leaf, err := paddingLeaf(idx, b.m.Config)
+			if err != nil {
+				return nil, err
+			}
+			if err = b.folder.push(0, leaf); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b.m.Depth = calTreeDepth(numLeaves)
+	root, err := b.folder.finish()
+	if err != nil {
+		return nil, err
+	}
+	return truncateRoot(root, b.m.Config), nil
+}
+
+// ComputeRoot computes the Merkle root of the data blocks returned by next, until next returns io.EOF,
+// with a StreamBuilder, so the data blocks and the leaves are not retained and the memory is O(log n).
+func ComputeRoot(config *Config, next func() (DataBlock, error)) ([]byte, error) {
+	if next == nil {
+		return nil, errors.New("next function is nil")
+	}
+	b, err := NewStreamBuilder(config)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		block, err := next()
+		if err == io.EOF {
+			return b.Root()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = b.Append(block); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"runtime"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// blockIterator returns a function returning the data blocks, then io.EOF.
+func blockIterator(blocks []DataBlock) func() (DataBlock, error) {
+	i := 0
+	return func() (DataBlock, error) {
+		if i == len(blocks) {
+			return nil, io.EOF
+		}
+		i++
+		return blocks[i-1], nil
+	}
+}
+
+func TestComputeRoot(t *testing.T) {
+	configs := []Config{
+		{},
+		{SortSiblingPairs: true},
+		{PaddingStrategy: PaddingPromote},
+		{PaddingStrategy: PaddingIndexed},
+		{BindLeafIndex: true},
+		{DisableLeafHashing: true},
+		{RootBytes: 20},
+		{Mode: ModeTreeBuild, RunInParallel: true},
+	}
+	for _, numBlocks := range []int{2, 3, 5, 8, 9, 100, 1025} {
+		blocks := dataBlocks(numBlocks)
+		for i, config := range configs {
+			name := fmt.Sprintf("%d blocks/config %d", numBlocks, i)
+			newConfig, rootConfig := config, config
+			want, err := New(&newConfig, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			root, err := ComputeRoot(&rootConfig, blockIterator(blocks))
+			if err != nil {
+				t.Fatalf("%s: ComputeRoot() error = %v", name, err)
+			}
+			if !bytes.Equal(root, want.Root) {
+				t.Errorf("%s: ComputeRoot() = %x, want %x", name, root, want.Root)
+			}
+		}
+	}
+}
+
+func TestComputeRoot_memory(t *testing.T) {
+	const (
+		numBlocks = 1 << 20
+		sample    = 1 << 17
+	)
+	var (
+		before, stats runtime.MemStats
+		maxGrowth     int64
+		i             int
+	)
+	next := func() (DataBlock, error) {
+		if i == numBlocks {
+			return nil, io.EOF
+		}
+		if i%sample == sample-1 {
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			if growth := int64(stats.HeapAlloc) - int64(before.HeapAlloc); growth > maxGrowth {
+				maxGrowth = growth
+			}
+		}
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(i))
+		i++
+		return &mock.DataBlock{Data: data}, nil
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	if _, err := ComputeRoot(nil, next); err != nil {
+		t.Fatalf("ComputeRoot() error = %v", err)
+	}
+	// The leaves alone would take over 32 MiB.
+	if maxGrowth > 16<<10 {
+		t.Errorf("ComputeRoot() retained %d bytes, want at most %d", maxGrowth, 16<<10)
+	}
+}
+
+func TestComputeRoot_errors(t *testing.T) {
+	blocks := dataBlocks(4)
+	errNext := errors.New("next error")
+	tests := []struct {
+		name   string
+		config *Config
+		next   func() (DataBlock, error)
+	}{
+		{"nil next", nil, nil},
+		{"one block", nil, blockIterator(blocks[:1])},
+		{"nil block", nil, blockIterator([]DataBlock{blocks[0], nil})},
+		{"next error", nil, func() (DataBlock, error) { return nil, errNext }},
+		{"additional hash functions", &Config{AdditionalHashFuncs: []TypeHashFunc{DefaultHashFunc}}, blockIterator(blocks)},
+		{"duplicate leaf error", &Config{OnDuplicateLeaf: DuplicateLeafError}, blockIterator(blocks)},
+		{"invalid root length", &Config{RootBytes: -1}, blockIterator(blocks)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ComputeRoot(tt.config, tt.next); err == nil {
+				t.Errorf("ComputeRoot() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestStreamBuilder(t *testing.T) {
+	blocks := dataBlocks(11)
+	want, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b, err := NewStreamBuilder(nil)
+	if err != nil {
+		t.Fatalf("NewStreamBuilder() error = %v", err)
+	}
+	if _, err = b.Root(); err == nil {
+		t.Errorf("Root() of no data block error = nil, want error")
+	}
+	for _, block := range blocks {
+		if err = b.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if b.NumLeaves() != len(blocks) {
+		t.Errorf("NumLeaves() = %d, want %d", b.NumLeaves(), len(blocks))
+	}
+	root, err := b.Root()
+	if err != nil {
+		t.Fatalf("Root() error = %v", err)
+	}
+	if !bytes.Equal(root, want.Root) {
+		t.Errorf("Root() = %x, want %x", root, want.Root)
+	}
+	if err = b.Append(blocks[0]); err == nil {
+		t.Errorf("Append() after Root() error = nil, want error")
+	}
+	if _, err = b.Root(); err == nil {
+		t.Errorf("Root() after Root() error = nil, want error")
+	}
+}
+
+func TestStreamBuilder_Peaks(t *testing.T) {
+	blocks := dataBlocks(11)
+	b, err := NewStreamBuilder(nil)
+	if err != nil {
+		t.Fatalf("NewStreamBuilder() error = %v", err)
+	}
+	if peaks := b.Peaks(); len(peaks) != 0 {
+		t.Errorf("Peaks() of no data block = %d peaks, want 0", len(peaks))
+	}
+	for i, block := range blocks {
+		if err = b.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if got, want := len(b.Peaks()), bits.OnesCount(uint(i+1)); got != want {
+			t.Errorf("Peaks() of %d data blocks = %d peaks, want %d", i+1, got, want)
+		}
+	}
+	// 11 = 8 + 2 + 1: the peaks are the roots of the first 8 leaves, of the next 2, and the last leaf.
+	var want [][]byte
+	for _, subtree := range [][]DataBlock{blocks[:8], blocks[8:10]} {
+		m, err := New(nil, subtree)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		want = append(want, m.Root)
+	}
+	leaf, err := leafFromBlock(blocks[10], 10, verifyConfig(nil))
+	if err != nil {
+		t.Fatalf("leafFromBlock() error = %v", err)
+	}
+	want = append(want, leaf)
+	peaks := b.Peaks()
+	if len(peaks) != len(want) {
+		t.Fatalf("Peaks() = %d peaks, want %d", len(peaks), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(peaks[i], want[i]) {
+			t.Errorf("Peaks()[%d] = %x, want %x", i, peaks[i], want[i])
+		}
+	}
+	peaks[0][0] ^= 1
+	if bytes.Equal(b.Peaks()[0], peaks[0]) {
+		t.Error("Peaks() shares the nodes with the stream builder")
+	}
+	root, err := b.Root()
+	if err != nil {
+		t.Fatalf("Root() error = %v", err)
+	}
+	if peaks = b.Peaks(); len(peaks) != 1 || !bytes.Equal(peaks[0], root) {
+		t.Errorf("Peaks() after Root() = %x, want the root %x", peaks, root)
+	}
+}
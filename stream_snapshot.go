@@ -0,0 +1,118 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// streamSnapshotVersion is the version byte of the StreamBuilder snapshot encoding.
+const streamSnapshotVersion = 1
+
+// Snapshot encodes the frontier of the StreamBuilder, i.e. its pending nodes, and the number of data blocks
+// appended, e.g. to persist a long stream for crash recovery and resume it with RestoreStreamBuilder.
+// The snapshot is O(log n) in size, with one node per set bit of the number of data blocks.
+//
+// The encoding is the version byte, the configuration fingerprint prefixed with its length as a uvarint,
+// the number of data blocks as a uvarint, and the pending nodes from the leaf level up,
+// each prefixed with its length as a uvarint.
+func (b *StreamBuilder) Snapshot() ([]byte, error) {
+	if b.finished {
+		return nil, errors.New("stream builder is finished")
+	}
+	fingerprint, err := configFingerprint(b.m.Config)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte{streamSnapshotVersion}
+	data = binary.AppendUvarint(data, uint64(len(fingerprint)))
+	data = append(data, fingerprint...)
+	data = binary.AppendUvarint(data, uint64(b.m.NumLeaves))
+	for _, node := range b.folder.pending {
+		if node != nil {
+			data = binary.AppendUvarint(data, uint64(len(node)))
+			data = append(data, node...)
+		}
+	}
+	return data, nil
+}
+
+// RestoreStreamBuilder returns a StreamBuilder with the configuration, resumed from the snapshot of Snapshot,
+// so that appending the remaining data blocks results in the root of the uninterrupted stream.
+// It returns ErrConfigMismatch if the snapshot was taken with a different configuration.
+// The configuration is not modified, and the nodes are copied, so the StreamBuilder does not reference the data.
+func RestoreStreamBuilder(data []byte, config *Config) (*StreamBuilder, error) {
+	b, err := NewStreamBuilder(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("stream snapshot data is empty")
+	}
+	if data[0] != streamSnapshotVersion {
+		return nil, fmt.Errorf("unsupported stream snapshot encoding version %d", data[0])
+	}
+	d := proofDecoder{data: data[1:]}
+	fingerprint := d.bytes()
+	numLeaves := d.uvarint()
+	if d.err != nil {
+		return nil, fmt.Errorf("invalid stream snapshot: %v", d.err)
+	}
+	want, err := configFingerprint(b.m.Config)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(fingerprint, want) {
+		return nil, ErrConfigMismatch
+	}
+	if numLeaves > uint64(maxInt) {
+		return nil, fmt.Errorf("invalid number of data blocks %d in stream snapshot", numLeaves)
+	}
+	// The nodes pushed at each level are the number of data blocks shifted by the level,
+	// and a node is pending at the levels of the set bits.
+	numLevels := bits.Len64(numLeaves)
+	b.folder.pending, b.folder.counts = make([][]byte, numLevels), make([]int, numLevels)
+	for level := range b.folder.counts {
+		b.folder.counts[level] = int(numLeaves >> level)
+		if numLeaves>>level&1 == 0 {
+			continue
+		}
+		node := d.bytes()
+		if d.err != nil {
+			return nil, fmt.Errorf("invalid stream snapshot: %v", d.err)
+		}
+		if len(node) == 0 {
+			return nil, fmt.Errorf("empty pending node at level %d in stream snapshot", level)
+		}
+		b.folder.pending[level] = append([]byte(nil), node...)
+	}
+	if len(d.data) != 0 {
+		return nil, errors.New("trailing bytes after the stream snapshot data")
+	}
+	b.m.NumLeaves = int(numLeaves)
+	return b, nil
+}
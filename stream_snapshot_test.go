@@ -0,0 +1,145 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStreamBuilder_Snapshot(t *testing.T) {
+	configs := []Config{
+		{},
+		{SortSiblingPairs: true},
+		{PaddingStrategy: PaddingPromote},
+		{PaddingStrategy: PaddingIndexed},
+		{BindLeafIndex: true},
+		{NoDuplicates: true, RootBytes: 20},
+	}
+	for _, numBlocks := range []int{2, 5, 8, 11, 100} {
+		blocks := dataBlocks(numBlocks)
+		for i, config := range configs {
+			newConfig := config
+			want, err := New(&newConfig, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			// The stream is interrupted after half of the data blocks, and at every position for a few blocks.
+			splits := []int{numBlocks / 2}
+			if numBlocks <= 11 {
+				splits = splits[:0]
+				for split := 0; split <= numBlocks; split++ {
+					splits = append(splits, split)
+				}
+			}
+			for _, split := range splits {
+				name := fmt.Sprintf("%d blocks/config %d/split %d", numBlocks, i, split)
+				b, err := NewStreamBuilder(&config)
+				if err != nil {
+					t.Fatalf("%s: NewStreamBuilder() error = %v", name, err)
+				}
+				for _, block := range blocks[:split] {
+					if err = b.Append(block); err != nil {
+						t.Fatalf("%s: Append() error = %v", name, err)
+					}
+				}
+				data, err := b.Snapshot()
+				if err != nil {
+					t.Fatalf("%s: Snapshot() error = %v", name, err)
+				}
+				restored, err := RestoreStreamBuilder(data, &config)
+				if err != nil {
+					t.Fatalf("%s: RestoreStreamBuilder() error = %v", name, err)
+				}
+				if restored.NumLeaves() != split {
+					t.Errorf("%s: NumLeaves() of the restored builder = %d, want %d", name, restored.NumLeaves(), split)
+				}
+				for _, block := range blocks[split:] {
+					if err = restored.Append(block); err != nil {
+						t.Fatalf("%s: Append() error = %v", name, err)
+					}
+				}
+				root, err := restored.Root()
+				if err != nil {
+					t.Fatalf("%s: Root() error = %v", name, err)
+				}
+				if !bytes.Equal(root, want.Root) {
+					t.Errorf("%s: Root() of the restored builder = %x, want %x", name, root, want.Root)
+				}
+			}
+		}
+	}
+}
+
+func TestRestoreStreamBuilder_errors(t *testing.T) {
+	b, err := NewStreamBuilder(nil)
+	if err != nil {
+		t.Fatalf("NewStreamBuilder() error = %v", err)
+	}
+	for _, block := range dataBlocks(7) {
+		if err = b.Append(block); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	data, err := b.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if _, err = RestoreStreamBuilder(data, &Config{SortSiblingPairs: true}); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("RestoreStreamBuilder() with another configuration error = %v, want ErrConfigMismatch", err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"wrong_version", append([]byte{streamSnapshotVersion + 1}, data[1:]...)},
+		{"truncated", data[:len(data)-1]},
+		{"trailing_bytes", append(append([]byte(nil), data...), 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RestoreStreamBuilder(tt.data, nil); err == nil {
+				t.Errorf("RestoreStreamBuilder() error = nil, want error")
+			}
+		})
+	}
+	// The restored nodes are copied from the data.
+	restored, err := RestoreStreamBuilder(data, nil)
+	if err != nil {
+		t.Fatalf("RestoreStreamBuilder() error = %v", err)
+	}
+	peaks := restored.Peaks()
+	data[len(data)-1] ^= 1
+	if !bytes.Equal(restored.Peaks()[len(peaks)-1], peaks[len(peaks)-1]) {
+		t.Errorf("Peaks() of the restored builder changed with the snapshot data")
+	}
+	if _, err = b.Root(); err != nil {
+		t.Fatalf("Root() error = %v", err)
+	}
+	if _, err = b.Snapshot(); err == nil {
+		t.Errorf("Snapshot() after Root() error = nil, want error")
+	}
+}
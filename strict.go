@@ -0,0 +1,126 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrConfigConflict is returned by the tree building with Strict when configuration fields conflict,
+// i.e. when a field is ignored or contradicted by another field.
+var ErrConfigConflict = errors.New("conflicting configuration")
+
+// configConflict is a combination of two configuration fields in which field is ignored or contradicted by other.
+type configConflict struct {
+	field, other string
+	// reason explains the conflict, and applies reports whether the configuration has it.
+	reason  string
+	applies func(c *Config) bool
+}
+
+// configConflicts are the conflicts rejected with Strict. An option ignored in some combinations gets an entry here.
+// The fields set by the tree building, e.g. RunInParallel by AutoParallel or NumRoutines by RunInParallel,
+// are only in conflicts that still hold when a configuration is reused, so that a strict configuration can be reused.
+var configConflicts = []configConflict{
+	{
+		field: "NumRoutines", other: "RunInParallel",
+		reason: "the number of routines is ignored without parallelization",
+		applies: func(c *Config) bool {
+			return c.NumRoutines != 0 && !c.RunInParallel && !c.AutoParallel
+		},
+	},
+	{
+		field: "ParallelBatchSize", other: "RunInParallel",
+		reason: "the batch size is ignored without parallelization",
+		applies: func(c *Config) bool {
+			return c.ParallelBatchSize != 0 && !c.RunInParallel && !c.AutoParallel
+		},
+	},
+	{
+		field: "NoDuplicates", other: "PaddingStrategy",
+		reason: "PaddingIndexed pads the leaves to a power of two, leaving no odd level to pad",
+		applies: func(c *Config) bool {
+			return c.NoDuplicates && c.PaddingStrategy == PaddingIndexed
+		},
+	},
+	{
+		field: "ChunkSize", other: "Hasher",
+		reason: "the data blocks are only written in chunks to the Hasher",
+		applies: func(c *Config) bool {
+			return c.ChunkSize != 0 && c.Hasher == nil
+		},
+	},
+	{
+		field: "ChunkSize", other: "DisableLeafHashing",
+		reason: "the data blocks are not hashed",
+		applies: func(c *Config) bool {
+			return c.ChunkSize != 0 && c.DisableLeafHashing
+		},
+	},
+	{
+		field: "StoreReversedProofs", other: "Mode",
+		reason: "no proof is generated in ModeTreeBuild",
+		applies: func(c *Config) bool {
+			return c.StoreReversedProofs && c.Mode == ModeTreeBuild
+		},
+	},
+	{
+		field: "ProofIndices", other: "Mode",
+		reason: "no proof is generated in ModeTreeBuild",
+		applies: func(c *Config) bool {
+			return c.ProofIndices != nil && c.Mode == ModeTreeBuild
+		},
+	},
+	{
+		field: "ShareSiblings", other: "Mode",
+		reason: "the siblings are only copied into the proofs in ModeProofGen",
+		applies: func(c *Config) bool {
+			return c.ShareSiblings && c.Mode != ModeProofGen
+		},
+	},
+	{
+		field: "AnomalyDuration", other: "OnAnomaly",
+		reason: "the threshold is ignored without the anomaly callback",
+		applies: func(c *Config) bool {
+			return c.AnomalyDuration != 0 && c.OnAnomaly == nil
+		},
+	},
+	{
+		field: "AnomalyLeafSize", other: "OnAnomaly",
+		reason: "the threshold is ignored without the anomaly callback",
+		applies: func(c *Config) bool {
+			return c.AnomalyLeafSize != 0 && c.OnAnomaly == nil
+		},
+	},
+}
+
+// checkStrict returns ErrConfigConflict naming the fields of the first conflict of the configuration, if any.
+func checkStrict(c *Config) error {
+	for _, conflict := range configConflicts {
+		if conflict.applies(c) {
+			return fmt.Errorf("%w: %s with %s: %s", ErrConfigConflict, conflict.field, conflict.other, conflict.reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha512"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unconflictedConfigFields are the configuration fields without an entry in configConflicts,
+// as they apply in every combination. A new field must be added either here or to configConflicts.
+var unconflictedConfigFields = []string{
+	"HashFunc", "HashName", "HashID", "Mode", "RunInParallel", "AutoParallel", "PaddingStrategy", "SortSiblingPairs",
+	"DisableLeafHashing", "BindLeafIndex", "Hasher", "EmbedFingerprint", "AdditionalHashFuncs", "ExpectLeafSize",
+	"OnNode", "RootBytes", "LeafTransform", "PairHashFunc", "OnDuplicateLeaf", "OnAnomaly", "ParanoidHashInput",
+	"MaxProofDepth", "Strict", "LevelHashFunc",
+}
+
+func TestConfigConflicts_coverFields(t *testing.T) {
+	classified := make(map[string]bool)
+	for _, field := range unconflictedConfigFields {
+		classified[field] = true
+	}
+	configType := reflect.TypeOf(Config{})
+	for _, conflict := range configConflicts {
+		for _, field := range []string{conflict.field, conflict.other} {
+			if _, ok := configType.FieldByName(field); !ok {
+				t.Errorf("conflict %s with %s: Config has no field %s", conflict.field, conflict.other, field)
+			}
+			classified[field] = true
+		}
+	}
+	for i := 0; i < configType.NumField(); i++ {
+		if field := configType.Field(i); field.IsExported() && !classified[field.Name] {
+			t.Errorf("Config.%s is neither in configConflicts nor in unconflictedConfigFields", field.Name)
+		}
+	}
+}
+
+func TestNew_strict(t *testing.T) {
+	blocks := dataBlocks(5)
+	onAnomaly := func(Anomaly) {}
+	tests := []struct {
+		name   string
+		config Config
+		fields []string
+	}{
+		{"num_routines", Config{NumRoutines: 4}, []string{"NumRoutines", "RunInParallel"}},
+		{"parallel_batch_size", Config{ParallelBatchSize: 8}, []string{"ParallelBatchSize", "RunInParallel"}},
+		{"no_duplicates_indexed", Config{NoDuplicates: true, PaddingStrategy: PaddingIndexed},
+			[]string{"NoDuplicates", "PaddingStrategy"}},
+		{"chunk_size", Config{ChunkSize: 64}, []string{"ChunkSize", "Hasher"}},
+		{"chunk_size_disable_leaf_hashing", Config{ChunkSize: 64, Hasher: sha512.New, DisableLeafHashing: true},
+			[]string{"ChunkSize", "DisableLeafHashing"}},
+		{"store_reversed_proofs", Config{StoreReversedProofs: true, Mode: ModeTreeBuild},
+			[]string{"StoreReversedProofs", "Mode"}},
+		{"proof_indices", Config{ProofIndices: []int{1}, Mode: ModeTreeBuild}, []string{"ProofIndices", "Mode"}},
+		{"share_siblings", Config{ShareSiblings: true, Mode: ModeProofGenAndTreeBuild}, []string{"ShareSiblings", "Mode"}},
+		{"anomaly_duration", Config{AnomalyDuration: time.Second}, []string{"AnomalyDuration", "OnAnomaly"}},
+		{"anomaly_leaf_size", Config{AnomalyLeafSize: 1 << 20}, []string{"AnomalyLeafSize", "OnAnomaly"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			if _, err := New(&config, blocks); err != nil {
+				t.Fatalf("New() without Strict error = %v", err)
+			}
+			config = tt.config
+			config.Strict = true
+			_, err := New(&config, blocks)
+			if !errors.Is(err, ErrConfigConflict) {
+				t.Fatalf("New() with Strict error = %v, want ErrConfigConflict", err)
+			}
+			for _, field := range tt.fields {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("New() with Strict error = %v, want it to name %s", err, field)
+				}
+			}
+		})
+	}
+	valid := []Config{
+		{},
+		{RunInParallel: true, NumRoutines: 4, ParallelBatchSize: 8},
+		{AutoParallel: true, NumRoutines: 4},
+		{NoDuplicates: true, ProofIndices: []int{0, 4}, StoreReversedProofs: true, ShareSiblings: true},
+		{Hasher: sha512.New, ChunkSize: 64, PaddingStrategy: PaddingIndexed},
+		{OnAnomaly: onAnomaly, AnomalyDuration: time.Second, AnomalyLeafSize: 1 << 20, Mode: ModeTreeBuild},
+	}
+	for i := range valid {
+		config := valid[i]
+		config.Strict = true
+		for build := 0; build < 2; build++ { // The configuration is reused after the build modified it.
+			if _, err := New(&config, blocks); err != nil {
+				t.Errorf("config %d: New() with Strict, build %d error = %v", i, build, err)
+			}
+		}
+	}
+}
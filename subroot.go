@@ -0,0 +1,100 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SubRoot computes the root of the Merkle Tree of the data blocks in the range [i, j),
+// as if they formed their own tree, without building the tree of all the data blocks.
+// The root is identical to the Root of the tree built by New with the same configuration for blocks[i:j],
+// in particular the leaf indexes bound with BindLeafIndex start from 0 at the data block i.
+// Only the leaves and one level of nodes at a time are retained, and no proof is generated.
+// The leaves are hashed in parallel if RunInParallel is true, the internal nodes are computed serially,
+// and OnNode is not called. The configuration is not modified.
+func SubRoot(blocks []DataBlock, i, j int, config *Config) ([]byte, error) {
+	if i < 0 || j > len(blocks) || i >= j {
+		return nil, fmt.Errorf("invalid range [%d, %d) of %d data blocks", i, j, len(blocks))
+	}
+	if j-i <= 1 {
+		return nil, errors.New("the number of data blocks must be greater than 1")
+	}
+	blocks = blocks[i:j]
+	if _, err := buildKeyMap(blocks); err != nil {
+		return nil, err
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	// The roots under the additional hash functions are not computed.
+	c.AdditionalHashFuncs = nil
+	m := &MerkleTree{Config: &c, NumLeaves: len(blocks)}
+	m.initConfig()
+	if err := m.checkPaddingStrategy(); err != nil {
+		return nil, err
+	}
+	if m.PaddingStrategy == PaddingIndexed {
+		return nil, errors.New("sub-roots are not supported with PaddingIndexed")
+	}
+	if m.RootBytes < 0 {
+		return nil, fmt.Errorf("invalid root length %d", m.RootBytes)
+	}
+	if err := checkLevelHashFunc(m.Config); err != nil {
+		return nil, err
+	}
+	if m.RunInParallel {
+		m.startWorkerPool()
+		defer m.stopWorkerPool()
+	}
+	if err := checkDuplicateLeaf(m.Config); err != nil {
+		return nil, err
+	}
+	leaves, err := m.generateLeaves(blocks)
+	if err != nil {
+		return nil, err
+	}
+	if leaves, _, err = m.handleDuplicateLeaves(leaves); err != nil {
+		return nil, err
+	}
+	if len(leaves) <= 1 {
+		return nil, errors.New("the number of data blocks must be greater than 1")
+	}
+	level := make([][]byte, len(leaves), len(leaves)+1)
+	copy(level, leaves)
+	for depth, prevLen := 1, len(level); prevLen > 1; depth, prevLen = depth+1, len(level) {
+		if level, prevLen, err = m.fixOdd(level, prevLen); err != nil {
+			return nil, err
+		}
+		// The parents are written over the level, as each pair is read before its parent is written.
+		for k := 0; k < prevLen; k += 2 {
+			if level[k>>1], err = m.hashPair(depth, level[k], level[k+1]); err != nil {
+				return nil, err
+			}
+		}
+		level = level[:prevLen>>1]
+	}
+	return truncateRoot(level[0], m.Config), nil
+}
@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestSubRoot(t *testing.T) {
+	configs := []Config{
+		{},
+		{PaddingStrategy: PaddingPromote},
+		{SortSiblingPairs: true},
+		{BindLeafIndex: true},
+		{DisableLeafHashing: true},
+		{RootBytes: 20},
+		{RunInParallel: true, NumRoutines: 3},
+		{Mode: ModeTreeBuild, AdditionalHashFuncs: []TypeHashFunc{mockHashFunc}},
+	}
+	ranges := [][2]int{{0, 2}, {0, 100}, {1, 4}, {3, 10}, {17, 50}, {63, 100}, {98, 100}}
+	blocks := dataBlocks(100)
+	for _, config := range configs {
+		for _, r := range ranges {
+			config := config
+			name := fmt.Sprintf("%+v/[%d, %d)", config, r[0], r[1])
+			got, err := SubRoot(blocks, r[0], r[1], &config)
+			if err != nil {
+				t.Fatalf("%s: SubRoot() error = %v", name, err)
+			}
+			m, err := New(&config, blocks[r[0]:r[1]])
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if !bytes.Equal(got, m.Root) {
+				t.Errorf("%s: SubRoot() = %x, want %x", name, got, m.Root)
+			}
+		}
+	}
+}
+
+func TestSubRoot_noDuplicates(t *testing.T) {
+	blocks := dataBlocks(20)
+	config := &Config{NoDuplicates: true}
+	root, err := SubRoot(blocks, 5, 16, config)
+	if err != nil {
+		t.Fatalf("SubRoot() error = %v", err)
+	}
+	m, err := New(config, blocks[5:16])
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(root, m.Root) {
+		t.Errorf("SubRoot() = %x, want %x", root, m.Root)
+	}
+}
+
+func TestSubRoot_invalid(t *testing.T) {
+	blocks := dataBlocks(10)
+	tests := []struct {
+		name   string
+		i, j   int
+		config *Config
+	}{
+		{"negative_start", -1, 5, nil},
+		{"end_out_of_range", 0, 11, nil},
+		{"empty_range", 4, 4, nil},
+		{"reversed_range", 6, 3, nil},
+		{"single_block", 4, 5, nil},
+		{"invalid_root_length", 0, 10, &Config{RootBytes: -1}},
+		{"invalid_padding_strategy", 0, 10, &Config{PaddingStrategy: PaddingPromote, NoDuplicates: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SubRoot(blocks, tt.i, tt.j, tt.config); err == nil {
+				t.Errorf("SubRoot() error = nil, want an error")
+			}
+		})
+	}
+}
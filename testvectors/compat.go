@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package testvectors
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// compatibilitySizes are the numbers of data blocks of the compatibility cases,
+// covering complete trees and odd levels at different heights.
+var compatibilitySizes = []int{2, 3, 4, 5, 7, 8, 13, 100}
+
+//go:embed golden/roots.json
+var goldenRoots []byte
+
+// CompatibilityCase is a canonical input of the compatibility suite: a configuration permutation
+// and a number of deterministic data blocks.
+type CompatibilityCase struct {
+	// Name identifies the configuration and the number of data blocks, e.g. "sorted/bound/promote/13".
+	Name   string
+	Config *mt.Config
+	Blocks []mt.DataBlock
+}
+
+// CompatibilityCases returns the canonical inputs of the compatibility suite: every permutation of the
+// configuration fields changing the root, i.e. SortSiblingPairs, DisableLeafHashing, BindLeafIndex
+// and PaddingStrategy, for every number of data blocks of the suite.
+func CompatibilityCases() []CompatibilityCase {
+	var cases []CompatibilityCase
+	for permutation := 0; permutation < 16; permutation++ {
+		var (
+			config = &mt.Config{
+				SortSiblingPairs:   permutation&1 != 0,
+				DisableLeafHashing: permutation&2 != 0,
+				BindLeafIndex:      permutation&4 != 0,
+			}
+			flags []string
+		)
+		if permutation&8 != 0 {
+			config.PaddingStrategy = mt.PaddingPromote
+		}
+		for _, flag := range []struct {
+			set  bool
+			name string
+		}{
+			{config.SortSiblingPairs, "sorted"},
+			{config.DisableLeafHashing, "unhashed"},
+			{config.BindLeafIndex, "bound"},
+			{config.PaddingStrategy == mt.PaddingPromote, "promote"},
+		} {
+			if flag.set {
+				flags = append(flags, flag.name)
+			}
+		}
+		if len(flags) == 0 {
+			flags = append(flags, "default")
+		}
+		for _, size := range compatibilitySizes {
+			c := *config
+			cases = append(cases, CompatibilityCase{
+				Name:   fmt.Sprintf("%s/%d", strings.Join(flags, "/"), size),
+				Config: &c,
+				Blocks: compatibilityBlocks(size),
+			})
+		}
+	}
+	return cases
+}
+
+// compatibilityBlocks returns the deterministic data blocks of the compatibility cases.
+func compatibilityBlocks(size int) []mt.DataBlock {
+	blocks := make([]mt.DataBlock, size)
+	for i := range blocks {
+		blocks[i] = &mock.DataBlock{Data: []byte(fmt.Sprintf("go-merkletree compatibility %d", i))}
+	}
+	return blocks
+}
+
+// CompatibilityRoots builds the Merkle Tree of every compatibility case and returns the hex encoded roots,
+// keyed by case name. Every case is checked with mt.CheckConsistency first, so all the configuration modes
+// and parallelization settings are covered by the root.
+func CompatibilityRoots() (map[string]string, error) {
+	roots := make(map[string]string)
+	for _, c := range CompatibilityCases() {
+		if err := mt.CheckConsistency(c.Config, c.Blocks); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		config := *c.Config
+		tree, err := mt.New(&config, c.Blocks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		roots[c.Name] = hex.EncodeToString(tree.Root)
+	}
+	return roots, nil
+}
+
+// EncodeGoldenRoots returns the canonical JSON encoding of the roots, as checked in to golden/roots.json:
+// keys in lexical order, two-space indentation and a trailing newline.
+func EncodeGoldenRoots(roots map[string]string) ([]byte, error) {
+	data, err := json.MarshalIndent(roots, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// CheckCompatibility compares the roots of the compatibility cases with the golden roots embedded in the package,
+// which are generated by a previous release. Downstream users pinning roots can run it in their CI,
+// as any difference means that the same data blocks and configuration give a different root.
+// It returns an error listing every case whose root changed, or nil if all the roots are unchanged.
+// The golden roots must only be updated deliberately, for an intended incompatible change.
+func CheckCompatibility() error {
+	var golden map[string]string
+	if err := json.Unmarshal(goldenRoots, &golden); err != nil {
+		return fmt.Errorf("decoding the golden roots: %w", err)
+	}
+	roots, err := CompatibilityRoots()
+	if err != nil {
+		return err
+	}
+	var diffs []string
+	for name, root := range roots {
+		if want, ok := golden[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: no golden root", name))
+		} else if root != want {
+			diffs = append(diffs, fmt.Sprintf("%s: root %s, golden root %s", name, root, want))
+		}
+	}
+	for name := range golden {
+		if _, ok := roots[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: golden root of a removed case", name))
+		}
+	}
+	if len(diffs) > 0 {
+		sort.Strings(diffs)
+		return fmt.Errorf("merkle roots changed from the golden roots:\n%s", strings.Join(diffs, "\n"))
+	}
+	return nil
+}
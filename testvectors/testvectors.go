@@ -0,0 +1,204 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package testvectors provides deterministic Merkle Tree test vectors.
+//
+// A set of vectors fixes the leaf inputs and the tree configuration, and records the expected
+// leaf hashes, Merkle root and proofs, so that verifiers implemented in other languages can be
+// checked against this implementation. The checked-in vectors are embedded and can be loaded with Load.
+package testvectors
+
+import (
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/mock"
+)
+
+const (
+	// HashSHA256 is the hash function name recorded for the default SHA256 hash function.
+	HashSHA256 = mt.HashSHA256
+	// HashCustom is the hash function name recorded when a custom hash function is configured.
+	HashCustom = "custom"
+	// vectorDir is the directory of the checked-in vector files.
+	vectorDir = "vectors"
+	// vectorExt is the file extension of the checked-in vector files.
+	vectorExt = ".json"
+)
+
+//go:embed vectors/*.json
+var vectorFS embed.FS
+
+// Vectors is a set of Merkle Tree test vectors.
+// All the byte values are hex encoded.
+type Vectors struct {
+	// Config is the tree configuration the vectors are generated with.
+	Config Config `json:"config"`
+	// Inputs are the serialized data blocks, in leaf order.
+	Inputs []string `json:"inputs"`
+	// LeafHashes are the expected leaves of the tree.
+	LeafHashes []string `json:"leaf_hashes"`
+	// Root is the expected Merkle root.
+	Root string `json:"root"`
+	// Proofs are the expected proofs, one for each input.
+	Proofs []Proof `json:"proofs"`
+}
+
+// Config is the portable description of the tree configuration used in the vectors.
+type Config struct {
+	HashFunc           string `json:"hash_func"`
+	SortSiblingPairs   bool   `json:"sort_sibling_pairs"`
+	DisableLeafHashing bool   `json:"disable_leaf_hashing"`
+}
+
+// Proof is the portable description of a Merkle Tree proof.
+type Proof struct {
+	Index    int      `json:"index"`
+	Path     uint32   `json:"path"`
+	Siblings []string `json:"siblings"`
+}
+
+// GenerateVectors builds a Merkle Tree from the seeds, each of which is used as the serialized data block,
+// and records the resulting leaf hashes, root and proofs.
+// The configuration is copied, so the caller's config is not modified.
+func GenerateVectors(config *mt.Config, seeds [][]byte) (Vectors, error) {
+	if config == nil {
+		config = new(mt.Config)
+	}
+	treeConfig := *config
+	treeConfig.Mode = mt.ModeProofGen
+	blocks := make([]mt.DataBlock, len(seeds))
+	for i, seed := range seeds {
+		blocks[i] = &mock.DataBlock{Data: seed}
+	}
+	tree, err := mt.New(&treeConfig, blocks)
+	if err != nil {
+		return Vectors{}, err
+	}
+	v := Vectors{
+		Config: Config{
+			HashFunc:           HashSHA256,
+			SortSiblingPairs:   config.SortSiblingPairs,
+			DisableLeafHashing: config.DisableLeafHashing,
+		},
+		Inputs:     encodeHexList(seeds),
+		LeafHashes: encodeHexList(tree.Leaves),
+		Root:       hex.EncodeToString(tree.Root),
+		Proofs:     make([]Proof, len(tree.Proofs)),
+	}
+	if config.HashFunc != nil {
+		v.Config.HashFunc = HashCustom
+	}
+	for i, p := range tree.Proofs {
+		v.Proofs[i] = Proof{
+			Index:    i,
+			Path:     p.Path,
+			Siblings: encodeHexList(p.Siblings),
+		}
+	}
+	return v, nil
+}
+
+// Encode returns the canonical JSON encoding of the vectors:
+// fields in declaration order, lowercase hex, two-space indentation and a trailing newline.
+func (v Vectors) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Decode parses vectors from their JSON encoding.
+func Decode(data []byte) (Vectors, error) {
+	var v Vectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Vectors{}, err
+	}
+	return v, nil
+}
+
+// Seeds returns the decoded inputs of the vectors.
+func (v Vectors) Seeds() ([][]byte, error) {
+	return decodeHexList(v.Inputs)
+}
+
+// TreeConfig returns the Merkle Tree configuration described by the vectors.
+// Vectors generated with a custom hash function cannot be converted.
+func (v Vectors) TreeConfig() (*mt.Config, error) {
+	if v.Config.HashFunc != HashSHA256 {
+		return nil, errors.New("unsupported hash function: " + v.Config.HashFunc)
+	}
+	return &mt.Config{
+		SortSiblingPairs:   v.Config.SortSiblingPairs,
+		DisableLeafHashing: v.Config.DisableLeafHashing,
+	}, nil
+}
+
+// Names returns the names of the checked-in vectors in lexical order.
+func Names() ([]string, error) {
+	entries, err := vectorFS.ReadDir(vectorDir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), vectorExt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns the checked-in vectors with the given name, e.g. "default".
+func Load(name string) (Vectors, error) {
+	data, err := vectorFS.ReadFile(path.Join(vectorDir, name+vectorExt))
+	if err != nil {
+		return Vectors{}, err
+	}
+	return Decode(data)
+}
+
+func encodeHexList(list [][]byte) []string {
+	res := make([]string, len(list))
+	for i, b := range list {
+		res[i] = hex.EncodeToString(b)
+	}
+	return res
+}
+
+func decodeHexList(list []string) ([][]byte, error) {
+	res := make([][]byte, len(list))
+	for i, s := range list {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = b
+	}
+	return res, nil
+}
@@ -0,0 +1,355 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package testvectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	mt "github.com/txaty/go-merkletree"
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// update regenerates the checked-in vectors: go test ./testvectors -update
+var update = flag.Bool("update", false, "regenerate the checked-in test vectors")
+
+// vectorConfigs are the configurations of the checked-in vectors, keyed by vector name.
+var vectorConfigs = map[string]*mt.Config{
+	"default":              {},
+	"sorted_pairs":         {SortSiblingPairs: true},
+	"disable_leaf_hashing": {DisableLeafHashing: true},
+}
+
+// vectorSeeds returns the inputs of the checked-in vectors.
+// An odd number of inputs is used so that the padding of odd levels is covered.
+func vectorSeeds() [][]byte {
+	seeds := make([][]byte, 7)
+	for i := range seeds {
+		seeds[i] = []byte(fmt.Sprintf("go-merkletree test vector %d", i))
+	}
+	return seeds
+}
+
+func TestCheckedInVectors(t *testing.T) {
+	if *update {
+		for name, config := range vectorConfigs {
+			v, err := GenerateVectors(config, vectorSeeds())
+			if err != nil {
+				t.Fatalf("GenerateVectors() error = %v", err)
+			}
+			data, err := v.Encode()
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(vectorDir, name+vectorExt), data, 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+		}
+		t.Skip("vectors regenerated, rebuild the package to embed them")
+	}
+	names, err := Names()
+	if err != nil {
+		t.Fatalf("Names() error = %v", err)
+	}
+	if len(names) != len(vectorConfigs) {
+		t.Fatalf("Names() = %v, want %d vector files", names, len(vectorConfigs))
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			config, ok := vectorConfigs[name]
+			if !ok {
+				t.Fatalf("no configuration for vectors %q", name)
+			}
+			want, err := vectorFS.ReadFile(filepath.Join(vectorDir, name+vectorExt))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			v, err := Load(name)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			seeds, err := v.Seeds()
+			if err != nil {
+				t.Fatalf("Seeds() error = %v", err)
+			}
+			generated, err := GenerateVectors(config, seeds)
+			if err != nil {
+				t.Fatalf("GenerateVectors() error = %v", err)
+			}
+			got, err := generated.Encode()
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("vectors %q drifted from the implementation, run with -update if intended", name)
+			}
+			verifyVectors(t, v)
+		})
+	}
+}
+
+// verifyVectors checks the vector proofs with the package-level verification,
+// as an external verifier would.
+func verifyVectors(t *testing.T, v Vectors) {
+	config, err := v.TreeConfig()
+	if err != nil {
+		t.Fatalf("TreeConfig() error = %v", err)
+	}
+	seeds, err := v.Seeds()
+	if err != nil {
+		t.Fatalf("Seeds() error = %v", err)
+	}
+	root, err := hex.DecodeString(v.Root)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+	for i, p := range v.Proofs {
+		siblings, err := decodeHexList(p.Siblings)
+		if err != nil {
+			t.Fatalf("decodeHexList() error = %v", err)
+		}
+		proof := &mt.Proof{Siblings: siblings, Path: p.Path}
+		ok, err := mt.Verify(&mock.DataBlock{Data: seeds[p.Index]}, proof, root, config)
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if !ok {
+			t.Errorf("Verify() proof %d failed", i)
+		}
+	}
+}
+
+// TestLeafHashNodeHash recomputes the checked-in vectors step by step with LeafHash and NodeHash,
+// as an implementation in another language would.
+func TestLeafHashNodeHash(t *testing.T) {
+	names, err := Names()
+	if err != nil {
+		t.Fatalf("Names() error = %v", err)
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			v, err := Load(name)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			config, err := v.TreeConfig()
+			if err != nil {
+				t.Fatalf("TreeConfig() error = %v", err)
+			}
+			seeds, err := v.Seeds()
+			if err != nil {
+				t.Fatalf("Seeds() error = %v", err)
+			}
+			for i, p := range v.Proofs {
+				node, err := mt.LeafHash(config, seeds[p.Index])
+				if err != nil {
+					t.Fatalf("LeafHash() error = %v", err)
+				}
+				if got := hex.EncodeToString(node); got != v.LeafHashes[p.Index] {
+					t.Fatalf("LeafHash() of input %d = %s, want %s", p.Index, got, v.LeafHashes[p.Index])
+				}
+				siblings, err := decodeHexList(p.Siblings)
+				if err != nil {
+					t.Fatalf("decodeHexList() error = %v", err)
+				}
+				for level, sibling := range siblings {
+					if p.Path>>level&1 == 1 {
+						node, err = mt.NodeHash(config, node, sibling)
+					} else {
+						node, err = mt.NodeHash(config, sibling, node)
+					}
+					if err != nil {
+						t.Fatalf("NodeHash() error = %v", err)
+					}
+				}
+				if got := hex.EncodeToString(node); got != v.Root {
+					t.Errorf("root folded from proof %d = %s, want %s", i, got, v.Root)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultHashFunc(t *testing.T) {
+	// SHA-256 test vector of FIPS 180-4 for the message "abc".
+	const abc = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	got, err := mt.DefaultHashFunc([]byte("abc"))
+	if err != nil || hex.EncodeToString(got) != abc {
+		t.Errorf("DefaultHashFunc(abc) = %x, %v, want %s", got, err, abc)
+	}
+	if got, err = mt.LeafHash(nil, []byte("abc")); err != nil || hex.EncodeToString(got) != abc {
+		t.Errorf("LeafHash(nil, abc) = %x, %v, want %s", got, err, abc)
+	}
+	// The internal nodes are the plain SHA-256 digests of the concatenated children.
+	if got, err = mt.NodeHash(nil, []byte("a"), []byte("bc")); err != nil || hex.EncodeToString(got) != abc {
+		t.Errorf("NodeHash(nil, a, bc) = %x, %v, want %s", got, err, abc)
+	}
+	sorted := &mt.Config{SortSiblingPairs: true}
+	if got, err = mt.NodeHash(sorted, []byte("bc"), []byte("a")); err != nil || hex.EncodeToString(got) != abc {
+		t.Errorf("NodeHash(sorted, bc, a) = %x, %v, want %s", got, err, abc)
+	}
+	// Without right node, the left node is padded as the last node of an odd level.
+	promote := &mt.Config{PaddingStrategy: mt.PaddingPromote}
+	if got, err = mt.NodeHash(promote, []byte("abc"), nil); err != nil || string(got) != "abc" {
+		t.Errorf("NodeHash() without right node with PaddingPromote = %q, %v, want the promoted left node", got, err)
+	}
+	noDuplicates := &mt.Config{NoDuplicates: true}
+	filler, err := mt.NoDuplicatesFiller([]byte("abc"), noDuplicates)
+	if err != nil {
+		t.Fatalf("NoDuplicatesFiller() error = %v", err)
+	}
+	for _, tt := range []struct {
+		name   string
+		config *mt.Config
+		right  []byte
+	}{
+		{"duplicate", nil, []byte("abc")},
+		{"no_duplicates", noDuplicates, filler},
+	} {
+		want, err := mt.NodeHash(tt.config, []byte("abc"), tt.right)
+		if err != nil {
+			t.Fatalf("NodeHash() error = %v", err)
+		}
+		if got, err = mt.NodeHash(tt.config, []byte("abc"), nil); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("NodeHash() without right node with %s padding = %x, %v, want %x", tt.name, got, err, want)
+		}
+	}
+	// The root of three leaves pairs the padded third leaf as New does.
+	blocks := []mt.DataBlock{&mock.DataBlock{Data: []byte("a")}, &mock.DataBlock{Data: []byte("b")},
+		&mock.DataBlock{Data: []byte("c")}}
+	for _, config := range []*mt.Config{nil, promote, noDuplicates} {
+		tree, err := mt.New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		left, err := mt.NodeHash(config, tree.Leaves[0], tree.Leaves[1])
+		if err != nil {
+			t.Fatalf("NodeHash() error = %v", err)
+		}
+		right, err := mt.NodeHash(config, tree.Leaves[2], nil)
+		if err != nil {
+			t.Fatalf("NodeHash() error = %v", err)
+		}
+		if got, err = mt.NodeHash(config, left, right); err != nil || !bytes.Equal(got, tree.Root) {
+			t.Errorf("NodeHash() of three leaves with %+v = %x, %v, want the root %x", config, got, err, tree.Root)
+		}
+	}
+	if _, err = mt.LeafHash(&mt.Config{BindLeafIndex: true}, []byte("abc")); err == nil {
+		t.Error("LeafHash() with BindLeafIndex: error = nil")
+	}
+	if mt.DefaultHashAlgorithm != "SHA-256" {
+		t.Errorf("DefaultHashAlgorithm = %q, want SHA-256", mt.DefaultHashAlgorithm)
+	}
+}
+
+func TestGenerateVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *mt.Config
+		seeds    [][]byte
+		wantHash string
+		wantErr  bool
+	}{
+		{
+			name:     "test_nil_config",
+			seeds:    vectorSeeds(),
+			wantHash: HashSHA256,
+		},
+		{
+			name: "test_custom_hash_func",
+			config: &mt.Config{
+				HashFunc: func(data []byte) ([]byte, error) {
+					return data[:4], nil
+				},
+			},
+			seeds:    vectorSeeds(),
+			wantHash: HashCustom,
+		},
+		{
+			name:     "test_no_duplicates",
+			config:   &mt.Config{NoDuplicates: true},
+			seeds:    vectorSeeds(),
+			wantHash: HashSHA256,
+		},
+		{
+			name:    "test_single_seed",
+			seeds:   vectorSeeds()[:1],
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateVectors(tt.config, tt.seeds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateVectors() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Config.HashFunc != tt.wantHash {
+				t.Errorf("GenerateVectors() hash func = %v, want %v", got.Config.HashFunc, tt.wantHash)
+			}
+			data, err := got.Encode()
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			decoded, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(decoded, got) {
+				t.Errorf("Decode() = %v, want %v", decoded, got)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	if *update {
+		roots, err := CompatibilityRoots()
+		if err != nil {
+			t.Fatalf("CompatibilityRoots() error = %v", err)
+		}
+		data, err := EncodeGoldenRoots(roots)
+		if err != nil {
+			t.Fatalf("EncodeGoldenRoots() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join("golden", "roots.json"), data, 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		t.Skip("golden roots regenerated, rebuild the package to embed them")
+	}
+	if err := CheckCompatibility(); err != nil {
+		t.Error(err)
+	}
+	if got, want := len(CompatibilityCases()), 16*len(compatibilitySizes); got != want {
+		t.Errorf("len(CompatibilityCases()) = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// TreeCap is the upper part of a Merkle Tree: the nodes at and above a level, e.g. to distribute the proof
+// generation of a large tree to workers owning ranges of leaves. With the tree cap, a RangeProver generates
+// the proofs of the leaves of its range from the leaves of the range only.
+type TreeCap struct {
+	// Config is the configuration of the Merkle Tree. It is not modified by the range provers.
+	Config *Config
+	// Level is the lowest level of the tree cap, level 0 being the leaf level.
+	Level int
+	// NumLeaves and Depth are the number of leaves and the depth of the Merkle Tree,
+	// the leaves including the padding leaves of PaddingIndexed, which the range provers treat as leaves.
+	NumLeaves int
+	Depth     uint32
+	// Nodes are the nodes of the levels from Level to Depth-1, including the padding nodes.
+	Nodes [][][]byte
+	// Padding are the padding nodes appended to the levels below Level, nil if the level is not padded.
+	Padding [][]byte
+	// Root is the Merkle root.
+	Root []byte
+	// Fingerprint of the configuration of the Merkle Tree, set if EmbedFingerprint is true in the configuration.
+	Fingerprint []byte
+}
+
+// ExportCap returns the tree cap of the Merkle Tree at the level, i.e. all the nodes at and above the level.
+// The tree nodes are required, so the Merkle Tree must be built in ModeTreeBuild or ModeProofGenAndTreeBuild.
+func (m *MerkleTree) ExportCap(level int) (*TreeCap, error) {
+	if m.nodes == nil {
+		return nil, errors.New("merkle Tree nodes are not available, could not export the tree cap")
+	}
+	if level < 0 || level >= int(m.Depth) {
+		return nil, fmt.Errorf("level %d is out of range [0, %d)", level, m.Depth)
+	}
+	nodes, err := m.treeNodes()
+	if err != nil {
+		return nil, err
+	}
+	config := *m.Config
+	treeCap := &TreeCap{
+		Config:      &config,
+		Level:       level,
+		NumLeaves:   m.treeLeafCount(),
+		Depth:       m.Depth,
+		Nodes:       make([][][]byte, int(m.Depth)-level),
+		Padding:     make([][]byte, level),
+		Root:        m.Root,
+		Fingerprint: m.fingerprint,
+	}
+	for i := range treeCap.Nodes {
+		treeCap.Nodes[i] = append([][]byte(nil), nodes[level+i]...)
+	}
+	for l := range treeCap.Padding {
+		if levelLen := (treeCap.NumLeaves + 1<<l - 1) >> l; len(nodes[l]) > levelLen {
+			treeCap.Padding[l] = nodes[l][levelLen]
+		}
+	}
+	return treeCap, nil
+}
+
+// RangeProver generates the proofs of the leaves of a range of consecutive leaves from the tree cap
+// and the leaves of the range, without the rest of the Merkle Tree.
+// The proofs are identical to the proofs generated by the Merkle Tree.
+type RangeProver struct {
+	treeCap *TreeCap
+	// tree holds the configuration and the shape of the Merkle Tree, it has no leaves or nodes.
+	tree       *MerkleTree
+	start, end int
+	// levels are the nodes of the range at the levels below the tree cap, including the padding nodes.
+	levels [][][]byte
+}
+
+// NewRangeProver returns the range prover of the leaves from the leaf index rangeStart, given their leaf hashes.
+// The range must be aligned to the tree cap: rangeStart must be a multiple of 2^Level, and the range must end
+// at a multiple of 2^Level or at the last leaf, so that the nodes of the range at the level of the tree cap
+// are computed from the leaves of the range only. These nodes are checked against the tree cap,
+// and an error is returned if they differ, e.g. if a leaf hash is wrong.
+func NewRangeProver(treeCap *TreeCap, rangeStart int, leafHashes [][]byte) (*RangeProver, error) {
+	if treeCap == nil || treeCap.Config == nil {
+		return nil, errors.New("tree cap or its configuration is nil")
+	}
+	if treeCap.Level < 0 || treeCap.Level >= int(treeCap.Depth) ||
+		len(treeCap.Nodes) != int(treeCap.Depth)-treeCap.Level || len(treeCap.Padding) != treeCap.Level {
+		return nil, errors.New("tree cap levels do not match its depth")
+	}
+	if len(leafHashes) == 0 {
+		return nil, errors.New("range has no leaves")
+	}
+	var (
+		step = 1 << treeCap.Level
+		end  = rangeStart + len(leafHashes)
+	)
+	if rangeStart < 0 || end > treeCap.NumLeaves {
+		return nil, fmt.Errorf("range [%d, %d) is out of range [0, %d)", rangeStart, end, treeCap.NumLeaves)
+	}
+	if rangeStart%step != 0 || (end%step != 0 && end != treeCap.NumLeaves) {
+		return nil, fmt.Errorf("range [%d, %d) is not aligned to the tree cap at level %d", rangeStart, end, treeCap.Level)
+	}
+	config := *treeCap.Config
+	p := &RangeProver{
+		treeCap: treeCap,
+		tree:    &MerkleTree{Config: verifyConfig(&config), NumLeaves: treeCap.NumLeaves, Depth: treeCap.Depth},
+		start:   rangeStart,
+		end:     end,
+		levels:  make([][][]byte, treeCap.Level),
+	}
+	buf := leafHashes
+	for level := 0; level < treeCap.Level; level++ {
+		levelLen := (treeCap.NumLeaves + 1<<level - 1) >> level
+		if rangeStart>>level+len(buf) == levelLen && levelLen&1 == 1 {
+			buf = append(buf[:len(buf):len(buf)], treeCap.Padding[level])
+		}
+		p.levels[level] = buf
+		parents := make([][]byte, len(buf)>>1)
+		for i := range parents {
+			parent, err := p.tree.hashPair(level+1, buf[i<<1], buf[i<<1+1])
+			if err != nil {
+				return nil, err
+			}
+			parents[i] = parent
+		}
+		buf = parents
+	}
+	capLevel, lo := treeCap.Nodes[0], rangeStart>>treeCap.Level
+	if lo+len(buf) > len(capLevel) {
+		return nil, errors.New("tree cap nodes do not match its number of leaves")
+	}
+	for i, node := range buf {
+		if !bytes.Equal(node, capLevel[lo+i]) {
+			return nil, fmt.Errorf("node %d at level %d computed from the leaf hashes does not match the tree cap",
+				lo+i, treeCap.Level)
+		}
+	}
+	return p, nil
+}
+
+// Range returns the leaf indexes of the range of the range prover, the end being exclusive.
+func (p *RangeProver) Range() (start, end int) {
+	return p.start, p.end
+}
+
+// Proof returns the proof of the leaf at the index, which must be in the range of the range prover.
+func (p *RangeProver) Proof(idx int) (*Proof, error) {
+	if idx < p.start || idx >= p.end {
+		return nil, fmt.Errorf("leaf index %d is out of range [%d, %d)", idx, p.start, p.end)
+	}
+	var (
+		path     uint32
+		siblings = make([][]byte, 0, p.treeCap.Depth)
+	)
+	for _, level := range p.tree.siblingLevels(idx) {
+		nodeIdx := idx >> level
+		if nodeIdx&1 == 0 {
+			path += 1 << len(siblings)
+		}
+		if level < p.treeCap.Level {
+			siblings = append(siblings, p.levels[level][(nodeIdx^1)-p.start>>level])
+		} else {
+			siblings = append(siblings, p.treeCap.Nodes[level-p.treeCap.Level][nodeIdx^1])
+		}
+	}
+	return &Proof{
+		Path:        path,
+		Siblings:    siblings,
+		Index:       idx,
+		Fingerprint: p.treeCap.Fingerprint,
+	}, nil
+}
+
+// Proofs returns the proofs of all the leaves of the range, in leaf index order.
+func (p *RangeProver) Proofs() []*Proof {
+	proofs := make([]*Proof, p.end-p.start)
+	for i := range proofs {
+		proofs[i], _ = p.Proof(p.start + i)
+	}
+	return proofs
+}
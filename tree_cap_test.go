@@ -0,0 +1,114 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewRangeProver(t *testing.T) {
+	for _, numLeaves := range []int{2, 5, 13, 64, 100, 257} {
+		for _, config := range []Config{
+			{Mode: ModeProofGenAndTreeBuild},
+			{Mode: ModeProofGenAndTreeBuild, PaddingStrategy: PaddingPromote},
+			{Mode: ModeProofGenAndTreeBuild, SortSiblingPairs: true, EmbedFingerprint: true},
+			{Mode: ModeProofGenAndTreeBuild, NoDuplicates: true},
+			{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, BindLeafIndex: true},
+		} {
+			config := config
+			m, err := New(&config, dataBlocks(numLeaves))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for level := 0; level < int(m.Depth); level++ {
+				name := fmt.Sprintf("%d leaves/%+v/level %d", numLeaves, config, level)
+				treeCap, err := m.ExportCap(level)
+				if err != nil {
+					t.Fatalf("%s: ExportCap() error = %v", name, err)
+				}
+				// Shards of 1, 2, 3, ... units of 2^level leaves, the last shard ending at the last leaf.
+				for start, units := 0, 1; start < numLeaves; start, units = start+units<<level, units+1 {
+					end := min(start+units<<level, numLeaves)
+					p, err := NewRangeProver(treeCap, start, m.Leaves[start:end])
+					if err != nil {
+						t.Fatalf("%s: NewRangeProver(%d, %d) error = %v", name, start, end, err)
+					}
+					for i, proof := range p.Proofs() {
+						if !proof.Equal(m.Proofs[start+i]) {
+							t.Fatalf("%s: proof of leaf %d differs from the proof of the Merkle Tree", name, start+i)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNewRangeProver_errors(t *testing.T) {
+	m, err := New(&Config{Mode: ModeTreeBuild}, dataBlocks(13))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	treeCap, err := m.ExportCap(2)
+	if err != nil {
+		t.Fatalf("ExportCap() error = %v", err)
+	}
+	wrong := append([][]byte(nil), m.Leaves[4:8]...)
+	wrong[1] = m.Leaves[0]
+	tests := []struct {
+		name       string
+		rangeStart int
+		leafHashes [][]byte
+	}{
+		{"empty range", 4, nil},
+		{"unaligned start", 2, m.Leaves[2:8]},
+		{"unaligned end", 4, m.Leaves[4:7]},
+		{"out of range", 12, m.Leaves[8:13]},
+		{"wrong leaf hash", 4, wrong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRangeProver(treeCap, tt.rangeStart, tt.leafHashes); err == nil {
+				t.Error("NewRangeProver() error = nil")
+			}
+		})
+	}
+	p, err := NewRangeProver(treeCap, 8, m.Leaves[8:])
+	if err != nil {
+		t.Fatalf("NewRangeProver() error = %v", err)
+	}
+	if _, err = p.Proof(7); err == nil {
+		t.Error("Proof() of a leaf outside the range: error = nil")
+	}
+	if _, err = m.ExportCap(int(m.Depth)); err == nil {
+		t.Error("ExportCap() at the root level: error = nil")
+	}
+	pg, err := New(nil, dataBlocks(13))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = pg.ExportCap(1); err == nil {
+		t.Error("ExportCap() of a tree without nodes: error = nil")
+	}
+}
@@ -0,0 +1,239 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// hexBytes is a byte slice encoded as a hex string in JSON.
+type hexBytes []byte
+
+func (b hexBytes) MarshalText() ([]byte, error) {
+	text := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(text, b)
+	return text, nil
+}
+
+func (b *hexBytes) UnmarshalText(text []byte) error {
+	decoded := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(decoded, text); err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// treeJSON is the JSON document of a Merkle Tree.
+type treeJSON struct {
+	Mode               string      `json:"mode"`
+	HashFunc           string      `json:"hash_func"`
+	SortSiblingPairs   bool        `json:"sort_sibling_pairs"`
+	DisableLeafHashing bool        `json:"disable_leaf_hashing"`
+	BindLeafIndex      bool        `json:"bind_leaf_index"`
+	NoDuplicates       bool        `json:"no_duplicates"`
+	PaddingStrategy    string      `json:"padding_strategy,omitempty"`
+	EmbedFingerprint   bool        `json:"embed_fingerprint"`
+	RootBytes          int         `json:"root_bytes,omitempty"`
+	Depth              uint32      `json:"depth"`
+	Root               hexBytes    `json:"root"`
+	Leaves             []hexBytes  `json:"leaves"`
+	Proofs             []proofJSON `json:"proofs"`
+}
+
+// proofJSON is the JSON document of a proof.
+type proofJSON struct {
+	Index       int        `json:"index"`
+	Path        uint32     `json:"path"`
+	Siblings    []hexBytes `json:"siblings"`
+	Fingerprint hexBytes   `json:"fingerprint,omitempty"`
+	HashID      byte       `json:"hash_id,omitempty"`
+}
+
+// newProofJSON returns the JSON document of the proof.
+func newProofJSON(proof *Proof) proofJSON {
+	doc := proofJSON{
+		Index:       proof.Index,
+		Path:        proof.Path,
+		Siblings:    make([]hexBytes, len(proof.Siblings)),
+		Fingerprint: proof.Fingerprint,
+		HashID:      proof.HashID,
+	}
+	for i, sibling := range proof.Siblings {
+		doc.Siblings[i] = sibling
+	}
+	return doc
+}
+
+// proof returns the proof of the JSON document.
+func (p *proofJSON) proof() *Proof {
+	proof := &Proof{Index: p.Index, Path: p.Path, Siblings: make([][]byte, len(p.Siblings)), HashID: p.HashID}
+	for i, sibling := range p.Siblings {
+		proof.Siblings[i] = sibling
+	}
+	if p.Fingerprint != nil {
+		proof.Fingerprint = p.Fingerprint
+	}
+	return proof
+}
+
+// MarshalJSON encodes the Merkle Tree as a JSON document with the configuration, the root, the leaves and the proofs,
+// with the byte values hex encoded.
+// The hash function is recorded by its registered name, HashName in the configuration, see RegisterHashFuncName,
+// so the name must be set for custom hash functions. The proofs are required, so the tree must be built in ModeProofGen
+// or ModeProofGenAndTreeBuild. The tree nodes are not encoded.
+// With PaddingIndexed, the proofs of the padding leaves follow the proofs of the leaves.
+func (m *MerkleTree) MarshalJSON() ([]byte, error) {
+	if m.Proofs == nil {
+		return nil, fmt.Errorf("merkle Tree built in %v has no proofs to marshal", m.Config.Mode)
+	}
+	if m.LeafTransform != nil {
+		return nil, errors.New("leaf transform could not be marshaled, could not marshal the Merkle Tree")
+	}
+	if m.PairHashFunc != nil {
+		return nil, errors.New("pair hash function could not be marshaled, could not marshal the Merkle Tree")
+	}
+	if m.LevelHashFunc != nil {
+		return nil, errors.New("level hash function could not be marshaled, could not marshal the Merkle Tree")
+	}
+	hashFuncName, err := registeredHashName(m.Config)
+	if err != nil {
+		return nil, err
+	}
+	doc := treeJSON{
+		Mode:               m.Config.Mode.String(),
+		HashFunc:           hashFuncName,
+		SortSiblingPairs:   m.SortSiblingPairs,
+		DisableLeafHashing: m.DisableLeafHashing,
+		BindLeafIndex:      m.BindLeafIndex,
+		NoDuplicates:       m.NoDuplicates,
+		PaddingStrategy:    m.PaddingStrategy.String(),
+		EmbedFingerprint:   m.EmbedFingerprint,
+		RootBytes:          m.RootBytes,
+		Depth:              m.Depth,
+		Root:               m.Root,
+		Leaves:             make([]hexBytes, len(m.Leaves)),
+		Proofs:             make([]proofJSON, m.treeLeafCount()),
+	}
+	for i, leaf := range m.Leaves {
+		doc.Leaves[i] = leaf
+	}
+	for i := range doc.Proofs {
+		proof, err := m.ProofOf(i)
+		if err != nil {
+			return nil, err
+		}
+		doc.Proofs[i] = newProofJSON(proof)
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes the Merkle Tree from the JSON document encoded by MarshalJSON.
+// The hash function is looked up by its registered name.
+// The decoded Merkle Tree supports Verify and the access to the leaves and the proofs,
+// but not the generation of proofs from the tree nodes, which are not encoded.
+func (m *MerkleTree) UnmarshalJSON(data []byte) error {
+	var doc treeJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	mode, err := parseConfigMode(doc.Mode)
+	if err != nil {
+		return err
+	}
+	paddingStrategy, err := parsePaddingStrategy(doc.PaddingStrategy)
+	if err != nil {
+		return err
+	}
+	hashFunc, ok := HashFuncByName(doc.HashFunc)
+	if !ok {
+		return errors.New("hash function is not registered: " + doc.HashFunc)
+	}
+	numProofs := len(doc.Leaves)
+	if paddingStrategy == PaddingIndexed {
+		numProofs = indexedPaddingLen(len(doc.Leaves))
+	}
+	if len(doc.Proofs) != numProofs || len(doc.Leaves) < 2 {
+		return errors.New("invalid number of leaves and proofs")
+	}
+	leaves := make([][]byte, len(doc.Leaves))
+	for i, leaf := range doc.Leaves {
+		leaves[i] = leaf
+	}
+	proofs := make([]*Proof, len(doc.Proofs))
+	for i, p := range doc.Proofs {
+		if p.Index != i || len(p.Siblings) == 0 || len(p.Siblings) > int(doc.Depth) ||
+			paddingStrategy != PaddingPromote && len(p.Siblings) != int(doc.Depth) {
+			return fmt.Errorf("invalid proof of leaf %d", i)
+		}
+		proofs[i] = p.proof()
+	}
+	m.Restore(&Config{
+		HashFunc:           hashFunc,
+		HashName:           doc.HashFunc,
+		Mode:               mode,
+		NoDuplicates:       doc.NoDuplicates,
+		PaddingStrategy:    paddingStrategy,
+		SortSiblingPairs:   doc.SortSiblingPairs,
+		DisableLeafHashing: doc.DisableLeafHashing,
+		BindLeafIndex:      doc.BindLeafIndex,
+		EmbedFingerprint:   doc.EmbedFingerprint,
+		RootBytes:          doc.RootBytes,
+	})
+	m.Root, m.Leaves, m.Proofs = doc.Root, leaves, proofs[:len(leaves):len(leaves)]
+	m.NumLeaves, m.Depth, m.nodes, m.keyMap = len(leaves), doc.Depth, nil, nil
+	m.numPaddingLeaves, m.paddingProofs = len(proofs)-len(leaves), proofs[len(leaves):]
+	m.pruned = false
+	m.proofsByLeafHash = nil
+	// The root may be truncated, while the top sibling of a proof is always a full hash.
+	topSiblings := proofs[0].Siblings
+	m.hashSize.Store(int64(len(topSiblings[len(topSiblings)-1])))
+	return nil
+}
+
+// parsePaddingStrategy returns the padding strategy named by TypePaddingStrategy.String,
+// PaddingDuplicate if the name is empty.
+func parsePaddingStrategy(name string) (TypePaddingStrategy, error) {
+	if name == "" {
+		return PaddingDuplicate, nil
+	}
+	for _, strategy := range []TypePaddingStrategy{PaddingDuplicate, PaddingPromote, PaddingIndexed} {
+		if strategy.String() == name {
+			return strategy, nil
+		}
+	}
+	return 0, errors.New("invalid padding strategy: " + name)
+}
+
+// parseConfigMode returns the configuration mode named by TypeConfigMode.String.
+func parseConfigMode(name string) (TypeConfigMode, error) {
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return 0, errors.New("invalid configuration mode: " + name)
+}
@@ -0,0 +1,205 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sha512HashFunc(data []byte) ([]byte, error) {
+	digest := sha512.Sum512(data)
+	return digest[:], nil
+}
+
+func TestMerkleTree_MarshalJSON(t *testing.T) {
+	if _, ok := HashFuncByName("sha512"); !ok {
+		if err := RegisterHashFuncName("sha512", sha512HashFunc); err != nil {
+			t.Fatalf("RegisterHashFuncName() error = %v", err)
+		}
+	}
+	blocks := dataBlocks(7)
+	for _, config := range []*Config{
+		nil,
+		{RunInParallel: true, SortSiblingPairs: true},
+		{Mode: ModeProofGenAndTreeBuild, BindLeafIndex: true, EmbedFingerprint: true},
+		{HashFunc: sha512HashFunc, HashName: "sha512", DisableLeafHashing: true},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("MarshalJSON() error = %v", err)
+		}
+		var decoded MerkleTree
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("UnmarshalJSON() error = %v", err)
+		}
+		if !bytes.Equal(decoded.Root, m.Root) || decoded.Mode != m.Mode || decoded.NumLeaves != m.NumLeaves ||
+			decoded.HashSize() != m.HashSize() {
+			t.Errorf("UnmarshalJSON() = root %x, mode %v, %d leaves, want root %x, mode %v, %d leaves",
+				decoded.Root, decoded.Mode, decoded.NumLeaves, m.Root, m.Mode, m.NumLeaves)
+		}
+		for i, block := range blocks {
+			if !bytes.Equal(decoded.Leaves[i], m.Leaves[i]) {
+				t.Errorf("decoded leaf %d differs", i)
+			}
+			if !decoded.Proofs[i].Equal(m.Proofs[i]) {
+				t.Errorf("decoded proof %d differs", i)
+			}
+			if ok, err := decoded.Verify(block, decoded.Proofs[i]); err != nil || !ok {
+				t.Errorf("Verify() with the decoded tree = %v, %v, want true", ok, err)
+			}
+		}
+		if _, err := decoded.Proof(blocks[0]); err == nil {
+			t.Errorf("Proof() of the decoded tree error = nil, want error")
+		}
+	}
+}
+
+func TestMerkleTree_MarshalJSON_errors(t *testing.T) {
+	blocks := dataBlocks(5)
+	unregistered := func(data []byte) ([]byte, error) {
+		return sha512HashFunc(data)
+	}
+	for _, config := range []*Config{
+		{HashFunc: sha512HashFunc},
+		{HashFunc: unregistered, HashName: "unregistered"},
+		{Mode: ModeTreeBuild},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if _, err := json.Marshal(m); err == nil {
+			t.Errorf("MarshalJSON() error = nil, want error")
+		}
+	}
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	for _, tt := range []struct{ old, new string }{
+		{`"hash_func":"sha256"`, `"hash_func":"unknown"`},
+		{`"mode":"ModeProofGen"`, `"mode":"ModeUnknown"`},
+		{`"depth":3`, `"depth":4`},
+		{`"root":"`, `"root":"zz`},
+	} {
+		if !strings.Contains(string(data), tt.old) {
+			t.Fatalf("JSON document %s does not contain %s", data, tt.old)
+		}
+		var decoded MerkleTree
+		if err := json.Unmarshal([]byte(strings.Replace(string(data), tt.old, tt.new, 1)), &decoded); err == nil {
+			t.Errorf("UnmarshalJSON() with %s error = nil, want error", tt.new)
+		}
+	}
+}
+
+func TestRegisterHashFuncName(t *testing.T) {
+	if err := RegisterHashFuncName(HashSHA256, mockHashFunc); err == nil {
+		t.Errorf("RegisterHashFuncName() of a registered name error = nil, want error")
+	}
+	if err := RegisterHashFuncName("", mockHashFunc); err == nil {
+		t.Errorf("RegisterHashFuncName() of an empty name error = nil, want error")
+	}
+	for _, config := range []*Config{nil, {HashFunc: DefaultHashFunc}, {RunInParallel: true}} {
+		m, err := New(config, dataBlocks(5))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if m.HashName != HashSHA256 {
+			t.Errorf("HashName of the default hash function = %q, want %q", m.HashName, HashSHA256)
+		}
+	}
+}
+
+func TestRegisterHashFuncName_closures(t *testing.T) {
+	// The closures of the same function literal are told apart by their names.
+	prefixed := func(prefix byte) TypeHashFunc {
+		return func(data []byte) ([]byte, error) {
+			return DefaultHashFunc(append([]byte{prefix}, data...))
+		}
+	}
+	names := []string{"prefixed_1", "prefixed_2"}
+	for i, name := range names {
+		if _, ok := HashFuncByName(name); !ok {
+			if err := RegisterHashFuncName(name, prefixed(byte(i+1))); err != nil {
+				t.Fatalf("RegisterHashFuncName() error = %v", err)
+			}
+		}
+	}
+	blocks := dataBlocks(5)
+	for i, name := range names {
+		m, err := New(&Config{HashFunc: prefixed(byte(i + 1)), HashName: name}, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("MarshalJSON() error = %v", err)
+		}
+		var decoded MerkleTree
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("UnmarshalJSON() error = %v", err)
+		}
+		if decoded.HashName != name {
+			t.Errorf("HashName of the decoded tree = %q, want %q", decoded.HashName, name)
+		}
+		if ok, err := decoded.Verify(blocks[2], decoded.Proofs[2]); err != nil || !ok {
+			t.Errorf("Verify() with the hash function named %s = %v, %v, want true", name, ok, err)
+		}
+	}
+}
+
+func TestMerkleTree_MarshalJSON_rootBytes(t *testing.T) {
+	blocks := dataBlocks(6)
+	m, err := New(&Config{RootBytes: 20}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	restored := new(MerkleTree)
+	if err = json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if restored.RootBytes != 20 || restored.HashSize() != defaultHashLen {
+		t.Errorf("restored RootBytes, HashSize() = %d, %d, want 20, %d", restored.RootBytes, restored.HashSize(), defaultHashLen)
+	}
+	for i, block := range blocks {
+		if ok, err := restored.Verify(block, restored.Proofs[i]); err != nil || !ok {
+			t.Errorf("Verify() of the restored proof %d = %v, %v, want true", i, ok, err)
+		}
+	}
+}
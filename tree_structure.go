@@ -0,0 +1,229 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// treeStructureVersion is the version byte of the tree structure encoding.
+const treeStructureVersion = 2
+
+// Flags of the boolean configuration fields in the tree structure encoding.
+const (
+	structureSortSiblingPairs = 1 << iota
+	structureDisableLeafHashing
+	structureBindLeafIndex
+	structureNoDuplicates
+	structureEmbedFingerprint
+)
+
+// MarshalStructure encodes the configuration, the root and the tree nodes of the Merkle Tree, without the proofs,
+// e.g. to store a large tree compactly and generate the proofs with ProofOf or GenerateProof after decoding it
+// with UnmarshalStructure. The encoding takes about twice the size of the leaves, while the proofs take
+// the size of the leaves times the depth of the tree.
+//
+// The encoding is the version byte, the registered name of the hash function prefixed with its length as a uvarint,
+// a byte of the boolean configuration fields, the padding strategy byte, RootBytes and the number of leaves
+// of the data blocks, without the padding leaves of PaddingIndexed, as uvarints, the root prefixed with its length
+// as a uvarint, and the nodes of each level from the leaves up, each prefixed with its length as a uvarint,
+// with the length 0 for the nil padding nodes of PaddingPromote.
+//
+// As with MarshalJSON, the hash function is recorded by HashName, see RegisterHashFuncName.
+// The tree nodes are required, so the tree must be built in ModeTreeBuild or ModeProofGenAndTreeBuild.
+func (m *MerkleTree) MarshalStructure() ([]byte, error) {
+	if m.nodes == nil {
+		return nil, fmt.Errorf("merkle Tree built in %v has no nodes to marshal", m.Config.Mode)
+	}
+	if m.LeafTransform != nil {
+		return nil, errors.New("leaf transform could not be marshaled, could not marshal the Merkle Tree")
+	}
+	if m.PairHashFunc != nil {
+		return nil, errors.New("pair hash function could not be marshaled, could not marshal the Merkle Tree")
+	}
+	if m.LevelHashFunc != nil {
+		return nil, errors.New("level hash function could not be marshaled, could not marshal the Merkle Tree")
+	}
+	hashFuncName, err := registeredHashName(m.Config)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := m.treeNodes()
+	if err != nil {
+		return nil, err
+	}
+	var flags byte
+	for flag, set := range map[byte]bool{
+		structureSortSiblingPairs:   m.SortSiblingPairs,
+		structureDisableLeafHashing: m.DisableLeafHashing,
+		structureBindLeafIndex:      m.BindLeafIndex,
+		structureNoDuplicates:       m.NoDuplicates,
+		structureEmbedFingerprint:   m.EmbedFingerprint,
+	} {
+		if set {
+			flags |= flag
+		}
+	}
+	size := 1 + uvarintLen(uint64(len(hashFuncName))) + len(hashFuncName) + 2 +
+		uvarintLen(uint64(m.RootBytes)) + uvarintLen(uint64(m.NumLeaves)) + uvarintLen(uint64(len(m.Root))) + len(m.Root)
+	for _, level := range nodes {
+		for _, node := range level {
+			size += uvarintLen(uint64(len(node))) + len(node)
+		}
+	}
+	data := make([]byte, 0, size)
+	data = append(data, treeStructureVersion)
+	data = binary.AppendUvarint(data, uint64(len(hashFuncName)))
+	data = append(data, hashFuncName...)
+	data = append(data, flags, byte(m.PaddingStrategy))
+	data = binary.AppendUvarint(data, uint64(m.RootBytes))
+	data = binary.AppendUvarint(data, uint64(m.NumLeaves))
+	data = binary.AppendUvarint(data, uint64(len(m.Root)))
+	data = append(data, m.Root...)
+	for _, level := range nodes {
+		for _, node := range level {
+			data = binary.AppendUvarint(data, uint64(len(node)))
+			data = append(data, node...)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalStructure decodes the Merkle Tree from the encoding of MarshalStructure.
+// The hash function is looked up by its registered name, and the root is checked against
+// the top level of the tree nodes. The decoded Merkle Tree is in ModeTreeBuild,
+// with the proofs generated from the tree nodes by ProofOf and GenerateProof.
+// The nodes are copied, so the Merkle Tree does not reference the data.
+func (m *MerkleTree) UnmarshalStructure(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("tree structure data is empty")
+	}
+	if data[0] != treeStructureVersion {
+		return fmt.Errorf("unsupported tree structure encoding version %d", data[0])
+	}
+	d := proofDecoder{data: data[1:]}
+	hashFuncName := string(d.bytes())
+	var flags, paddingStrategy byte
+	if d.err == nil && len(d.data) < 2 {
+		d.err = errors.New("tree structure data is too short")
+	} else if d.err == nil {
+		flags, paddingStrategy, d.data = d.data[0], d.data[1], d.data[2:]
+	}
+	rootBytes, numLeaves := d.uvarint(), d.uvarint()
+	root := d.bytes()
+	if d.err != nil {
+		return fmt.Errorf("invalid tree structure: %v", d.err)
+	}
+	hashFunc, ok := HashFuncByName(hashFuncName)
+	if !ok {
+		return errors.New("hash function is not registered: " + hashFuncName)
+	}
+	if paddingStrategy > byte(PaddingIndexed) {
+		return fmt.Errorf("invalid padding strategy %d in tree structure", paddingStrategy)
+	}
+	if numLeaves < 2 || numLeaves > uint64(len(d.data)) || rootBytes > uint64(maxInt) || len(root) == 0 {
+		return errors.New("invalid tree structure")
+	}
+	treeLeaves := int(numLeaves)
+	if TypePaddingStrategy(paddingStrategy) == PaddingIndexed {
+		treeLeaves = indexedPaddingLen(treeLeaves)
+	}
+	depth := calTreeDepth(treeLeaves)
+	nodes := make([][][]byte, depth)
+	levelLen := treeLeaves
+	for level := range nodes {
+		// The odd levels are padded with a node.
+		levelLen += levelLen & 1
+		nodes[level] = make([][]byte, levelLen)
+		for i := range nodes[level] {
+			nodes[level][i] = d.bytes()
+		}
+		levelLen >>= 1
+	}
+	if d.err != nil {
+		return fmt.Errorf("invalid tree structure: %v", d.err)
+	}
+	if len(d.data) != 0 {
+		return errors.New("trailing bytes after the tree structure data")
+	}
+	config := &Config{
+		HashFunc:           hashFunc,
+		HashName:           hashFuncName,
+		Mode:               ModeTreeBuild,
+		PaddingStrategy:    TypePaddingStrategy(paddingStrategy),
+		SortSiblingPairs:   flags&structureSortSiblingPairs != 0,
+		DisableLeafHashing: flags&structureDisableLeafHashing != 0,
+		BindLeafIndex:      flags&structureBindLeafIndex != 0,
+		NoDuplicates:       flags&structureNoDuplicates != 0,
+		EmbedFingerprint:   flags&structureEmbedFingerprint != 0,
+		RootBytes:          int(rootBytes),
+	}
+	restored := &MerkleTree{}
+	restored.Restore(config)
+	top := nodes[depth-1]
+	computed, err := restored.hashPair(int(depth), top[0], top[1])
+	if err != nil {
+		return err
+	}
+	if !rootMatches(computed, root, config) {
+		return errors.New("root does not match the tree nodes in the tree structure")
+	}
+	m.Restore(config)
+	m.nodes, m.pruned = copyNodes(nodes), false
+	m.Root = append([]byte(nil), root...)
+	m.NumLeaves, m.Depth, m.numPaddingLeaves = int(numLeaves), depth, treeLeaves-int(numLeaves)
+	m.Leaves = m.nodes[0][:m.NumLeaves:m.NumLeaves]
+	m.Proofs, m.paddingProofs, m.keyMap, m.proofsByLeafHash = nil, nil, nil, nil
+	m.hashSize.Store(int64(len(computed)))
+	m.leafMap, m.leafMapShared = &sync.Map{}, false
+	for i, leaf := range m.Leaves {
+		m.leafMap.LoadOrStore(string(leaf), i)
+	}
+	return nil
+}
+
+// copyNodes copies the tree nodes into one buffer, keeping the nil nodes nil.
+func copyNodes(nodes [][][]byte) [][][]byte {
+	total := 0
+	for _, level := range nodes {
+		for _, node := range level {
+			total += len(node)
+		}
+	}
+	buf := make([]byte, total)
+	offset := 0
+	for _, level := range nodes {
+		for i, node := range level {
+			if node == nil {
+				continue
+			}
+			end := offset + copy(buf[offset:], node)
+			level[i] = buf[offset:end:end]
+			offset = end
+		}
+	}
+	return nodes
+}
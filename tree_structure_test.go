@@ -0,0 +1,180 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMerkleTree_MarshalStructure(t *testing.T) {
+	if _, ok := HashFuncByName("sha512"); !ok {
+		if err := RegisterHashFuncName("sha512", sha512HashFunc); err != nil {
+			t.Fatalf("RegisterHashFuncName() error = %v", err)
+		}
+	}
+	for _, numBlocks := range []int{2, 5, 9, 100} {
+		blocks := dataBlocks(numBlocks)
+		for _, config := range []Config{
+			{Mode: ModeTreeBuild},
+			{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, SortSiblingPairs: true},
+			{Mode: ModeTreeBuild, NoDuplicates: true, EmbedFingerprint: true},
+			{Mode: ModeTreeBuild, PaddingStrategy: PaddingPromote, RootBytes: 8},
+			{Mode: ModeTreeBuild, PaddingStrategy: PaddingIndexed},
+			{Mode: ModeTreeBuild, BindLeafIndex: true},
+			{Mode: ModeTreeBuild, HashFunc: sha512HashFunc, HashName: "sha512", DisableLeafHashing: true},
+		} {
+			config := config
+			m, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			data, err := m.MarshalStructure()
+			if err != nil {
+				t.Fatalf("MarshalStructure() error = %v", err)
+			}
+			var decoded MerkleTree
+			if err := decoded.UnmarshalStructure(data); err != nil {
+				t.Fatalf("%d blocks, %+v: UnmarshalStructure() error = %v", numBlocks, config, err)
+			}
+			if !bytes.Equal(decoded.Root, m.Root) || decoded.Mode != ModeTreeBuild || decoded.NumLeaves != m.NumLeaves ||
+				decoded.Depth != m.Depth || decoded.HashSize() != m.HashSize() {
+				t.Errorf("UnmarshalStructure() = root %x, mode %v, %d leaves, depth %d, want root %x, %v, %d leaves, depth %d",
+					decoded.Root, decoded.Mode, decoded.NumLeaves, decoded.Depth, m.Root, ModeTreeBuild, m.NumLeaves, m.Depth)
+			}
+			for i := 0; i < m.NumLeaves; i++ {
+				want, err := m.ProofOf(i)
+				if err != nil {
+					t.Fatalf("ProofOf() error = %v", err)
+				}
+				got, err := decoded.ProofOf(i)
+				if err != nil {
+					t.Fatalf("ProofOf() of the decoded tree error = %v", err)
+				}
+				if !got.Equal(want) {
+					t.Errorf("%d blocks, %+v: ProofOf(%d) of the decoded tree = %+v, want %+v", numBlocks, config, i, got, want)
+				}
+			}
+			for i, block := range blocks {
+				proof, err := decoded.ProofOf(i)
+				if err != nil {
+					t.Fatalf("ProofOf() of the decoded tree error = %v", err)
+				}
+				if ok, err := decoded.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify() with the decoded tree = %v, %v, want true", ok, err)
+				}
+				if config.BindLeafIndex {
+					continue
+				}
+				if proof, err = decoded.GenerateProof(block); err != nil {
+					t.Fatalf("GenerateProof() of the decoded tree error = %v", err)
+				}
+				if ok, err := Verify(block, proof, m.Root, &config); err != nil || !ok {
+					t.Errorf("Verify() of the proof of the decoded tree = %v, %v, want true", ok, err)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTree_MarshalStructure_size(t *testing.T) {
+	// Without the proofs, the structure is smaller than the proofs alone, and than the JSON archive.
+	m, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, dataBlocks(1000))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := m.MarshalStructure()
+	if err != nil {
+		t.Fatalf("MarshalStructure() error = %v", err)
+	}
+	proofsSize := 0
+	for _, proof := range m.Proofs {
+		proofsSize += proof.Size()
+	}
+	archive, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if len(data) >= proofsSize || len(data) >= len(archive) {
+		t.Errorf("MarshalStructure() is %d bytes, want less than the %d bytes of the proofs and the %d bytes of MarshalJSON()",
+			len(data), proofsSize, len(archive))
+	}
+	var decoded MerkleTree
+	if err := decoded.UnmarshalStructure(data); err != nil {
+		t.Fatalf("UnmarshalStructure() error = %v", err)
+	}
+	for i := range m.Proofs {
+		if proof, err := decoded.ProofOf(i); err != nil || !proof.Equal(m.Proofs[i]) {
+			t.Errorf("ProofOf(%d) of the decoded tree = %+v, %v, want %+v", i, proof, err, m.Proofs[i])
+		}
+	}
+}
+
+func TestMerkleTree_MarshalStructure_errors(t *testing.T) {
+	blocks := dataBlocks(5)
+	unregistered := func(data []byte) ([]byte, error) {
+		return sha512HashFunc(data)
+	}
+	for _, config := range []*Config{
+		nil,
+		{Mode: ModeTreeBuild, HashFunc: sha512HashFunc},
+		{Mode: ModeTreeBuild, HashFunc: unregistered, HashName: "unregistered"},
+		{Mode: ModeTreeBuild, LevelHashFunc: twoLevelHashFunc},
+	} {
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if _, err := m.MarshalStructure(); err == nil {
+			t.Errorf("MarshalStructure() with %+v error = nil, want error", config)
+		}
+	}
+
+	m, err := New(&Config{Mode: ModeTreeBuild}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	data, err := m.MarshalStructure()
+	if err != nil {
+		t.Fatalf("MarshalStructure() error = %v", err)
+	}
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(corrupted)-1] ^= 1
+	unknownHash := append([]byte{treeStructureVersion, 3}, "md4"...)
+	unknownHash = append(unknownHash, data[8:]...)
+	for name, data := range map[string][]byte{
+		"empty":          nil,
+		"version":        append([]byte{treeStructureVersion + 1}, data[1:]...),
+		"truncated":      data[:len(data)-1],
+		"trailing bytes": append(append([]byte(nil), data...), 0),
+		"corrupted node": corrupted,
+		"unknown hash":   unknownHash,
+		"header only":    data[:10],
+	} {
+		var decoded MerkleTree
+		if err := decoded.UnmarshalStructure(data); err == nil {
+			t.Errorf("UnmarshalStructure() of %s data error = nil, want error", name)
+		}
+	}
+}
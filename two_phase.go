@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// HashLeavesParallel computes the leaves of the data blocks in input order in parallel, without building the tree,
+// e.g. close to the data before shipping the leaves to NewFromLeafHashes. It is HashLeaves with RunInParallel set,
+// and the configuration is not modified.
+func HashLeavesParallel(config *Config, blocks []DataBlock) ([][]byte, error) {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	c.RunInParallel = true
+	return HashLeaves(&c, blocks)
+}
+
+// NewFromLeafHashes builds the Merkle Tree from the leaf layer, skipping the serialization and the hashing of the
+// data blocks, e.g. from the leaves computed by HashLeaves or HashLeavesParallel with the same configuration
+// on another machine, or from the Leaves of a previous tree after replacing a few of them, in any mode.
+// New is HashLeaves followed by the tree building of NewFromLeafHashes, sharing every step after the leaf generation,
+// e.g. the padding applied to the leaves and the handling of duplicate leaves, so the tree is identical to that of New
+// with the same configuration. The leaves are referenced by the tree, so they must not be modified.
+// AdditionalHashFuncs is not supported, as the leaves under the additional hash functions are not given,
+// and the data blocks are not keyed.
+func NewFromLeafHashes(config *Config, leafHashes [][]byte) (*MerkleTree, error) {
+	if len(leafHashes) <= 1 {
+		return nil, errors.New("the number of leaf hashes must be greater than 1")
+	}
+	if config != nil && len(config.AdditionalHashFuncs) > 0 {
+		return nil, errors.New("AdditionalHashFuncs is not supported when building from leaf hashes")
+	}
+	return build(context.Background(), config, len(leafHashes), 0, func(m *MerkleTree) ([][]byte, error) {
+		m.NumLeaves = len(leafHashes)
+		leaves := make([][]byte, len(leafHashes))
+		for i, leaf := range leafHashes {
+			if len(leaf) == 0 {
+				return nil, fmt.Errorf("leaf hash %d is empty", i)
+			}
+			if !m.DisableLeafHashing {
+				if err := m.checkHashSize(leaf); err != nil {
+					return nil, fmt.Errorf("leaf hash %d: %w", i, err)
+				}
+			}
+			leaves[i] = leaf
+		}
+		return leaves, nil
+	})
+}
+
+// BuildFromLeafHashes builds the Merkle Tree from the leaves as NewFromLeafHashes does.
+//
+// Deprecated: Use NewFromLeafHashes.
+func BuildFromLeafHashes(config *Config, leafHashes [][]byte) (*MerkleTree, error) {
+	return NewFromLeafHashes(config, leafHashes)
+}
@@ -0,0 +1,163 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestNewFromLeafHashes_sameAsNew(t *testing.T) {
+	configs := []Config{
+		{},
+		{SortSiblingPairs: true},
+		{PaddingStrategy: PaddingPromote},
+		{PaddingStrategy: PaddingIndexed},
+		{BindLeafIndex: true, EmbedFingerprint: true},
+		{DisableLeafHashing: true},
+		{OnDuplicateLeaf: DuplicateLeafCollapse},
+		{RootBytes: 20},
+	}
+	modes := []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild}
+	for _, numBlocks := range []int{2, 5, 9, 100} {
+		blocks := dataBlocks(numBlocks)
+		if numBlocks > 2 {
+			blocks[numBlocks-1] = blocks[0] // The leaves have a duplicate.
+		}
+		for i, config := range configs {
+			for _, mode := range modes {
+				name := fmt.Sprintf("%d blocks/config %d/%v", numBlocks, i, mode)
+				config.Mode = mode
+				newConfig, hashConfig, buildConfig := config, config, config
+				want, err := New(&newConfig, blocks)
+				if err != nil {
+					t.Fatalf("%s: New() error = %v", name, err)
+				}
+				leaves, err := HashLeavesParallel(&hashConfig, blocks)
+				if err != nil {
+					t.Fatalf("%s: HashLeavesParallel() error = %v", name, err)
+				}
+				if hashConfig.RunInParallel {
+					t.Fatalf("%s: HashLeavesParallel() modified the configuration", name)
+				}
+				m, err := NewFromLeafHashes(&buildConfig, leaves)
+				if err != nil {
+					t.Fatalf("%s: NewFromLeafHashes() error = %v", name, err)
+				}
+				if !bytes.Equal(m.Root, want.Root) || m.NumLeaves != want.NumLeaves || m.Depth != want.Depth {
+					t.Fatalf("%s: NewFromLeafHashes() differs from New()", name)
+				}
+				for j := range want.Leaves {
+					if !bytes.Equal(m.Leaves[j], want.Leaves[j]) {
+						t.Fatalf("%s: leaf %d differs from New()", name, j)
+					}
+					if mode == ModeTreeBuild {
+						continue
+					}
+					if !m.Proofs[j].Equal(want.Proofs[j]) {
+						t.Fatalf("%s: proof %d differs from New()", name, j)
+					}
+				}
+				for j, block := range blocks {
+					idx, err := want.LeafIndexOf(j)
+					if err != nil {
+						t.Fatalf("%s: LeafIndexOf() error = %v", name, err)
+					}
+					proof, err := m.ProofOf(idx)
+					if err != nil {
+						t.Fatalf("%s: ProofOf() error = %v", name, err)
+					}
+					if ok, err := Verify(block, proof, m.Root, &buildConfig); err != nil || !ok {
+						t.Fatalf("%s: Verify() of block %d = %v, %v, want true", name, j, ok, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNewFromLeafHashes(t *testing.T) {
+	blocks := dataBlocks(13)
+	for _, mode := range []TypeConfigMode{ModeProofGen, ModeTreeBuild, ModeProofGenAndTreeBuild} {
+		for _, padding := range []TypePaddingStrategy{PaddingDuplicate, PaddingPromote, PaddingIndexed} {
+			name := fmt.Sprintf("%v/%v", mode, padding)
+			config := &Config{Mode: mode, PaddingStrategy: padding}
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			rebuilt, err := NewFromLeafHashes(config, m.Leaves)
+			if err != nil {
+				t.Fatalf("%s: NewFromLeafHashes() error = %v", name, err)
+			}
+			if !bytes.Equal(rebuilt.Root, m.Root) {
+				t.Errorf("%s: NewFromLeafHashes() root = %x, want %x", name, rebuilt.Root, m.Root)
+			}
+			// Replace a leaf with the leaf of another data block, hashing only that data block.
+			changed := append([]DataBlock(nil), blocks...)
+			changed[4] = dataBlocks(1)[0]
+			newLeaves, err := HashLeaves(config, changed[4:5])
+			if err != nil {
+				t.Fatalf("%s: HashLeaves() error = %v", name, err)
+			}
+			leaves := append([][]byte(nil), m.Leaves...)
+			leaves[4] = newLeaves[0]
+			if rebuilt, err = NewFromLeafHashes(config, leaves); err != nil {
+				t.Fatalf("%s: NewFromLeafHashes() error = %v", name, err)
+			}
+			want, err := New(config, changed)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if !bytes.Equal(rebuilt.Root, want.Root) {
+				t.Errorf("%s: NewFromLeafHashes() of the changed leaves root = %x, want %x", name, rebuilt.Root, want.Root)
+			}
+		}
+	}
+}
+
+func TestNewFromLeafHashes_invalid(t *testing.T) {
+	leaves, err := HashLeaves(nil, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("HashLeaves() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		config *Config
+		leaves [][]byte
+	}{
+		{"one leaf", nil, leaves[:1]},
+		{"empty leaf", nil, [][]byte{leaves[0], {}}},
+		{"inconsistent sizes", nil, [][]byte{leaves[0], leaves[1][:20]}},
+		{"additional hash functions", &Config{AdditionalHashFuncs: []TypeHashFunc{DefaultHashFunc}}, leaves},
+		{"invalid padding strategy", &Config{PaddingStrategy: TypePaddingStrategy(-1)}, leaves},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFromLeafHashes(tt.config, tt.leaves); err == nil {
+				t.Errorf("NewFromLeafHashes() error = nil, want error")
+			}
+		})
+	}
+}
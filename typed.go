@@ -0,0 +1,55 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import "errors"
+
+// typedBlock adapts an item of any type to the DataBlock interface.
+type typedBlock[T any] struct {
+	item      T
+	serialize func(T) ([]byte, error)
+}
+
+// Serialize serializes the item with the adapter's serialize function.
+func (b *typedBlock[T]) Serialize() ([]byte, error) {
+	return b.serialize(b.item)
+}
+
+// NewTypedBlock adapts an item to the DataBlock interface with the serialize function,
+// e.g. to verify a typed item against a tree built by NewTyped.
+func NewTypedBlock[T any](item T, serialize func(T) ([]byte, error)) DataBlock {
+	return &typedBlock[T]{item: item, serialize: serialize}
+}
+
+// NewTyped generates a new Merkle Tree from typed items with specified configuration.
+// Each item is serialized with the serialize function, so the item type does not need to implement DataBlock.
+func NewTyped[T any](config *Config, items []T, serialize func(T) ([]byte, error)) (*MerkleTree, error) {
+	if serialize == nil {
+		return nil, errors.New("serialize function is nil")
+	}
+	blocks := make([]DataBlock, len(items))
+	for i := range items {
+		blocks[i] = NewTypedBlock(items[i], serialize)
+	}
+	return New(config, blocks)
+}
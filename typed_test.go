@@ -0,0 +1,131 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type typedRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type typedRecordBlock struct {
+	record typedRecord
+}
+
+func (b *typedRecordBlock) Serialize() ([]byte, error) {
+	return json.Marshal(b.record)
+}
+
+func serializeTypedRecord(r typedRecord) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func typedRecords(num int) []typedRecord {
+	records := make([]typedRecord, num)
+	for i := range records {
+		records[i] = typedRecord{ID: i, Name: string(rune('a' + i%26))}
+	}
+	return records
+}
+
+func TestNewTyped(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		items     []typedRecord
+		serialize func(typedRecord) ([]byte, error)
+		wantErr   bool
+	}{
+		{
+			name:      "test_5",
+			items:     typedRecords(5),
+			serialize: serializeTypedRecord,
+		},
+		{
+			name:      "test_100_parallel",
+			config:    &Config{RunInParallel: true, NumRoutines: 4},
+			items:     typedRecords(100),
+			serialize: serializeTypedRecord,
+		},
+		{
+			name:      "test_tree_build",
+			config:    &Config{Mode: ModeTreeBuild},
+			items:     typedRecords(9),
+			serialize: serializeTypedRecord,
+		},
+		{
+			name:    "test_nil_serialize",
+			items:   typedRecords(5),
+			wantErr: true,
+		},
+		{
+			name:  "test_serialize_error",
+			items: typedRecords(5),
+			serialize: func(typedRecord) ([]byte, error) {
+				return nil, errors.New("test_serialize_error")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTyped(tt.config, tt.items, tt.serialize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTyped() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			blocks := make([]DataBlock, len(tt.items))
+			for i, item := range tt.items {
+				blocks[i] = &typedRecordBlock{record: item}
+			}
+			var config *Config
+			if tt.config != nil {
+				config = &Config{Mode: tt.config.Mode}
+			}
+			want, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if !bytes.Equal(got.Root, want.Root) {
+				t.Errorf("NewTyped() root = %x, want %x", got.Root, want.Root)
+			}
+			if got.Proofs == nil {
+				return
+			}
+			for i, item := range tt.items {
+				ok, err := got.Verify(NewTypedBlock(item, tt.serialize), got.Proofs[i])
+				if err != nil || !ok {
+					t.Errorf("Verify() = %v, %v, want true", ok, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// isUnrolledTree reports whether the proofs are generated by the unrolled builders of the trees of 2, 4 and 8 leaves,
+// i.e. the small-tree fast path with nodes computed with the default hash function.
+// The trees have no odd level, so the padding strategy does not apply.
+func (m *MerkleTree) isUnrolledTree() bool {
+	if m.NumLeaves != 2 && m.NumLeaves != 4 && m.NumLeaves != 8 {
+		return false
+	}
+	if !m.isSmallTree() || m.DisableLeafHashing {
+		return false
+	}
+	ok, _ := m.isDefaultNodeHashing(m.HashSize())
+	return ok
+}
+
+// unrolledTree2 holds the proofs and the nodes of a tree of 2 leaves, allocated at once.
+type unrolledTree2 struct {
+	proofs   [2]Proof
+	ptrs     [2]*Proof
+	siblings [2 * 1][]byte
+	nodes    [1 * sha256.Size]byte
+}
+
+// unrolledTree4 holds the proofs and the nodes of a tree of 4 leaves, allocated at once.
+type unrolledTree4 struct {
+	proofs   [4]Proof
+	ptrs     [4]*Proof
+	siblings [4 * 2][]byte
+	nodes    [3 * sha256.Size]byte
+}
+
+// unrolledTree8 holds the proofs and the nodes of a tree of 8 leaves, allocated at once.
+type unrolledTree8 struct {
+	proofs   [8]Proof
+	ptrs     [8]*Proof
+	siblings [8 * 3][]byte
+	nodes    [7 * sha256.Size]byte
+}
+
+// proofGenUnrolled generates the proofs and the root of a tree of 2, 4 or 8 leaves, identical to those of proofGen.
+// The nodes are indexed as in a binary heap in a stack-allocated scratch array: the root is at index 1,
+// the children of the node at index k are at indexes 2k and 2k+1, and leaf i is at index NumLeaves+i.
+// The proofs, their siblings and the nodes are allocated at once.
+func (m *MerkleTree) proofGenUnrolled() error {
+	if err := m.checkCanceled(); err != nil {
+		return err
+	}
+	var (
+		_, sorted = m.isDefaultNodeHashing(sha256.Size)
+		heap      [16][]byte
+		concat    [2 * sha256.Size]byte
+	)
+	copy(heap[m.NumLeaves:], m.Leaves)
+	switch m.NumLeaves {
+	case 2:
+		t := new(unrolledTree2)
+		heap[1] = unrolledHash(t.nodes[:], &concat, heap[2], heap[3], sorted)
+		m.setUnrolledProofs(heap[:4], t.proofs[:], t.ptrs[:], t.siblings[:])
+	case 4:
+		t := new(unrolledTree4)
+		heap[2] = unrolledHash(t.nodes[0*sha256.Size:], &concat, heap[4], heap[5], sorted)
+		heap[3] = unrolledHash(t.nodes[1*sha256.Size:], &concat, heap[6], heap[7], sorted)
+		heap[1] = unrolledHash(t.nodes[2*sha256.Size:], &concat, heap[2], heap[3], sorted)
+		m.setUnrolledProofs(heap[:8], t.proofs[:], t.ptrs[:], t.siblings[:])
+	case 8:
+		t := new(unrolledTree8)
+		heap[4] = unrolledHash(t.nodes[0*sha256.Size:], &concat, heap[8], heap[9], sorted)
+		heap[5] = unrolledHash(t.nodes[1*sha256.Size:], &concat, heap[10], heap[11], sorted)
+		heap[6] = unrolledHash(t.nodes[2*sha256.Size:], &concat, heap[12], heap[13], sorted)
+		heap[7] = unrolledHash(t.nodes[3*sha256.Size:], &concat, heap[14], heap[15], sorted)
+		heap[2] = unrolledHash(t.nodes[4*sha256.Size:], &concat, heap[4], heap[5], sorted)
+		heap[3] = unrolledHash(t.nodes[5*sha256.Size:], &concat, heap[6], heap[7], sorted)
+		heap[1] = unrolledHash(t.nodes[6*sha256.Size:], &concat, heap[2], heap[3], sorted)
+		m.setUnrolledProofs(heap[:16], t.proofs[:], t.ptrs[:], t.siblings[:])
+	}
+	m.Root = truncateRoot(heap[1], m.Config)
+	return nil
+}
+
+// unrolledHash computes the parent of the nodes with the default hash function into dst,
+// concatenating the nodes, sorted if sort is true, in the concat scratch array.
+func unrolledHash(dst []byte, concat *[2 * sha256.Size]byte, left, right []byte, sort bool) []byte {
+	if sort && bytes.Compare(left, right) >= 0 {
+		left, right = right, left
+	}
+	copy(concat[:sha256.Size], left)
+	copy(concat[sha256.Size:], right)
+	digest := sha256.Sum256(concat[:])
+	return dst[:copy(dst, digest[:]):sha256.Size]
+}
+
+// setUnrolledProofs sets the proofs of the tree from the nodes indexed as in a binary heap.
+func (m *MerkleTree) setUnrolledProofs(heap [][]byte, proofs []Proof, ptrs []*Proof, siblings [][]byte) {
+	depth := int(m.Depth)
+	for i := range proofs {
+		proof := &proofs[i]
+		proof.Index, proof.Fingerprint = i, m.fingerprint
+		proof.Siblings = siblings[i*depth : (i+1)*depth : (i+1)*depth]
+		for k, level := len(proofs)+i, 0; k > 1; k, level = k>>1, level+1 {
+			if k&1 == 0 {
+				proof.Path += 1 << level // The sibling is on the right.
+			}
+			proof.Siblings[level] = heap[k^1]
+		}
+		ptrs[i] = proof
+	}
+	m.Proofs = ptrs
+}
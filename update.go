@@ -0,0 +1,361 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// UpdateBatch replaces the data blocks at the leaf indexes with the new data blocks, and recomputes the tree.
+// The new leaves are hashed in parallel if RunInParallel is true, and every internal node on the paths
+// from the updated leaves to the root is recomputed exactly once, level by level.
+// The update is atomic: the changes are staged and only applied if all the new leaves and nodes are computed,
+// so on error the tree is left unchanged. Proofs returned before the update are not modified,
+// and neither are the snapshots returned by Snapshot.
+// The tree nodes are required, so the Merkle Tree must be built in ModeTreeBuild or ModeProofGenAndTreeBuild.
+// UpdateBatch must not be called concurrently with other methods of the Merkle Tree.
+// Trees built with additional hash functions could not be updated.
+func (m *MerkleTree) UpdateBatch(updates map[int]DataBlock) error {
+	if m.nodes == nil {
+		return fmt.Errorf("merkle Tree built in %v has no tree nodes, could not update data blocks", m.Config.Mode)
+	}
+	if len(m.AdditionalHashFuncs) > 0 {
+		return errors.New("merkle Tree with additional hash functions could not be updated, " +
+			"as the additional leaves are not retained")
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(updates))
+	for idx, block := range updates {
+		if idx < 0 || idx >= m.NumLeaves {
+			return fmt.Errorf("leaf index %d is out of range [0, %d)", idx, m.NumLeaves)
+		}
+		if block == nil {
+			return fmt.Errorf("data block at leaf index %d is nil", idx)
+		}
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	blocks := make([]DataBlock, len(indexes))
+	for i, idx := range indexes {
+		blocks[i] = updates[idx]
+	}
+	if m.pruned {
+		// The pruned levels are recomputed for the update, and pruned again afterwards.
+		nodes, err := m.treeNodes()
+		if err != nil {
+			return err
+		}
+		m.nodes = nodes
+		defer m.dropPrunedLevels()
+	}
+	keyMap, err := m.updatedKeyMap(indexes, blocks)
+	if err != nil {
+		return err
+	}
+	if m.RunInParallel {
+		m.startWorkerPool()
+		defer m.stopWorkerPool()
+	}
+	newLeaves, err := m.updatedLeaves(indexes, blocks)
+	if err != nil {
+		return err
+	}
+	nodes, changed, root, err := m.updatedNodes(indexes, newLeaves)
+	if err != nil {
+		return err
+	}
+
+	// All the computations succeeded, apply the staged changes.
+	m.ownLeafMap()
+	leaves := make([][]byte, len(m.Leaves))
+	copy(leaves, m.Leaves)
+	// The leaf map keeps the first of identical leaves, so a replaced leaf may still be found at a later index.
+	removed := make(map[string]struct{})
+	for i, idx := range indexes {
+		if val, ok := m.leafMap.Load(string(m.Leaves[idx])); ok && val.(int) == idx {
+			m.leafMap.Delete(string(m.Leaves[idx]))
+			removed[string(m.Leaves[idx])] = struct{}{}
+		}
+		leaves[idx] = newLeaves[i]
+	}
+	for i, idx := range indexes {
+		if val, ok := m.leafMap.Load(string(newLeaves[i])); !ok || val.(int) > idx {
+			m.leafMap.Store(string(newLeaves[i]), idx)
+		}
+	}
+	for i := 0; i < len(leaves) && len(removed) > 0; i++ {
+		if _, ok := removed[string(leaves[i])]; ok {
+			m.leafMap.Store(string(leaves[i]), i)
+			delete(removed, string(leaves[i]))
+		}
+	}
+	if m.Proofs != nil {
+		proofs := m.updatedProofs(nodes, changed)
+		if m.ReversedProofs != nil {
+			m.ReversedProofs = updatedReversedProofs(m.ReversedProofs, m.Proofs, proofs)
+		}
+		m.Proofs = proofs
+	}
+	m.Leaves, m.nodes, m.Root, m.keyMap = leaves, nodes, root, keyMap
+	m.proofsByLeafHash = nil
+	return nil
+}
+
+// UpdateLeaf replaces the data block at the leaf index with the new data block, and recomputes the tree.
+// Only the nodes on the path from the leaf to the root are recomputed, and in ModeProofGenAndTreeBuild,
+// only the stored proofs having one of these nodes as a sibling are refreshed, each by replacing that sibling,
+// so the stored proofs stay consistent with the new root. The requirements are those of UpdateBatch.
+func (m *MerkleTree) UpdateLeaf(idx int, block DataBlock) error {
+	return m.UpdateBatch(map[int]DataBlock{idx: block})
+}
+
+// updatedKeyMap returns the key map with the keys of the data blocks at the leaf indexes replaced.
+// The key map of the tree is not modified.
+func (m *MerkleTree) updatedKeyMap(indexes []int, blocks []DataBlock) (map[string]int, error) {
+	keyed := false
+	for _, block := range blocks {
+		if _, ok := block.(KeyedDataBlock); ok {
+			keyed = true
+			break
+		}
+	}
+	if m.keyMap == nil && !keyed {
+		return nil, nil
+	}
+	updated := make(map[int]struct{}, len(indexes))
+	for _, idx := range indexes {
+		updated[idx] = struct{}{}
+	}
+	keyMap := make(map[string]int, len(m.keyMap)+len(blocks))
+	for key, idx := range m.keyMap {
+		if _, ok := updated[idx]; !ok {
+			keyMap[key] = idx
+		}
+	}
+	for i, block := range blocks {
+		keyedBlock, ok := block.(KeyedDataBlock)
+		if !ok {
+			continue
+		}
+		key := keyedBlock.Key()
+		if _, ok := keyMap[key]; ok {
+			return nil, errors.New("duplicate data block keys: " + key)
+		}
+		keyMap[key] = indexes[i]
+	}
+	if len(keyMap) == 0 {
+		return nil, nil
+	}
+	return keyMap, nil
+}
+
+// updatedLeaves computes the leaves of the data blocks at the leaf indexes.
+func (m *MerkleTree) updatedLeaves(indexes []int, blocks []DataBlock) ([][]byte, error) {
+	leaves := make([][]byte, len(indexes))
+	if !m.RunInParallel {
+		for i, idx := range indexes {
+			if err := m.updateLeaf(leaves, blocks, indexes, i); err != nil {
+				return nil, fmt.Errorf("leaf index %d: %w", idx, err)
+			}
+		}
+		return leaves, nil
+	}
+	numRoutines := m.numWorkers
+	if numRoutines > len(indexes) {
+		numRoutines = len(indexes)
+	}
+	argList := make([]argType, numRoutines)
+	for i := 0; i < numRoutines; i++ {
+		argList[i] = argType{
+			mt:             m,
+			dataBlockField: blocks,
+			byteField1:     leaves,
+			intSliceField:  indexes,
+			intField1:      i, // starting index
+			intField2:      numRoutines,
+		}
+	}
+	if err := m.mapWorkers(updateLeafHandler, argList); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// updateLeafHandler computes the updated leaves in parallel.
+func updateLeafHandler(arg argType) (err error) {
+	defer recoverError(&err)
+	var (
+		blocks      = arg.dataBlockField
+		leaves      = arg.byteField1
+		indexes     = arg.intSliceField
+		start       = arg.intField1
+		numRoutines = arg.intField2
+	)
+	for i := start; i < len(indexes); i += numRoutines {
+		if err = arg.mt.checkCanceled(); err != nil {
+			return
+		}
+		if err = arg.mt.updateLeaf(leaves, blocks, indexes, i); err != nil {
+			return fmt.Errorf("leaf index %d: %w", indexes[i], err)
+		}
+	}
+	return nil
+}
+
+// updateLeaf computes the i-th updated leaf.
+func (m *MerkleTree) updateLeaf(leaves [][]byte, blocks []DataBlock, indexes []int, i int) (err error) {
+	if leaves[i], _, err = m.dataBlockLeaves(blocks[i], indexes[i]); err != nil {
+		return
+	}
+	if !m.DisableLeafHashing {
+		err = m.checkHashSize(leaves[i])
+	}
+	return
+}
+
+// updatedNodes recomputes the tree nodes on the paths from the updated leaves to the root.
+// Only the modified levels are copied, the tree nodes are not modified.
+// It returns the new tree nodes, the indexes of the changed nodes at each level, and the new root.
+func (m *MerkleTree) updatedNodes(indexes []int, leaves [][]byte) ([][][]byte, [][]int, []byte, error) {
+	var (
+		nodes   = make([][][]byte, len(m.nodes))
+		changed = make([][]int, len(m.nodes))
+		dirty   = append([]int(nil), indexes...)
+		values  = leaves
+		realLen = m.treeLeafCount() // number of nodes at the level before padding
+	)
+	for level := range m.nodes {
+		buf := make([][]byte, len(m.nodes[level]))
+		copy(buf, m.nodes[level])
+		for i, idx := range dirty {
+			buf[idx] = values[i]
+		}
+		// The padding node duplicates the last node, or is derived from it with NoDuplicates,
+		// unless it is the nil node of PaddingPromote.
+		if len(buf) > realLen && buf[realLen] != nil && dirty[len(dirty)-1] == realLen-1 {
+			buf[realLen] = buf[realLen-1]
+			if m.NoDuplicates {
+				var err error
+				if buf[realLen], err = m.noDuplicatesFiller(m.HashFunc, buf[realLen-1]); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+			dirty = append(dirty, realLen)
+		}
+		nodes[level], changed[level] = buf, dirty
+		if level == len(m.nodes)-1 {
+			break
+		}
+		var (
+			parents      = make([]int, 0, len(dirty))
+			parentValues = make([][]byte, 0, len(dirty))
+		)
+		for _, idx := range dirty {
+			parent := idx >> 1
+			if len(parents) > 0 && parents[len(parents)-1] == parent {
+				continue
+			}
+			node, err := m.hashPair(level+1, buf[parent<<1], buf[parent<<1+1])
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			parents = append(parents, parent)
+			parentValues = append(parentValues, node)
+		}
+		dirty, values, realLen = parents, parentValues, len(buf)>>1
+	}
+	top := nodes[len(nodes)-1]
+	root, err := m.hashPair(len(nodes), top[0], top[1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return nodes, changed, truncateRoot(root, m.Config), nil
+}
+
+// updatedProofs returns the proofs with the siblings replaced by the changed nodes.
+// The proofs are copied on write, so the proofs of the tree are not modified.
+func (m *MerkleTree) updatedProofs(nodes [][][]byte, changed [][]int) []*Proof {
+	proofs := make([]*Proof, len(m.Proofs))
+	copy(proofs, m.Proofs)
+	copied := make(map[int]struct{})
+	// positions are the sibling positions of the levels of the proofs of the changed leaves, if they differ.
+	positions := make(map[int][]int)
+	for level, dirty := range changed {
+		for _, idx := range dirty {
+			// The changed node is the sibling of the nodes of the leaves in the subtree of its neighbor.
+			start := (idx ^ 1) << level
+			end := min(start+1<<level, m.NumLeaves)
+			for i := start; i < end; i++ {
+				if proofs[i] == nil {
+					continue
+				}
+				if _, ok := copied[i]; !ok {
+					proof := *proofs[i]
+					proof.Siblings = append([][]byte(nil), proof.Siblings...)
+					proofs[i] = &proof
+					copied[i] = struct{}{}
+				}
+				position := level
+				if m.PaddingStrategy == PaddingPromote {
+					if _, ok := positions[i]; !ok {
+						positions[i] = m.siblingPositions(i)
+					}
+					position = positions[i][level]
+				}
+				proofs[i].Siblings[position] = nodes[level][idx]
+			}
+		}
+	}
+	return proofs
+}
+
+// updatedReversedProofs returns the reversed proofs with the proofs changed from the old proofs
+// to the new proofs reversed again. The reversed proofs of the tree are not modified.
+func updatedReversedProofs(reversed, oldProofs, newProofs []*Proof) []*Proof {
+	updated := make([]*Proof, len(reversed))
+	copy(updated, reversed)
+	for i, proof := range newProofs {
+		if proof != oldProofs[i] {
+			// The proof is not nil, as only the generated proofs are changed.
+			updated[i] = proof.Reverse()
+		}
+	}
+	return updated
+}
+
+// siblingPositions returns the position in the proof of the leaf at the index of the sibling at each level,
+// or -1 if the proof has no sibling at the level.
+func (m *MerkleTree) siblingPositions(idx int) []int {
+	positions := make([]int, m.Depth)
+	for i := range positions {
+		positions[i] = -1
+	}
+	for position, level := range m.siblingLevels(idx) {
+		positions[level] = position
+	}
+	return positions
+}
@@ -0,0 +1,245 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+type errDataBlock struct{}
+
+func (errDataBlock) Serialize() ([]byte, error) {
+	return nil, errors.New("test_data_block_serialize_err")
+}
+
+func TestMerkleTree_UpdateBatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, numLeaves := range []int{2, 3, 8, 13, 37} {
+		for _, mode := range []TypeConfigMode{ModeTreeBuild, ModeProofGenAndTreeBuild} {
+			for _, config := range []Config{
+				{},
+				{RunInParallel: true, NumRoutines: 3},
+				{BindLeafIndex: true, SortSiblingPairs: true},
+			} {
+				config.Mode = mode
+				blocks := dataBlocks(numLeaves)
+				treeConfig := config
+				m, err := New(&treeConfig, blocks)
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				for round := 0; round < 5; round++ {
+					// Random leaves, a pair of adjacent leaves, and the last leaf which may be padded.
+					updates := map[int]DataBlock{numLeaves - 1: dataBlocks(1)[0]}
+					adjacent := r.Intn(numLeaves - 1)
+					updates[adjacent], updates[adjacent+1] = dataBlocks(1)[0], dataBlocks(1)[0]
+					for _, idx := range r.Perm(numLeaves)[:r.Intn(numLeaves)] {
+						updates[idx] = dataBlocks(1)[0]
+					}
+					oldRoot, oldProofs, oldBlocks := m.Root, m.Proofs, append([]DataBlock(nil), blocks...)
+					if err := m.UpdateBatch(updates); err != nil {
+						t.Fatalf("UpdateBatch() error = %v", err)
+					}
+					for idx, block := range updates {
+						blocks[idx] = block
+					}
+					rebuildConfig := config
+					want, err := New(&rebuildConfig, blocks)
+					if err != nil {
+						t.Fatalf("New() error = %v", err)
+					}
+					if !bytes.Equal(m.Root, want.Root) {
+						t.Fatalf("leaves: %d, config: %+v: root after UpdateBatch differs from a full rebuild",
+							numLeaves, config)
+					}
+					for i := range blocks {
+						if !bytes.Equal(m.Leaves[i], want.Leaves[i]) {
+							t.Errorf("leaf %d after UpdateBatch differs from a full rebuild", i)
+						}
+						if mode == ModeProofGenAndTreeBuild && !m.Proofs[i].Equal(want.Proofs[i]) {
+							t.Errorf("proof %d after UpdateBatch differs from a full rebuild", i)
+						}
+					}
+					for i := range blocks {
+						proof, err := m.Proof(blocks[i])
+						if err != nil && !config.BindLeafIndex {
+							t.Fatalf("Proof() error = %v", err)
+						}
+						if err == nil && !proof.Equal(want.proofFromNodes(i)) {
+							t.Errorf("Proof() of leaf %d after UpdateBatch differs from a full rebuild", i)
+						}
+					}
+					// The proofs returned before the update still verify against the old root.
+					verifyConfig := m.ConfigSnapshot()
+					for i := range oldProofs {
+						if ok, err := Verify(oldBlocks[i], oldProofs[i], oldRoot, &verifyConfig); err != nil || !ok {
+							t.Errorf("Verify() of old proof %d against the old root = %v, %v, want true", i, ok, err)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTree_UpdateLeaf(t *testing.T) {
+	for _, numLeaves := range []int{2, 5, 13} {
+		for _, config := range []Config{
+			{Mode: ModeProofGenAndTreeBuild},
+			{Mode: ModeProofGenAndTreeBuild, PaddingStrategy: PaddingPromote},
+			{Mode: ModeProofGenAndTreeBuild, RunInParallel: true, SortSiblingPairs: true},
+		} {
+			config := config
+			blocks := dataBlocks(numLeaves)
+			m, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for idx := range blocks {
+				oldProof := m.Proofs[idx]
+				blocks[idx] = dataBlocks(1)[0]
+				if err = m.UpdateLeaf(idx, blocks[idx]); err != nil {
+					t.Fatalf("UpdateLeaf() error = %v", err)
+				}
+				for i, block := range blocks {
+					if ok, err := m.Verify(block, m.Proofs[i]); err != nil || !ok {
+						t.Errorf("leaves: %d, config: %+v: Verify() of stored proof %d after UpdateLeaf(%d) = %v, %v",
+							numLeaves, config, i, idx, ok, err)
+					}
+				}
+				// The siblings of the updated leaf only change if it is duplicated to pad the leaf level.
+				padded := idx == numLeaves-1 && numLeaves&1 == 1 && config.PaddingStrategy != PaddingPromote
+				if !padded && m.Proofs[idx] != oldProof {
+					t.Errorf("UpdateLeaf(%d) refreshed the proof of the updated leaf, want it unchanged", idx)
+				}
+			}
+		}
+	}
+	m, err := New(&Config{Mode: ModeProofGenAndTreeBuild}, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = m.UpdateLeaf(4, dataBlocks(1)[0]); err == nil {
+		t.Error("UpdateLeaf() of an out of range leaf index: error = nil")
+	}
+}
+
+func TestMerkleTree_UpdateBatch_noDuplicates(t *testing.T) {
+	blocks := dataBlocks(7)
+	m, err := New(&Config{Mode: ModeProofGenAndTreeBuild, NoDuplicates: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	updates := map[int]DataBlock{0: dataBlocks(1)[0], 6: dataBlocks(1)[0]}
+	if err := m.UpdateBatch(updates); err != nil {
+		t.Fatalf("UpdateBatch() error = %v", err)
+	}
+	for idx, block := range updates {
+		blocks[idx] = block
+	}
+	for i, block := range blocks {
+		if ok, err := m.Verify(block, m.Proofs[i]); err != nil || !ok {
+			t.Errorf("Verify() of leaf %d = %v, %v, want true", i, ok, err)
+		}
+	}
+	// The padding nodes are derived from the last nodes, so the root is that of the rebuilt tree.
+	rebuilt, err := New(&Config{Mode: ModeProofGenAndTreeBuild, NoDuplicates: true}, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !bytes.Equal(m.Root, rebuilt.Root) {
+		t.Errorf("UpdateBatch() root = %x, want %x of the rebuilt tree", m.Root, rebuilt.Root)
+	}
+}
+
+func TestMerkleTree_UpdateBatch_atomic(t *testing.T) {
+	blocks := dataBlocks(9)
+	tests := []struct {
+		name    string
+		config  *Config
+		updates map[int]DataBlock
+	}{
+		{"serialize_error", &Config{Mode: ModeProofGenAndTreeBuild},
+			map[int]DataBlock{1: dataBlocks(1)[0], 8: errDataBlock{}}},
+		{"serialize_error_parallel", &Config{Mode: ModeProofGenAndTreeBuild, RunInParallel: true},
+			map[int]DataBlock{1: dataBlocks(1)[0], 5: errDataBlock{}}},
+		{"out_of_range", &Config{Mode: ModeTreeBuild}, map[int]DataBlock{1: dataBlocks(1)[0], 9: dataBlocks(1)[0]}},
+		{"nil_data_block", &Config{Mode: ModeTreeBuild}, map[int]DataBlock{1: nil}},
+		{"hash_error", &Config{
+			Mode: ModeTreeBuild,
+			HashFunc: func(data []byte) ([]byte, error) {
+				if bytes.Equal(data, []byte("hash_error")) {
+					return nil, errors.New("test_hash_func_err")
+				}
+				return DefaultHashFunc(data)
+			},
+		}, map[int]DataBlock{0: dataBlocks(1)[0], 4: &mock.DataBlock{Data: []byte("hash_error")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			root, leaves, proofs := m.Root, append([][]byte(nil), m.Leaves...), append([]*Proof(nil), m.Proofs...)
+			if err := m.UpdateBatch(tt.updates); err == nil {
+				t.Fatalf("UpdateBatch() error = nil, want error")
+			}
+			if !bytes.Equal(m.Root, root) {
+				t.Errorf("UpdateBatch() modified the root on error")
+			}
+			for i := range leaves {
+				if !bytes.Equal(m.Leaves[i], leaves[i]) {
+					t.Errorf("UpdateBatch() modified leaf %d on error", i)
+				}
+			}
+			for i := range proofs {
+				if m.Proofs[i] != proofs[i] {
+					t.Errorf("UpdateBatch() modified proof %d on error", i)
+				}
+			}
+			for i, block := range blocks {
+				proof, err := m.Proof(block)
+				if err != nil {
+					t.Fatalf("Proof() error = %v", err)
+				}
+				if ok, err := m.Verify(block, proof); err != nil || !ok {
+					t.Errorf("Verify() of leaf %d after failed UpdateBatch = %v, %v, want true", i, ok, err)
+				}
+			}
+		})
+	}
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	const want = "merkle Tree built in ModeProofGen has no tree nodes, could not update data blocks"
+	if err := m.UpdateBatch(map[int]DataBlock{0: dataBlocks(1)[0]}); err == nil || err.Error() != want {
+		t.Errorf("UpdateBatch() in ModeProofGen error = %v, want %q", err, want)
+	}
+}
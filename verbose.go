@@ -0,0 +1,148 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeWarningCode is the code of a warning returned by NewVerbose.
+type TypeWarningCode int
+
+const (
+	// WarningPadding is returned when odd levels of the tree are padded, or the leaf level by PaddingIndexed,
+	// describing the padded levels and the padding strategy.
+	WarningPadding TypeWarningCode = iota + 1
+	// WarningParallelSkipped is returned when AutoParallel is true and the tree is built serially,
+	// as there are fewer than AutoParallelMinBlocks data blocks.
+	WarningParallelSkipped
+	// WarningDefaultRoutines is returned when the tree is built in parallel and NumRoutines is not set,
+	// so the number of CPU is used.
+	WarningDefaultRoutines
+	// WarningRoutinesCapped is returned when the tree is built in parallel with fewer workers than NumRoutines,
+	// as the number of workers is capped to GOMAXPROCS.
+	WarningRoutinesCapped
+	// WarningDefaultHashFunc is returned when neither HashFunc nor Hasher is set, so SHA-256 is used.
+	WarningDefaultHashFunc
+)
+
+func (c TypeWarningCode) String() string {
+	switch c {
+	case WarningPadding:
+		return "padding"
+	case WarningParallelSkipped:
+		return "parallelism skipped"
+	case WarningDefaultRoutines:
+		return "default routines"
+	case WarningRoutinesCapped:
+		return "routines capped"
+	case WarningDefaultHashFunc:
+		return "default hash function"
+	default:
+		return fmt.Sprintf("TypeWarningCode(%d)", int(c))
+	}
+}
+
+// Warning is a non-fatal decision of the tree building, returned by NewVerbose.
+type Warning struct {
+	// Code identifies the decision.
+	Code TypeWarningCode
+	// Message describes the decision for humans.
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%v: %s", w.Code, w.Message)
+}
+
+// NewVerbose generates a new Merkle Tree like New, and also returns the warnings describing
+// the decisions taken silently by New, e.g. to debug surprising roots: the padding of odd levels,
+// the parallelization skipped by AutoParallel, and the defaults applied to the number of routines
+// and to the hash function. The warnings are in the order of their codes.
+func NewVerbose(config *Config, blocks []DataBlock) (*MerkleTree, []Warning, error) {
+	m, err := New(config, blocks)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config == nil {
+		config = new(Config)
+	}
+	return m, m.buildWarnings(config), nil
+}
+
+// buildWarnings returns the warnings of the build of the Merkle Tree with the configuration of the caller,
+// which the build leaves unchanged.
+func (m *MerkleTree) buildWarnings(config *Config) []Warning {
+	var warnings []Warning
+	warn := func(code TypeWarningCode, format string, args ...any) {
+		warnings = append(warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	if m.PaddingStrategy == PaddingIndexed {
+		// The leaf level is padded to a power of two, so no level above is odd.
+		if m.numPaddingLeaves > 0 {
+			warn(WarningPadding, "leaf level 0 (%d nodes) is padded to %d nodes by appending indexed padding leaves",
+				m.NumLeaves, m.PaddedLeafCount())
+		}
+	} else if levels := m.paddedLevels(); len(levels) > 0 {
+		var padding string
+		switch {
+		case m.PaddingStrategy == PaddingPromote:
+			padding = "promoting the last node"
+		case m.NoDuplicates:
+			padding = "appending a node derived from the last node"
+		default:
+			padding = "duplicating the last node"
+		}
+		names := make([]string, len(levels))
+		for i, level := range levels {
+			names[i] = fmt.Sprintf("%d (%d nodes)", level, (m.NumLeaves+1<<level-1)>>level)
+		}
+		warn(WarningPadding, "odd levels %s are padded by %s", strings.Join(names, ", "), padding)
+	}
+	if config.AutoParallel && !m.RunInParallel {
+		warn(WarningParallelSkipped, "parallelism skipped for %d data blocks, fewer than %d",
+			m.NumLeaves, AutoParallelMinBlocks)
+	}
+	if m.RunInParallel && config.NumRoutines <= 0 {
+		warn(WarningDefaultRoutines, "NumRoutines is not set, the number of CPU %d is used", m.NumRoutines)
+	}
+	if m.RunInParallel && m.numWorkers < m.NumRoutines {
+		warn(WarningRoutinesCapped, "%d routines are capped to GOMAXPROCS %d", m.NumRoutines, m.numWorkers)
+	}
+	if config.HashFunc == nil && config.Hasher == nil {
+		warn(WarningDefaultHashFunc, "neither HashFunc nor Hasher is set, %s is used", DefaultHashAlgorithm)
+	}
+	return warnings
+}
+
+// paddedLevels returns the levels of the tree with an odd number of nodes, which are padded.
+func (m *MerkleTree) paddedLevels() []int {
+	var levels []int
+	for level := 0; level < int(m.Depth); level++ {
+		if levelLen := (m.NumLeaves + 1<<level - 1) >> level; levelLen&1 == 1 {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
@@ -0,0 +1,183 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// warningCodes returns the codes of the warnings.
+func warningCodes(warnings []Warning) []TypeWarningCode {
+	codes := make([]TypeWarningCode, len(warnings))
+	for i, w := range warnings {
+		codes[i] = w.Code
+	}
+	return codes
+}
+
+func TestNewVerbose(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		numBlocks int
+		want      []TypeWarningCode
+		message   string
+	}{
+		{
+			name:      "duplication",
+			config:    &Config{HashFunc: DefaultHashFunc},
+			numBlocks: 5,
+			want:      []TypeWarningCode{WarningPadding},
+			message:   "odd levels 0 (5 nodes), 1 (3 nodes) are padded by duplicating the last node",
+		},
+		{
+			name:      "promotion",
+			config:    &Config{HashFunc: DefaultHashFunc, PaddingStrategy: PaddingPromote},
+			numBlocks: 6,
+			want:      []TypeWarningCode{WarningPadding},
+			message:   "odd levels 1 (3 nodes) are padded by promoting the last node",
+		},
+		{
+			name:      "indexed",
+			config:    &Config{HashFunc: DefaultHashFunc, PaddingStrategy: PaddingIndexed},
+			numBlocks: 5,
+			want:      []TypeWarningCode{WarningPadding},
+			message:   "leaf level 0 (5 nodes) is padded to 8 nodes by appending indexed padding leaves",
+		},
+		{
+			name:      "indexed_power_of_two",
+			config:    &Config{HashFunc: DefaultHashFunc, PaddingStrategy: PaddingIndexed},
+			numBlocks: 8,
+		},
+		{
+			name:      "no_padding",
+			config:    &Config{HashFunc: DefaultHashFunc},
+			numBlocks: 8,
+		},
+		{
+			name:      "parallelism_skipped",
+			config:    &Config{HashFunc: DefaultHashFunc, AutoParallel: true, RunInParallel: true},
+			numBlocks: 4,
+			want:      []TypeWarningCode{WarningParallelSkipped},
+			message:   "parallelism skipped for 4 data blocks, fewer than 10000",
+		},
+		{
+			name:      "default_hash_func",
+			config:    nil,
+			numBlocks: 4,
+			want:      []TypeWarningCode{WarningDefaultHashFunc},
+			message:   "neither HashFunc nor Hasher is set, SHA-256 is used",
+		},
+		{
+			name:      "default_routines",
+			config:    &Config{HashFunc: DefaultHashFunc, RunInParallel: true},
+			numBlocks: 4,
+			want:      []TypeWarningCode{WarningDefaultRoutines},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, warnings, err := NewVerbose(tt.config, dataBlocks(tt.numBlocks))
+			if err != nil {
+				t.Fatalf("NewVerbose() error = %v", err)
+			}
+			if m == nil || m.NumLeaves != tt.numBlocks {
+				t.Fatalf("NewVerbose() tree = %v, want a tree of %d leaves", m, tt.numBlocks)
+			}
+			got := warningCodes(warnings)
+			// The capping of the routines depends on GOMAXPROCS.
+			if len(got) > 0 && got[len(got)-1] == WarningRoutinesCapped {
+				got = got[:len(got)-1]
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("NewVerbose() warnings = %v, want codes %v", warnings, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("NewVerbose() warning %d = %v, want %v", i, warnings[i], tt.want[i])
+				}
+			}
+			if tt.message != "" && warnings[0].Message != tt.message {
+				t.Errorf("NewVerbose() message = %q, want %q", warnings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestNewVerbose_routinesCapped(t *testing.T) {
+	config := &Config{HashFunc: DefaultHashFunc, RunInParallel: true, NumRoutines: runtime.GOMAXPROCS(0) + 1}
+	_, warnings, err := NewVerbose(config, dataBlocks(4))
+	if err != nil {
+		t.Fatalf("NewVerbose() error = %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Code != WarningRoutinesCapped {
+		t.Errorf("NewVerbose() warnings = %v, want a routines capped warning", warnings)
+	}
+}
+
+func TestNewVerbose_error(t *testing.T) {
+	if _, _, err := NewVerbose(nil, dataBlocks(1)); err == nil {
+		t.Errorf("NewVerbose() error = nil, want error")
+	}
+}
+
+func TestConfig_AutoParallel(t *testing.T) {
+	config := &Config{AutoParallel: true, RunInParallel: true}
+	small, err := New(config, dataBlocks(10))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if small.RunInParallel {
+		t.Errorf("RunInParallel = true for 10 data blocks, want false")
+	}
+	// The parallelism is decided on the configuration of the tree, not on that of the caller.
+	if !config.RunInParallel {
+		t.Errorf("RunInParallel of the caller configuration = false, want it unchanged")
+	}
+	plan, err := Plan(&Config{AutoParallel: true}, AutoParallelMinBlocks)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if !plan.Parallel {
+		t.Errorf("Plan() Parallel = false for %d data blocks, want true", AutoParallelMinBlocks)
+	}
+	m, err := New(&Config{AutoParallel: true}, dataBlocks(AutoParallelMinBlocks))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !m.RunInParallel {
+		t.Errorf("RunInParallel = false for %d data blocks, want true", AutoParallelMinBlocks)
+	}
+}
+
+func TestWarning_String(t *testing.T) {
+	w := Warning{Code: WarningPadding, Message: "odd levels 0 (3 nodes) are padded"}
+	if got := w.String(); !strings.HasPrefix(got, "padding: ") {
+		t.Errorf("String() = %q, want the code prefix", got)
+	}
+	if got := TypeWarningCode(42).String(); got != "TypeWarningCode(42)" {
+		t.Errorf("String() = %q, want %q", got, "TypeWarningCode(42)")
+	}
+}
@@ -0,0 +1,122 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// RootVerifier verifies data blocks and leaves with their Merkle proofs against the Merkle root and the configuration
+// bound by NewVerifier, e.g. in a hot loop verifying many proofs of the same tree, so that the root and the options
+// cannot be mismatched across the calls, and the state derived from the configuration is computed once.
+// It implements Verifier, and is safe for concurrent use if the hash functions of the configuration are concurrent safe.
+type RootVerifier struct {
+	root   []byte
+	config *Config
+	// fingerprint is the fingerprint of the configuration, checked against the fingerprints embedded in the proofs.
+	fingerprint []byte
+}
+
+var _ Verifier = (*RootVerifier)(nil)
+
+// NewVerifier returns a RootVerifier verifying against the Merkle root with the configuration,
+// as Verify does. The root and the configuration are copied, so they can be modified after.
+func NewVerifier(root []byte, config *Config) (*RootVerifier, error) {
+	if root == nil {
+		return nil, errNilInput("root")
+	}
+	if len(root) == 0 {
+		return nil, errors.New("root is empty")
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if c.RootBytes < 0 {
+		return nil, fmt.Errorf("invalid root length %d", c.RootBytes)
+	}
+	if err := checkLevelHashFunc(&c); err != nil {
+		return nil, err
+	}
+	v := &RootVerifier{root: append([]byte(nil), root...), config: verifyConfig(&c)}
+	var err error
+	if v.fingerprint, err = configFingerprint(v.config); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Verify verifies the data block with its Merkle proof against the root of the verifier, as Verify does.
+func (v *RootVerifier) Verify(dataBlock DataBlock, proof *Proof) (bool, error) {
+	if isNilBlock(dataBlock) {
+		return false, errNilInput("data block")
+	}
+	if err := v.checkProof(proof); err != nil {
+		return false, err
+	}
+	leaf, err := leafFromBlock(dataBlock, proof.Index, v.config)
+	if err != nil {
+		return false, err
+	}
+	return v.verifyLeaf(leaf, proof)
+}
+
+// VerifyLeafHash verifies the leaf, e.g. Leaves[proof.Index] of the Merkle Tree, with its Merkle proof
+// against the root of the verifier, without the data block.
+func (v *RootVerifier) VerifyLeafHash(leafHash []byte, proof *Proof) (bool, error) {
+	if leafHash == nil {
+		return false, errNilInput("leaf hash")
+	}
+	if len(leafHash) == 0 {
+		return false, errors.New("leaf hash is empty")
+	}
+	if err := v.checkProof(proof); err != nil {
+		return false, err
+	}
+	return v.verifyLeaf(leafHash, proof)
+}
+
+// checkProof checks the depth of the proof and its embedded fingerprint, if any, before any hashing.
+func (v *RootVerifier) checkProof(proof *Proof) error {
+	if proof == nil {
+		return errNilInput("proof")
+	}
+	if err := checkProofDepth(proof, v.config); err != nil {
+		return err
+	}
+	if proof.Fingerprint != nil && !bytes.Equal(proof.Fingerprint, v.fingerprint) {
+		return ErrConfigMismatch
+	}
+	return nil
+}
+
+// verifyLeaf computes the root from the leaf and the proof, and compares it with the root of the verifier.
+func (v *RootVerifier) verifyLeaf(leaf []byte, proof *Proof) (bool, error) {
+	result, err := rootFromLeaf(leaf, proof, v.config)
+	if err != nil {
+		return false, err
+	}
+	return rootMatches(result, v.root, v.config), nil
+}
@@ -0,0 +1,197 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestRootVerifier(t *testing.T) {
+	configs := []*Config{
+		nil,
+		{SortSiblingPairs: true, EmbedFingerprint: true},
+		{BindLeafIndex: true, RootBytes: 20},
+		{HashFunc: sha512HashFunc, NoDuplicates: true},
+		{LevelHashFunc: twoLevelHashFunc, PaddingStrategy: PaddingIndexed},
+	}
+	blocks := dataBlocks(13)
+	for i, config := range configs {
+		name := fmt.Sprintf("config %d", i)
+		m, err := New(config, blocks)
+		if err != nil {
+			t.Fatalf("%s: New() error = %v", name, err)
+		}
+		var c *Config
+		if config != nil {
+			copied := *config
+			copied.EmbedFingerprint = false
+			c = &copied
+		}
+		v, err := NewVerifier(m.Root, c)
+		if err != nil {
+			t.Fatalf("%s: NewVerifier() error = %v", name, err)
+		}
+		for idx, block := range blocks {
+			proof := m.Proofs[idx]
+			if ok, err := v.Verify(block, proof); err != nil || !ok {
+				t.Errorf("%s: Verify(%d) = %v, %v, want true", name, idx, ok, err)
+			}
+			if ok, err := v.VerifyLeafHash(m.Leaves[idx], proof); err != nil || !ok {
+				t.Errorf("%s: VerifyLeafHash(%d) = %v, %v, want true", name, idx, ok, err)
+			}
+			other := blocks[(idx+1)%len(blocks)]
+			if ok, err := v.Verify(other, proof); err != nil || ok {
+				t.Errorf("%s: Verify(%d) of another data block = %v, %v, want false", name, idx, ok, err)
+			}
+			if ok, err := v.VerifyLeafHash(m.Leaves[(idx+1)%len(blocks)], proof); err != nil || ok {
+				t.Errorf("%s: VerifyLeafHash(%d) of another leaf = %v, %v, want false", name, idx, ok, err)
+			}
+		}
+	}
+}
+
+func TestRootVerifier_boundState(t *testing.T) {
+	blocks := dataBlocks(6)
+	config := &Config{EmbedFingerprint: true}
+	m, err := New(config, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	root := append([]byte(nil), m.Root...)
+	verifierConfig := &Config{}
+	v, err := NewVerifier(root, verifierConfig)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	// The root and the configuration are copied by NewVerifier.
+	root[0] ^= 1
+	verifierConfig.SortSiblingPairs = true
+	if ok, err := v.Verify(blocks[2], m.Proofs[2]); err != nil || !ok {
+		t.Errorf("Verify() after modifying the root and the configuration = %v, %v, want true", ok, err)
+	}
+	sorted, err := NewVerifier(m.Root, &Config{SortSiblingPairs: true})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	if _, err := sorted.Verify(blocks[2], m.Proofs[2]); !errors.Is(err, ErrConfigMismatch) {
+		t.Errorf("Verify() with another configuration: error = %v, want ErrConfigMismatch", err)
+	}
+}
+
+func TestRootVerifier_concurrent(t *testing.T) {
+	blocks := dataBlocks(64)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	v, err := NewVerifier(m.Root, nil)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(blocks))
+	for idx := range blocks {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			if ok, err := v.Verify(blocks[idx], m.Proofs[idx]); err != nil || !ok {
+				errs <- fmt.Errorf("Verify(%d) = %v, %v, want true", idx, ok, err)
+			}
+		}(idx)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestNewVerifier_errors(t *testing.T) {
+	blocks := dataBlocks(4)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := NewVerifier(nil, nil); err == nil {
+		t.Error("NewVerifier() of an empty root: error = nil")
+	}
+	if _, err := NewVerifier(m.Root, &Config{RootBytes: -1}); err == nil {
+		t.Error("NewVerifier() with a negative RootBytes: error = nil")
+	}
+	if _, err := NewVerifier(m.Root, &Config{LevelHashFunc: twoLevelHashFunc, PaddingStrategy: PaddingPromote}); err == nil {
+		t.Error("NewVerifier() with LevelHashFunc and PaddingPromote: error = nil")
+	}
+	v, err := NewVerifier(m.Root, nil)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	if _, err := v.Verify(nil, m.Proofs[0]); err == nil {
+		t.Error("Verify() of a nil data block: error = nil")
+	}
+	if _, err := v.Verify(blocks[0], nil); err == nil {
+		t.Error("Verify() of a nil proof: error = nil")
+	}
+	if _, err := v.VerifyLeafHash(nil, m.Proofs[0]); err == nil {
+		t.Error("VerifyLeafHash() of an empty leaf hash: error = nil")
+	}
+	deep := &Proof{Siblings: make([][]byte, DefaultMaxProofDepth+1)}
+	if _, err := v.VerifyLeafHash(m.Leaves[0], deep); !errors.Is(err, ErrProofTooDeep) {
+		t.Errorf("VerifyLeafHash() of a deep proof: error = %v, want ErrProofTooDeep", err)
+	}
+}
+
+func BenchmarkVerify_rootVerifier(b *testing.B) {
+	blocks := dataBlocks(1 << 10)
+	config := &Config{SortSiblingPairs: true, EmbedFingerprint: true}
+	m, err := New(config, blocks)
+	if err != nil {
+		b.Fatal(err)
+	}
+	block, proof := blocks[len(blocks)/3].(*mock.DataBlock), m.Proofs[len(blocks)/3]
+	b.Run("package", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if ok, err := Verify(block, proof, m.Root, &Config{SortSiblingPairs: true}); err != nil || !ok {
+				b.Fatal(ok, err)
+			}
+		}
+	})
+	b.Run("verifier", func(b *testing.B) {
+		v, err := NewVerifier(m.Root, &Config{SortSiblingPairs: true})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if ok, err := v.Verify(block, proof); err != nil || !ok {
+				b.Fatal(ok, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha512"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestVerifyFile(t *testing.T) {
+	blocks := dataBlocks(9)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "leaf")
+	if err := os.WriteFile(path, blocks[4].(*mock.DataBlock).Data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	otherPath := filepath.Join(dir, "other")
+	if err := os.WriteFile(otherPath, blocks[5].(*mock.DataBlock).Data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	tagLeaf := func(data []byte) ([]byte, error) {
+		return append([]byte("leaf:"), data...), nil
+	}
+	tests := []struct {
+		name         string
+		config       *Config
+		verifyConfig *Config
+	}{
+		{"default", nil, nil},
+		{"bind_leaf_index", &Config{BindLeafIndex: true}, &Config{BindLeafIndex: true}},
+		{"hasher", &Config{Hasher: sha512.New}, &Config{Hasher: sha512.New, ChunkSize: 7}},
+		{"fingerprint", &Config{EmbedFingerprint: true, SortSiblingPairs: true}, &Config{SortSiblingPairs: true}},
+		{"custom_hash_func", &Config{HashFunc: sha512HashFunc}, &Config{HashFunc: sha512HashFunc}},
+		{"disable_leaf_hashing", &Config{DisableLeafHashing: true}, &Config{DisableLeafHashing: true}},
+		{"leaf_transform", &Config{LeafTransform: tagLeaf}, &Config{LeafTransform: tagLeaf}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			ok, err := VerifyFile(path, m.Proofs[4], m.Root, tt.verifyConfig)
+			if err != nil || !ok {
+				t.Errorf("VerifyFile() = %v, %v, want true", ok, err)
+			}
+			if ok, err := VerifyFile(otherPath, m.Proofs[4], m.Root, tt.verifyConfig); err != nil || ok {
+				t.Errorf("VerifyFile() of another file = %v, %v, want false", ok, err)
+			}
+		})
+	}
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The default hash function set by New is streamed as SHA256.
+	if ok, err := VerifyFile(path, m.Proofs[4], m.Root, m.Config); err != nil || !ok {
+		t.Errorf("VerifyFile() with the tree configuration = %v, %v, want true", ok, err)
+	}
+	errTests := []struct {
+		name   string
+		path   string
+		config *Config
+	}{
+		{"missing_file", filepath.Join(dir, "missing"), nil},
+		{"directory", dir, nil},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyFile(tt.path, m.Proofs[4], m.Root, tt.config); err == nil {
+				t.Errorf("VerifyFile() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// VerifyFull verifies the data blocks against the Merkle root by recomputing the root from all of them,
+// an all-or-nothing check that needs no proof. The root is computed with a StreamBuilder, so the memory is
+// O(log n), unless the duplicate leaves are errors or collapsed, which requires the leaves of a tree.
+// A single data block is the degenerate tree whose root is its leaf, i.e. the root verified by the proof
+// without siblings. AdditionalHashFuncs and ProofIndices are ignored, and the configuration is not modified.
+func VerifyFull(blocks []DataBlock, root []byte, config *Config) (bool, error) {
+	if len(blocks) == 0 {
+		return false, errors.New("the number of data blocks must be greater than 0")
+	}
+	if root == nil {
+		return false, errNilInput("root")
+	}
+	for i, block := range blocks {
+		if isNilBlock(block) {
+			return false, errNilInput(fmt.Sprintf("data block %d", i))
+		}
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	c.AdditionalHashFuncs, c.ProofIndices = nil, nil
+	if len(blocks) == 1 {
+		return Verify(blocks[0], &Proof{}, root, &c)
+	}
+	var (
+		result []byte
+		err    error
+	)
+	if c.OnDuplicateLeaf == DuplicateLeafAllow {
+		result, err = ComputeRoot(&c, sliceIterator(blocks))
+	} else {
+		// No proof is generated, as none is selected.
+		c.Mode, c.ProofIndices = ModeProofGen, []int{}
+		var m *MerkleTree
+		if m, err = New(&c, blocks); err == nil {
+			result = m.Root
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return rootMatches(result, root, &c), nil
+}
+
+// sliceIterator returns the next function of ComputeRoot iterating over the data blocks.
+func sliceIterator(blocks []DataBlock) func() (DataBlock, error) {
+	var i int
+	return func() (DataBlock, error) {
+		if i == len(blocks) {
+			return nil, io.EOF
+		}
+		i++
+		return blocks[i-1], nil
+	}
+}
@@ -0,0 +1,102 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+// corruptBlocks returns a copy of the data blocks with one byte of the data block at the index flipped.
+func corruptBlocks(blocks []DataBlock, idx int) []DataBlock {
+	corrupted := append([]DataBlock(nil), blocks...)
+	data := append([]byte(nil), blocks[idx].(*mock.DataBlock).Data...)
+	data[len(data)/2] ^= 1
+	corrupted[idx] = &mock.DataBlock{Data: data}
+	return corrupted
+}
+
+func TestVerifyFull(t *testing.T) {
+	configs := []*Config{
+		nil,
+		{PaddingStrategy: PaddingIndexed, RootBytes: 20},
+		{NoDuplicates: true, BindLeafIndex: true},
+		{OnDuplicateLeaf: DuplicateLeafCollapse},
+		{AdditionalHashFuncs: []TypeHashFunc{sha512HashFunc}, Mode: ModeTreeBuild},
+	}
+	for _, numBlocks := range []int{2, 5, 100} {
+		blocks := dataBlocks(numBlocks)
+		if numBlocks > 2 {
+			blocks[numBlocks-1] = blocks[0] // The leaves have a duplicate.
+		}
+		for i, config := range configs {
+			name := fmt.Sprintf("%d blocks/config %d", numBlocks, i)
+			m, err := New(config, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			if ok, err := VerifyFull(blocks, m.Root, config); err != nil || !ok {
+				t.Errorf("%s: VerifyFull() = %v, %v, want true", name, ok, err)
+			}
+			for _, idx := range []int{0, numBlocks / 2} {
+				if ok, err := VerifyFull(corruptBlocks(blocks, idx), m.Root, config); err != nil || ok {
+					t.Errorf("%s: VerifyFull() with block %d corrupted = %v, %v, want false", name, idx, ok, err)
+				}
+			}
+			missing := append([]DataBlock{blocks[0]}, blocks[2:]...)
+			if ok, err := VerifyFull(missing, m.Root, config); err != nil || ok {
+				t.Errorf("%s: VerifyFull() with a missing block = %v, %v, want false", name, ok, err)
+			}
+		}
+	}
+}
+
+func TestVerifyFull_singleBlock(t *testing.T) {
+	blocks := dataBlocks(1)
+	leaf, err := leafFromBlock(blocks[0], 0, verifyConfig(nil))
+	if err != nil {
+		t.Fatalf("leafFromBlock() error = %v", err)
+	}
+	if ok, err := VerifyFull(blocks, leaf, nil); err != nil || !ok {
+		t.Errorf("VerifyFull() of a single block = %v, %v, want true", ok, err)
+	}
+	if ok, err := VerifyFull(corruptBlocks(blocks, 0), leaf, nil); err != nil || ok {
+		t.Errorf("VerifyFull() of a corrupted single block = %v, %v, want false", ok, err)
+	}
+}
+
+func TestVerifyFull_errors(t *testing.T) {
+	blocks := dataBlocks(4)
+	if _, err := VerifyFull(nil, []byte("root"), nil); err == nil {
+		t.Error("VerifyFull() of no data blocks: error = nil")
+	}
+	if _, err := VerifyFull([]DataBlock{blocks[0], nil}, []byte("root"), nil); err == nil {
+		t.Error("VerifyFull() with a nil data block: error = nil")
+	}
+	blocks[3] = blocks[1]
+	if _, err := VerifyFull(blocks, []byte("root"), &Config{OnDuplicateLeaf: DuplicateLeafError}); err == nil {
+		t.Error("VerifyFull() with a rejected duplicate leaf: error = nil")
+	}
+}
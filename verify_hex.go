@@ -0,0 +1,63 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidHex is returned by VerifyHex when the root or a sibling is not a valid hex string,
+// as opposed to a proof that fails the verification.
+var ErrInvalidHex = errors.New("invalid hex string")
+
+// VerifyHex verifies the serialized data block against the hex-encoded Merkle root with the hex-encoded siblings,
+// ordered from the leaf up to the root. directions[i] reports whether the node at level i is the left child,
+// i.e. whether the sibling at level i is on the right, as the bits of Proof.Path.
+// Malformed hex strings return ErrInvalidHex, distinct from a failed verification.
+func VerifyHex(blockBytes []byte, proofHexSiblings []string, directions []bool, rootHex string,
+	config *Config) (bool, error) {
+	if len(directions) != len(proofHexSiblings) {
+		return false, fmt.Errorf("got %d directions for %d siblings", len(directions), len(proofHexSiblings))
+	}
+	if len(directions) > 32 {
+		return false, fmt.Errorf("%w: %d siblings, the path holds at most 32", ErrProofTooDeep, len(directions))
+	}
+	root, err := hex.DecodeString(rootHex)
+	if err != nil {
+		return false, fmt.Errorf("%w: root: %v", ErrInvalidHex, err)
+	}
+	proof := &Proof{Siblings: make([][]byte, len(proofHexSiblings))}
+	for i, sib := range proofHexSiblings {
+		if proof.Siblings[i], err = hex.DecodeString(sib); err != nil {
+			return false, fmt.Errorf("%w: sibling at level %d: %v", ErrInvalidHex, i, err)
+		}
+		if directions[i] {
+			proof.Path |= 1 << i
+		} else {
+			proof.Index |= 1 << i
+		}
+	}
+	return Verify(byteBlock(blockBytes), proof, root, config)
+}
@@ -0,0 +1,123 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestVerifyHex(t *testing.T) {
+	blocks := dataBlocks(11)
+	tree, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	const idx = 6
+	blockBytes, err := blocks[idx].Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	proof := tree.Proofs[idx]
+	siblings := make([]string, len(proof.Siblings))
+	directions := make([]bool, len(proof.Siblings))
+	for i, sib := range proof.Siblings {
+		siblings[i] = hex.EncodeToString(sib)
+		directions[i] = (proof.Path>>i)&1 == 1
+	}
+	rootHex := hex.EncodeToString(tree.Root)
+	withSibling := func(i int, sib string) []string {
+		s := append([]string(nil), siblings...)
+		s[i] = sib
+		return s
+	}
+	tests := []struct {
+		name       string
+		siblings   []string
+		directions []bool
+		rootHex    string
+		want       bool
+		wantErr    bool
+		wantHexErr bool
+	}{
+		{
+			name:       "test_ok",
+			siblings:   siblings,
+			directions: directions,
+			rootHex:    rootHex,
+			want:       true,
+		},
+		{
+			name:       "test_wrong_direction",
+			siblings:   siblings,
+			directions: append([]bool{!directions[0]}, directions[1:]...),
+			rootHex:    rootHex,
+			want:       false,
+		},
+		{
+			name:       "test_wrong_sibling",
+			siblings:   withSibling(1, siblings[2]),
+			directions: directions,
+			rootHex:    rootHex,
+			want:       false,
+		},
+		{
+			name:       "test_malformed_root",
+			siblings:   siblings,
+			directions: directions,
+			rootHex:    rootHex[:len(rootHex)-1],
+			wantErr:    true,
+			wantHexErr: true,
+		},
+		{
+			name:       "test_malformed_sibling",
+			siblings:   withSibling(2, "zz"+siblings[2][2:]),
+			directions: directions,
+			rootHex:    rootHex,
+			wantErr:    true,
+			wantHexErr: true,
+		},
+		{
+			name:       "test_directions_mismatch",
+			siblings:   siblings,
+			directions: directions[1:],
+			rootHex:    rootHex,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyHex(blockBytes, tt.siblings, tt.directions, tt.rootHex, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyHex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrInvalidHex) != tt.wantHexErr {
+				t.Errorf("VerifyHex() error = %v, want ErrInvalidHex %v", err, tt.wantHexErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyHex() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
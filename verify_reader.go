@@ -0,0 +1,103 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"crypto/sha256"
+	"io"
+)
+
+// VerifyReader verifies that the content read from the reader until io.EOF is the data block of the proof
+// against the Merkle root, e.g. a data block received from a network stream.
+// The leaf is computed as Verify does with the configuration, including BindLeafIndex and LeafTransform.
+// The content is streamed through the Hasher of the configuration, or the default SHA256 if neither Hasher
+// nor a custom HashFunc is set, in ChunkSize increments if ChunkSize is set, without being read into memory.
+// Otherwise, i.e. with a custom HashFunc and no Hasher, LeafTransform, LevelHashFunc or DisableLeafHashing,
+// which need the whole serialized data block, the content is read into memory first.
+func VerifyReader(r io.Reader, proof *Proof, root []byte, config *Config) (bool, error) {
+	if r == nil {
+		return false, errNilInput("reader")
+	}
+	if proof == nil {
+		return false, errNilInput("proof")
+	}
+	if root == nil {
+		return false, errNilInput("root")
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	streamed := !c.DisableLeafHashing && c.LeafTransform == nil && c.LevelHashFunc == nil
+	if streamed && c.Hasher == nil {
+		if streamed = c.HashFunc == nil || isDefaultHashFunc(c.HashFunc); streamed {
+			c.Hasher = sha256.New
+		}
+	}
+	config = verifyConfig(&c)
+	if err := checkProofDepth(proof, config); err != nil {
+		return false, err
+	}
+	if err := checkFingerprint(proof.Fingerprint, config); err != nil {
+		return false, err
+	}
+	var (
+		leaf []byte
+		err  error
+	)
+	if streamed {
+		leaf, err = streamLeaf(r, proof.Index, config)
+	} else {
+		var blockBytes []byte
+		if blockBytes, err = io.ReadAll(r); err != nil {
+			return false, err
+		}
+		leaf, err = leafFromBytes(blockBytes, proof.Index, config)
+	}
+	if err != nil {
+		return false, err
+	}
+	result, err := rootFromLeaf(leaf, proof, config)
+	if err != nil {
+		return false, err
+	}
+	return rootMatches(result, root, config), nil
+}
+
+// streamLeaf computes the leaf at the leaf index by streaming the reader into the Hasher of the configuration,
+// in ChunkSize increments if ChunkSize is set.
+func streamLeaf(r io.Reader, idx int, config *Config) ([]byte, error) {
+	h, err := newLeafHash(config, idx)
+	if err != nil {
+		return nil, err
+	}
+	var buf []byte
+	if config.ChunkSize > 0 {
+		buf = make([]byte, config.ChunkSize)
+	}
+	// The hash is wrapped so that io.CopyBuffer uses the buffer instead of a ReaderFrom of the reader.
+	if _, err := io.CopyBuffer(struct{ io.Writer }{h}, r, buf); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
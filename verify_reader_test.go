@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
+	"testing"
+	"testing/iotest"
+
+	"github.com/txaty/go-merkletree/mock"
+)
+
+func TestVerifyReader(t *testing.T) {
+	blocks := dataBlocks(9)
+	leafPrefix := func(blockBytes []byte) ([]byte, error) {
+		return append([]byte("leaf:"), blockBytes...), nil
+	}
+	tests := []struct {
+		name         string
+		config       *Config
+		verifyConfig *Config
+	}{
+		{"default", nil, nil},
+		{"bind_leaf_index", &Config{BindLeafIndex: true}, &Config{BindLeafIndex: true}},
+		{"hasher", &Config{Hasher: sha512.New}, &Config{Hasher: sha512.New, ChunkSize: 7}},
+		{"custom_hash_func", &Config{HashFunc: sha512HashFunc}, &Config{HashFunc: sha512HashFunc}},
+		{"leaf_transform", &Config{LeafTransform: leafPrefix, BindLeafIndex: true},
+			&Config{LeafTransform: leafPrefix, BindLeafIndex: true}},
+		{"disable_leaf_hashing", &Config{DisableLeafHashing: true}, &Config{DisableLeafHashing: true}},
+		{"fingerprint", &Config{EmbedFingerprint: true, SortSiblingPairs: true}, &Config{SortSiblingPairs: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.config, blocks)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			r := bytes.NewReader(blocks[4].(*mock.DataBlock).Data)
+			if ok, err := VerifyReader(r, m.Proofs[4], m.Root, tt.verifyConfig); err != nil || !ok {
+				t.Errorf("VerifyReader() = %v, %v, want true", ok, err)
+			}
+			other := bytes.NewReader(blocks[5].(*mock.DataBlock).Data)
+			if ok, err := VerifyReader(other, m.Proofs[4], m.Root, tt.verifyConfig); err != nil || ok {
+				t.Errorf("VerifyReader() of another data block = %v, %v, want false", ok, err)
+			}
+		})
+	}
+}
+
+func TestVerifyReader_errors(t *testing.T) {
+	blocks := dataBlocks(4)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	readErr := errors.New("connection reset")
+	data := blocks[1].(*mock.DataBlock).Data
+	if _, err := VerifyReader(nil, m.Proofs[1], m.Root, nil); err == nil {
+		t.Error("VerifyReader() of a nil reader: error = nil")
+	}
+	if _, err := VerifyReader(bytes.NewReader(data), nil, m.Root, nil); err == nil {
+		t.Error("VerifyReader() of a nil proof: error = nil")
+	}
+	for _, config := range []*Config{nil, {HashFunc: sha512HashFunc}} {
+		if _, err := VerifyReader(iotest.ErrReader(readErr), m.Proofs[1], m.Root, config); !errors.Is(err, readErr) {
+			t.Errorf("VerifyReader() of a failing reader: error = %v, want %v", err, readErr)
+		}
+	}
+}
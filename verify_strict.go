@@ -0,0 +1,143 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrProofPosition is returned by VerifyStrict if the proof is not the proof of the claimed leaf index
+// in a Merkle Tree of the claimed number of leaves.
+var ErrProofPosition = errors.New("proof does not match the leaf position")
+
+// VerifyStrict verifies the data block with the proof against the Merkle root like Verify, pinning the position
+// of the leaf and the size of the tree: the proof must be the proof of the leaf at the index in a Merkle Tree
+// of numLeaves leaves, i.e. NumLeaves of the Merkle Tree, which excludes the padding leaves of PaddingIndexed.
+// The index of the proof, its number of siblings and the bits of its path must be exactly those implied
+// by the index and numLeaves under the padding strategy of the configuration, and at the odd levels
+// where the node of the leaf is the last node, the sibling must be the padding node derived from it,
+// so that e.g. the proof of the second to last leaf of a tree of 6 leaves is rejected for 5 leaves.
+// The size is checked as far as the proof reveals it: the siblings off the right edge of the tree hide the sizes
+// of their subtrees, so the proof of a leaf whose path never reaches the last node of an odd level
+// is the same in the trees of the same depth.
+// A proof of another position or tree size returns an error wrapping ErrProofPosition, not false.
+func VerifyStrict(dataBlock DataBlock, proof *Proof, index, numLeaves int, root []byte, config *Config) (bool, error) {
+	if err := checkVerifyInput(dataBlock, proof, root); err != nil {
+		return false, err
+	}
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if err := checkProofShape(proof, index, numLeaves, &c); err != nil {
+		return false, err
+	}
+	ok, leaf, err := VerifyReturningLeaf(dataBlock, proof, root, &c)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err = checkPaddingSiblings(leaf, proof, numLeaves, &c); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkProofShape checks that the proof has the index, the number of siblings and the path bits
+// of the proof of the leaf at the index in a Merkle Tree of numLeaves leaves.
+func checkProofShape(proof *Proof, index, numLeaves int, config *Config) error {
+	if numLeaves < 2 {
+		return fmt.Errorf("%w: invalid number of leaves %d", ErrProofPosition, numLeaves)
+	}
+	if index < 0 || index >= numLeaves {
+		return fmt.Errorf("%w: leaf index %d is out of range [0, %d)", ErrProofPosition, index, numLeaves)
+	}
+	if proof.Index != index {
+		return fmt.Errorf("%w: proof of leaf %d, want leaf %d", ErrProofPosition, proof.Index, index)
+	}
+	m := &MerkleTree{Config: config, NumLeaves: numLeaves, Depth: calTreeDepth(numLeaves)}
+	levels := m.siblingLevels(index)
+	if len(proof.Siblings) != len(levels) {
+		return fmt.Errorf("%w: proof has %d siblings, want %d for leaf %d of %d leaves",
+			ErrProofPosition, len(proof.Siblings), len(levels), index, numLeaves)
+	}
+	var path uint32
+	for i, level := range levels {
+		if (index>>level)&1 == 0 {
+			path |= 1 << i
+		}
+	}
+	if proof.RootToLeaf {
+		proof = proof.Reverse()
+	}
+	if proof.Path != path {
+		return fmt.Errorf("%w: proof path %b, want %b for leaf %d of %d leaves",
+			ErrProofPosition, proof.Path, path, index, numLeaves)
+	}
+	return nil
+}
+
+// checkPaddingSiblings checks that the siblings of the proof of the leaf at the odd levels where the node
+// of the leaf is the last node are the padding nodes derived from the node by fixOdd.
+// With PaddingPromote, the odd levels are not padded, and the missing siblings are checked by checkProofShape.
+// With PaddingIndexed, the leaf level is padded to a power of two, so no level is odd.
+func checkPaddingSiblings(leaf []byte, proof *Proof, numLeaves int, config *Config) error {
+	if config.PaddingStrategy == PaddingPromote || config.PaddingStrategy == PaddingIndexed {
+		return nil
+	}
+	if proof.RootToLeaf {
+		proof = proof.Reverse()
+	}
+	config = verifyConfig(config)
+	node := leaf
+	for level, sibling := range proof.Siblings {
+		idx, levelLen := proof.Index>>level, (numLeaves+1<<level-1)>>level
+		if idx == levelLen-1 && levelLen&1 == 1 {
+			padding := node
+			if config.NoDuplicates {
+				var err error
+				if padding, err = config.noDuplicatesFiller(config.HashFunc, node); err != nil {
+					return err
+				}
+			}
+			if !bytes.Equal(sibling, padding) {
+				return fmt.Errorf("%w: sibling at level %d is not the padding node of the last node of %d leaves",
+					ErrProofPosition, level, numLeaves)
+			}
+		}
+		if level == len(proof.Siblings)-1 {
+			break
+		}
+		var err error
+		if idx&1 == 0 {
+			node, err = config.pairHash(level+1, node, sibling)
+		} else {
+			node, err = config.pairHash(level+1, sibling, node)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// MIT License
+//
+// Copyright (c) 2023 Tommy TIAN
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVerifyStrict(t *testing.T) {
+	for _, numBlocks := range []int{2, 3, 5, 6, 9, 16, 17} {
+		blocks := dataBlocks(numBlocks)
+		for _, config := range []Config{
+			{},
+			{SortSiblingPairs: true},
+			{NoDuplicates: true},
+			{PaddingStrategy: PaddingPromote},
+			{PaddingStrategy: PaddingIndexed},
+			{StoreReversedProofs: true},
+			{DisableLeafHashing: true},
+		} {
+			config := config
+			name := fmt.Sprintf("%d blocks/%+v", numBlocks, config)
+			m, err := New(&config, blocks)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			proofs := m.Proofs
+			if config.StoreReversedProofs {
+				proofs = m.ReversedProofs
+			}
+			for i, block := range blocks {
+				if ok, err := VerifyStrict(block, proofs[i], i, m.NumLeaves, m.Root, &config); err != nil || !ok {
+					t.Errorf("%s: VerifyStrict() of leaf %d = %v, %v, want true", name, i, ok, err)
+				}
+				for _, claim := range [][2]int{{i + 1, m.NumLeaves}, {i - 1, m.NumLeaves}, {i, -1}} {
+					if ok, err := VerifyStrict(block, proofs[i], claim[0], claim[1], m.Root, &config); ok ||
+						!errors.Is(err, ErrProofPosition) {
+						t.Errorf("%s: VerifyStrict() of leaf %d claimed as leaf %d of %d = %v, %v, want %v",
+							name, i, claim[0], claim[1], ok, err, ErrProofPosition)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyStrict_wrongSize(t *testing.T) {
+	// The proofs of a tree of 6 leaves are rejected for trees of other depths, and the proof of leaf 4,
+	// which is the last node of an odd level in a tree of 5 leaves, is rejected for 5 leaves.
+	blocks := dataBlocks(6)
+	for _, config := range []Config{{}, {NoDuplicates: true}, {PaddingStrategy: PaddingPromote}} {
+		config := config
+		m, err := New(&config, blocks)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		for i, block := range blocks {
+			for _, numLeaves := range []int{2, 3, 4, 9, 16} {
+				if i >= numLeaves {
+					continue
+				}
+				if ok, err := VerifyStrict(block, m.Proofs[i], i, numLeaves, m.Root, &config); ok ||
+					!errors.Is(err, ErrProofPosition) {
+					t.Errorf("%v: VerifyStrict() of leaf %d of 6 claimed in %d = %v, %v, want %v",
+						config.PaddingStrategy, i, numLeaves, ok, err, ErrProofPosition)
+				}
+			}
+		}
+		if ok, err := VerifyStrict(blocks[4], m.Proofs[4], 4, 5, m.Root, &config); ok || !errors.Is(err, ErrProofPosition) {
+			t.Errorf("%v: VerifyStrict() of leaf 4 of 6 claimed in 5 = %v, %v, want %v",
+				config.PaddingStrategy, ok, err, ErrProofPosition)
+		}
+	}
+}
+
+func TestVerifyStrict_invalid(t *testing.T) {
+	blocks := dataBlocks(5)
+	m, err := New(nil, blocks)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if ok, err := VerifyStrict(blocks[1], m.Proofs[0], 0, 5, m.Root, nil); ok || err != nil {
+		t.Errorf("VerifyStrict() of another block = %v, %v, want false, nil", ok, err)
+	}
+	if _, err := VerifyStrict(blocks[0], nil, 0, 5, m.Root, nil); !errors.Is(err, ErrNilProof) {
+		t.Errorf("VerifyStrict() of a nil proof error = %v, want %v", err, ErrNilProof)
+	}
+	tampered := *m.Proofs[2]
+	tampered.Path ^= 1 << 2
+	if _, err := VerifyStrict(blocks[2], &tampered, 2, 5, m.Root, nil); !errors.Is(err, ErrProofPosition) {
+		t.Errorf("VerifyStrict() of a proof with a flipped path bit error = %v, want %v", err, ErrProofPosition)
+	}
+	indexed := &Config{PaddingStrategy: PaddingIndexed}
+	if m, err = New(indexed, blocks); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The number of leaves excludes the padding leaves, and the proofs of the padding leaves are rejected.
+	if ok, err := VerifyStrict(blocks[4], m.Proofs[4], 4, 5, m.Root, indexed); err != nil || !ok {
+		t.Errorf("VerifyStrict() of leaf 4 of 5 padded leaves = %v, %v, want true", ok, err)
+	}
+	proof, err := m.ProofOf(5)
+	if err != nil {
+		t.Fatalf("ProofOf() error = %v", err)
+	}
+	if _, err := VerifyStrict(blocks[0], proof, 5, 5, m.Root, indexed); !errors.Is(err, ErrProofPosition) {
+		t.Errorf("VerifyStrict() of a padding leaf error = %v, want %v", err, ErrProofPosition)
+	}
+	if _, err := VerifyStrict(blocks[0], m.Proofs[0], 0, 3, m.Root, indexed); !errors.Is(err, ErrProofPosition) {
+		t.Errorf("VerifyStrict() of 5 padded leaves claimed in 3 error = %v, want %v", err, ErrProofPosition)
+	}
+}